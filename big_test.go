@@ -1,12 +1,17 @@
+//go:build !purego
+
 package getdata
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/joefowler/gogetdata/internal/testdirfile"
 )
 
 func createTestDirfile(dir string) {
@@ -18,55 +23,9 @@ func createTestDirfile(dir string) {
 	if err != nil {
 		log.Fatal("Could not create dirfile: ", err)
 	}
-	data := make([]byte, 80)
-	for i := 0; i < 80; i++ {
-		data[i] = byte(i + 1)
-	}
-	f, err := os.Create(fmt.Sprintf("%s/data", dir))
-	if err != nil {
-		log.Fatal("Could not create dirfile/data")
-	}
-	f.Write(data)
-	f.Close()
-
-	f, err = os.Create(fmt.Sprintf("%s/format", dir))
-	if err != nil {
-		log.Fatal("Could not create dirfile/format")
-	}
-	fmt.Fprintf(f, `/ENDIAN little
-data RAW INT8 8
-lincom LINCOM data 1.1 2.2 INDEX 2.2 3.3;4.4 linterp const const
-/META data mstr STRING "This is a string constant."
-/META data mconst CONST COMPLEX128 3.3;4.4
-/META data mcarray CARRAY FLOAT64 1.9 2.8 3.7 4.6 5.5
-/META data mlut LINTERP DATA ./lut
-const CONST FLOAT64 5.5
-carray CARRAY FLOAT64 1.1 2.2 3.3 4.4 5.5 6.6
-linterp LINTERP data ./lut
-polynom POLYNOM data 1.1 2.2 2.2 3.3;4.4 const const
-bit BIT data 3 4
-sbit SBIT data 5 6
-mplex MPLEX data sbit 1 10
-mult MULTIPLY data sbit
-div DIVIDE mult bit
-recip RECIP div 6.5;4.3
-phase PHASE data 11
-window WINDOW linterp mult LT 4.1
-/ALIAS alias data
-string STRING "Zaphod Beeblebrox"
-sarray SARRAY one two three four five six seven
-data/msarray SARRAY eight nine ten eleven twelve
-indir INDIR data carray
-sindir SINDIR data sarray
-`)
-	f.Close()
-
-	f, err = os.Create(fmt.Sprintf("%s/form2", dir))
-	if err != nil {
-		log.Fatal("Could not create dirfile/form2")
-	}
-	fmt.Fprintf(f, "const2 CONST INT8 -19")
-	f.Close()
+	if err := testdirfile.Write(dir, testdirfile.Format); err != nil {
+		log.Fatal("Could not write dirfile: ", err)
+	}
 }
 
 func removeTestDirfile(dir string) {
@@ -453,6 +412,17 @@ func TestRead(t *testing.T) {
 	} else if err.Error() != e38 {
 		t.Errorf("GetData on non-existent field gives error string\n\t%s\nwant\n\t%s",
 			err.Error(), e38)
+	} else {
+		if !errors.Is(err, ErrBadCode) {
+			t.Errorf("GetData on non-existent field gives error %v, want ErrBadCode", err)
+		}
+		var gderr *Error
+		if !errors.As(err, &gderr) {
+			t.Errorf("GetData on non-existent field gives error of type %T, want *Error", err)
+		} else if gderr.Op != "GetData" || gderr.Field != "xyz" {
+			t.Errorf("GetData on non-existent field gives Op=%q, Field=%q, want \"GetData\", \"xyz\"",
+				gderr.Op, gderr.Field)
+		}
 	}
 
 	// #40: Entry (raw) check
@@ -1905,6 +1875,14 @@ func TestRead(t *testing.T) {
 		}
 	}
 
+	// AliasTarget check
+	if target, err1 := d.AliasTarget("new20"); err1 != nil || target != "data" {
+		t.Errorf("AliasTarget(new20) = %q (%v), want data", target, err1)
+	}
+	if _, err1 := d.AliasTarget("data"); err1 == nil {
+		t.Error("AliasTarget of a field that is not an alias did not return error")
+	}
+
 	// #221: NAliases check
 	expect222 := []string{"data", "alias", "new20"}
 	// expect222 := []string{"data", "alias", "data/mnew20", "new20"}
@@ -1944,6 +1922,14 @@ func TestRead(t *testing.T) {
 		if frag226.Suffix() != suffix1 {
 			t.Errorf("Fragment(1) has suffix \"%s\", want \"%s\"", frag226.Suffix(), suffix1)
 		}
+		if frag226.Index() != 1 || frag226.Parent() != 0 || !strings.HasSuffix(frag226.Name(), "format1") {
+			t.Errorf("Fragment(1) has index %d, parent %d, name %s, want 1, 0, .../format1",
+				frag226.Index(), frag226.Parent(), frag226.Name())
+		}
+		if frag226.Protection() != PROTECTNONE || frag226.Namespace() != "" {
+			t.Errorf("Fragment(1) has protection %d, namespace %q, want none and empty",
+				frag226.Protection(), frag226.Namespace())
+		}
 	}
 
 	// #227: Change fragment prefix/suffix
@@ -2348,3 +2334,168 @@ func TestRead(t *testing.T) {
 		t.Errorf("Could not discard dirfile read-only")
 	}
 }
+
+func TestMAdd(t *testing.T) {
+	dir := "dirfile_madd"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Discard()
+
+	// MAddLincom check
+	if err = d.MAddLincom("data", "mnew1", []string{"in1", "in2"},
+		[]float64{9.9, 7.7}, []float64{8.8, 6.6}); err != nil {
+		t.Error("Could not MAddLincom:", err)
+	} else if e, err := d.Entry("data/mnew1"); err != nil {
+		t.Error("Could not read Entry data/mnew1:", err)
+	} else if e.Type() != LINCOMENTRY {
+		t.Errorf("Entry data/mnew1 gets field type 0x%x, want LINCOM=0x%x", e.Type(), LINCOMENTRY)
+	} else if m, _ := e.Scale(1); m != 7.7 {
+		t.Errorf("Entry data/mnew1 gets m[1]=%f, want 7.7", m)
+	}
+
+	// MAddBit check
+	if err = d.MAddBit("data", "mnew2", "in1", 13, 12); err != nil {
+		t.Error("Could not MAddBit:", err)
+	} else if e, err := d.Entry("data/mnew2"); err != nil {
+		t.Error("Could not read Entry data/mnew2:", err)
+	} else if bitnum, numbits, _ := e.BitRange(); bitnum != 13 || numbits != 12 {
+		t.Errorf("Entry data/mnew2 gets bit range %d, %d, want 13, 12", bitnum, numbits)
+	}
+
+	// MAddConst and MAddString check: calibration metadata for a RAW field
+	if err = d.MAddConst("data", "gain", FLOAT64, 3.25); err != nil {
+		t.Error("Could not MAddConst:", err)
+	} else if c, err := d.GetConstantFloat64("data/gain"); err != nil || c != 3.25 {
+		t.Errorf("GetConstantFloat64(\"data/gain\") returns %f, %v, want 3.25, nil", c, err)
+	}
+	if err = d.MAddString("data", "units", "volts"); err != nil {
+		t.Error("Could not MAddString:", err)
+	} else if s, err := d.GetString("data/units"); err != nil || s != "volts" {
+		t.Errorf("GetString(\"data/units\") returns %q, %v, want \"volts\", nil", s, err)
+	}
+
+	// MAddCarray and MAddSarray check
+	if err = d.MAddCarray("data", "mnew3", INT16, []int16{3, 4, 5}); err != nil {
+		t.Error("Could not MAddCarray:", err)
+	} else if n := d.ArrayLen("data/mnew3"); n != 3 {
+		t.Errorf("ArrayLen(\"data/mnew3\") returns %d, want 3", n)
+	}
+	if err = d.MAddSarray("data", "mnew4", []string{"x", "y"}); err != nil {
+		t.Error("Could not MAddSarray:", err)
+	} else if sa, err := d.GetSarray("data/mnew4"); err != nil || len(sa) != 2 || sa[1] != "y" {
+		t.Errorf("GetSarray(\"data/mnew4\") returns %v, %v, want [x y], nil", sa, err)
+	}
+
+	// Empty or over-long inputs are argument errors, not panics
+	empty := map[string]error{
+		"MAddLincom":   d.MAddLincom("data", "mbad", nil, nil, nil),
+		"MAddCLincom":  d.MAddCLincom("data", "mbad", []string{"a", "b", "c", "d"}, make([]complex128, 4), make([]complex128, 4)),
+		"MAddPolynom":  d.MAddPolynom("data", "mbad", "in1", nil),
+		"MAddCPolynom": d.MAddCPolynom("data", "mbad", "in1", make([]complex128, MAXPOLYORD+2)),
+		"MAddSarray":   d.MAddSarray("data", "mbad", nil),
+	}
+	for op, err := range empty {
+		if !errors.Is(err, ErrArgument) {
+			t.Errorf("%s with bad lengths returned %v, want ErrArgument", op, err)
+		}
+	}
+
+	// MAddWindow check
+	if err = d.MAddWindow("data", "mnew5", "in1", "in2", WINDOPGE, 2.5); err != nil {
+		t.Error("Could not MAddWindow:", err)
+	} else if e, err := d.Entry("data/mnew5"); err != nil {
+		t.Error("Could not read Entry data/mnew5:", err)
+	} else if thresh, _ := e.Threshold(); thresh != 2.5 {
+		t.Errorf("Entry data/mnew5 gets threshold %v, want 2.5", thresh)
+	}
+
+	// MAddSpec check
+	if err = d.MAddSpec("mnew6 PHASE in1 -3", "data"); err != nil {
+		t.Error("Could not MAddSpec:", err)
+	} else if e, err := d.Entry("data/mnew6"); err != nil {
+		t.Error("Could not read Entry data/mnew6:", err)
+	} else if shift, _ := e.Shift(); shift != -3 {
+		t.Errorf("Entry data/mnew6 gets shift %d, want -3", shift)
+	}
+
+	// MAddEntry check
+	e := RecipEntry("mnew7", "in1", 1.5, 0)
+	if err = d.MAddEntry(&e, "lincom"); err != nil {
+		t.Error("Could not MAddEntry:", err)
+	} else if et := d.EntryType("lincom/mnew7"); et != RECIPENTRY {
+		t.Errorf("Entry lincom/mnew7 gets field type 0x%x, want RECIP=0x%x", et, RECIPENTRY)
+	}
+	e = ConstEntry("mconst", COMPLEX128, complex(3.3, 4.4), 0)
+	if err = d.MAddEntry(&e, "lincom"); err != nil {
+		t.Error("Could not MAddEntry:", err)
+	} else if c, err := d.GetConstantComplex128("lincom/mconst"); err != nil || c != complex(3.3, 4.4) {
+		t.Errorf("GetConstantComplex128(\"lincom/mconst\") returns %f, %v, want (3.3+4.4i), nil", c, err)
+	}
+	e = RawEntry("mnew8", 0, 1, INT8)
+	if err = d.MAddEntry(&e, "data"); err == nil {
+		t.Error("MAddEntry accepted a RAW metafield, want error")
+	}
+
+	if n := d.NMFields("data"); n != 13 {
+		t.Errorf("NMFields(\"data\") returns %d, want 13", n)
+	}
+}
+
+func TestGetDataAlloc(t *testing.T) {
+	dir := "dirfile_alloc"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-only:", err)
+	}
+	defer d.Close()
+
+	var data []int16
+	n, err := d.GetDataAlloc("data", 5, 0, 2, 3, &data)
+	if err != nil {
+		t.Error("GetDataAlloc failed:", err)
+	}
+	if n != 19 || len(data) != 19 {
+		t.Errorf("GetDataAlloc read n=%d into len %d, want 19", n, len(data))
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] != int16(41+i) {
+			t.Errorf("GetDataAlloc returned d[%d]=%d, want %d", i, data[i], 41+i)
+		}
+	}
+
+	// Reading past the end of the field should trim, reusing the slice
+	n, err = d.GetDataAlloc("data", 8, 0, 5, 0, &data)
+	if err != nil {
+		t.Error("GetDataAlloc past EoF failed:", err)
+	}
+	if n != 16 || len(data) != 16 || data[0] != 65 {
+		t.Errorf("GetDataAlloc past EoF read n=%d, len=%d, want 16", n, len(data))
+	}
+
+	// Zero-length requests and empty slices should be errors, not panics
+	if _, err = d.GetDataAlloc("data", 5, 0, 0, 0, &data); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataAlloc of zero samples returned %v, want ErrArgument", err)
+	}
+	empty := []int16{}
+	if _, err = d.GetData("data", 5, 0, 1, 0, &empty); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetData into empty slice returned %v, want ErrArgument", err)
+	}
+	if _, err = d.GetDataAlloc("xyz", 5, 0, 1, 0, &data); !errors.Is(err, ErrBadCode) {
+		t.Errorf("GetDataAlloc on non-existent field returned %v, want ErrBadCode", err)
+	}
+
+	// INDEX has no end, so all that is requested should be read
+	var index []int64
+	n, err = d.GetDataAlloc("INDEX", 1000, 0, 3, 0, &index)
+	if err != nil || n != 3 || len(index) != 3 || index[0] != 1000 || index[2] != 1002 {
+		t.Errorf("GetDataAlloc(INDEX) read n=%d, %v (%v), want 3, [1000 1001 1002]", n, index, err)
+	}
+}
@@ -0,0 +1,206 @@
+//go:build !purego
+
+package getdata
+
+/*
+#include <getdata.h>
+#include <stdlib.h>
+#include <string.h>
+
+int goParserCallback(gd_parser_data_t *, void *);
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+// FormatSuberror identifies the kind of syntax error found in a format file
+type FormatSuberror int
+
+// FORMATBADSPF indicates an invalid samples per frame
+const FORMATBADSPF FormatSuberror = C.GD_E_FORMAT_BAD_SPF
+
+// FORMATNFIELDS indicates an invalid number of LINCOM input fields
+const FORMATNFIELDS FormatSuberror = C.GD_E_FORMAT_N_FIELDS
+
+// FORMATNTOK indicates too few tokens on a line
+const FORMATNTOK FormatSuberror = C.GD_E_FORMAT_N_TOK
+
+// FORMATNUMBITS indicates an invalid number of bits in a BIT or SBIT field
+const FORMATNUMBITS FormatSuberror = C.GD_E_FORMAT_NUMBITS
+
+// FORMATBITNUM indicates an invalid first bit in a BIT or SBIT field
+const FORMATBITNUM FormatSuberror = C.GD_E_FORMAT_BITNUM
+
+// FORMATBITSIZE indicates a bit range extending past bit 63
+const FORMATBITSIZE FormatSuberror = C.GD_E_FORMAT_BITSIZE
+
+// FORMATCHARACTER indicates an invalid character
+const FORMATCHARACTER FormatSuberror = C.GD_E_FORMAT_CHARACTER
+
+// FORMATBADLINE indicates an unrecognised line
+const FORMATBADLINE FormatSuberror = C.GD_E_FORMAT_BAD_LINE
+
+// FORMATRESNAME indicates use of a reserved field name
+const FORMATRESNAME FormatSuberror = C.GD_E_FORMAT_RES_NAME
+
+// FORMATENDIAN indicates an invalid /ENDIAN directive
+const FORMATENDIAN FormatSuberror = C.GD_E_FORMAT_ENDIAN
+
+// FORMATBADTYPE indicates an invalid data type
+const FORMATBADTYPE FormatSuberror = C.GD_E_FORMAT_BAD_TYPE
+
+// FORMATBADNAME indicates an invalid field name
+const FORMATBADNAME FormatSuberror = C.GD_E_FORMAT_BAD_NAME
+
+// FORMATUNTERM indicates an unterminated token
+const FORMATUNTERM FormatSuberror = C.GD_E_FORMAT_UNTERM
+
+// FORMATMETARAW indicates a RAW metafield
+const FORMATMETARAW FormatSuberror = C.GD_E_FORMAT_METARAW
+
+// FORMATNOFIELD indicates a /META directive whose parent field does not exist
+const FORMATNOFIELD FormatSuberror = C.GD_E_FORMAT_NO_FIELD
+
+// FORMATDUPLICATE indicates a duplicate field name
+const FORMATDUPLICATE FormatSuberror = C.GD_E_FORMAT_DUPLICATE
+
+// FORMATLOCATION indicates a metafield defined in a different fragment from its parent
+const FORMATLOCATION FormatSuberror = C.GD_E_FORMAT_LOCATION
+
+// FORMATPROTECT indicates an invalid /PROTECT directive
+const FORMATPROTECT FormatSuberror = C.GD_E_FORMAT_PROTECT
+
+// FORMATLITERAL indicates an unexpected literal in place of a field code
+const FORMATLITERAL FormatSuberror = C.GD_E_FORMAT_LITERAL
+
+// FORMATWINDOP indicates an invalid WINDOW operation
+const FORMATWINDOP FormatSuberror = C.GD_E_FORMAT_WINDOP
+
+// FORMATMETAMETA indicates a metafield of a metafield
+const FORMATMETAMETA FormatSuberror = C.GD_E_FORMAT_META_META
+
+// FORMATALIAS indicates an alias used where it is not permitted
+const FORMATALIAS FormatSuberror = C.GD_E_FORMAT_ALIAS
+
+// FORMATMPLEXVAL indicates an invalid MPLEX count value or period
+const FORMATMPLEXVAL FormatSuberror = C.GD_E_FORMAT_MPLEXVAL
+
+// ParserAction tells the format file parser how to handle a syntax error
+type ParserAction int
+
+// PARSERABORT stops parsing; the open fails with the syntax error
+const PARSERABORT ParserAction = C.GD_SYNTAX_ABORT
+
+// PARSERRESCAN re-parses the line, after it was corrected with ParseError.Rescan
+const PARSERRESCAN ParserAction = C.GD_SYNTAX_RESCAN
+
+// PARSERIGNORE skips the line and continues parsing
+const PARSERIGNORE ParserAction = C.GD_SYNTAX_IGNORE
+
+// PARSERCONTINUE continues parsing, but the open will still fail after it
+// finishes, reporting the syntax error
+const PARSERCONTINUE ParserAction = C.GD_SYNTAX_CONTINUE
+
+// ParseError describes a syntax error found in a format file, and is passed to
+// the callback given to OpenDirfileCallback. It may be kept after the callback
+// returns, but can then no longer Rescan the line.
+type ParseError struct {
+	Suberror FormatSuberror // the kind of syntax error
+	Filename string         // path to the format file fragment
+	Linenum  int            // line number within that file
+	Line     string         // the offending line
+	parser   *parserLine
+}
+
+// parserLine holds the parser's line buffer, which is valid only until the
+// callback returns
+type parserLine struct {
+	pdata *C.gd_parser_data_t
+}
+
+// Error describes the syntax error
+func (pe ParseError) Error() string {
+	return fmt.Sprintf("Syntax error (0x%x) in %s line %d: %s", int(pe.Suberror), pe.Filename, pe.Linenum, pe.Line)
+}
+
+// Rescan replaces the offending line with a corrected one. The callback should
+// return the result, PARSERRESCAN, so that the new line is parsed. Called after
+// the callback has returned, it changes nothing and returns PARSERABORT.
+func (pe ParseError) Rescan(line string) ParserAction {
+	if pe.parser == nil || pe.parser.pdata == nil {
+		return PARSERABORT
+	}
+	pdata := pe.parser.pdata
+	cline := C.CString(line)
+	defer C.free(unsafe.Pointer(cline))
+	n := C.size_t(len(line) + 1)
+	if n > pdata.buflen {
+		// The parser owns the buffer, so grow it in place rather than replacing it
+		pdata.line = (*C.char)(C.realloc(unsafe.Pointer(pdata.line), n))
+		pdata.buflen = n
+	}
+	C.memcpy(unsafe.Pointer(pdata.line), unsafe.Pointer(cline), n)
+	return PARSERRESCAN
+}
+
+// ParserCallback is called by the format file parser on each syntax error. A
+// panic in the callback is recovered, and aborts parsing.
+type ParserCallback func(ParseError) ParserAction
+
+//export goParserCallback
+func goParserCallback(pdata *C.gd_parser_data_t, extra unsafe.Pointer) (action C.int) {
+	// A panic must not unwind through the parser's C frames
+	defer func() {
+		if recover() != nil {
+			action = C.int(PARSERABORT)
+		}
+	}()
+	callback := (*(*cgo.Handle)(extra)).Value().(ParserCallback)
+	parser := &parserLine{pdata: pdata}
+	defer func() { parser.pdata = nil }()
+	pe := ParseError{
+		Suberror: FormatSuberror(pdata.suberror),
+		Filename: C.GoString(pdata.filename),
+		Linenum:  int(pdata.linenum),
+		Line:     C.GoString(pdata.line),
+		parser:   parser,
+	}
+	return C.int(callback(pe))
+}
+
+// OpenDirfileCallback opens a Dirfile, as OpenDirfile does, but calls callback
+// on each syntax error found in the format files (including any fragments
+// included later) to decide how to proceed.
+func OpenDirfileCallback(name string, flags Flags, callback ParserCallback) (Dirfile, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	// The handle is kept in C memory, as the parser may call back at any time
+	// until the Dirfile is closed.
+	extra := C.malloc(C.size_t(unsafe.Sizeof(cgo.Handle(0))))
+	*(*cgo.Handle)(extra) = cgo.NewHandle(callback)
+
+	result := C.gd_cbopen(cname, C.ulong(flags), C.gd_parser_callback_t(C.goParserCallback), extra)
+	dirfile := Dirfile{name: name, d: result, pending: new(sync.WaitGroup), callback: extra}
+
+	errcode := C.gd_error(result)
+	if errcode != C.GD_E_OK {
+		return dirfile, dirfile.opError("OpenDirfileCallback", "")
+	}
+	return dirfile, nil
+}
+
+// releaseCallback frees the parser callback, if any, once the Dirfile is closed
+func (df *Dirfile) releaseCallback() {
+	if df.callback == nil {
+		return
+	}
+	(*(*cgo.Handle)(df.callback)).Delete()
+	C.free(df.callback)
+	df.callback = nil
+}
@@ -0,0 +1,87 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func createBadDirfile(t *testing.T, dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal("Could not remove dirfile:", err)
+	}
+	if err := os.Mkdir(dir, 0775); err != nil {
+		t.Fatal("Could not create dirfile:", err)
+	}
+	format := `/ENDIAN little
+data RAW INT8 8
+junk LINCOM
+fixme RAW NOTATYPE 8
+`
+	if err := os.WriteFile(fmt.Sprintf("%s/format", dir), []byte(format), 0664); err != nil {
+		t.Fatal("Could not create dirfile/format:", err)
+	}
+}
+
+func TestOpenDirfileCallback(t *testing.T) {
+	dir := "dirfile_callback"
+	createBadDirfile(t, dir)
+	defer removeTestDirfile(dir)
+
+	if d, err := OpenDirfile(dir, RDONLY); err == nil {
+		t.Error("OpenDirfile on a malformed format file did not return error")
+	} else {
+		d.Discard()
+	}
+
+	var seen []ParseError
+	d, err := OpenDirfileCallback(dir, RDONLY, func(pe ParseError) ParserAction {
+		seen = append(seen, pe)
+		if strings.HasPrefix(pe.Line, "fixme") {
+			return pe.Rescan("fixme RAW INT16 8")
+		}
+		return PARSERIGNORE
+	})
+	if err != nil {
+		t.Fatal("OpenDirfileCallback failed:", err)
+	}
+	defer d.Close()
+	if len(seen) != 2 {
+		t.Fatalf("Parser callback called %d times, want 2", len(seen))
+	}
+	if seen[0].Linenum != 3 || !strings.HasPrefix(seen[0].Line, "junk") {
+		t.Errorf("First ParseError at line %d: %q, want line 3: \"junk LINCOM\"", seen[0].Linenum, seen[0].Line)
+	}
+	if !strings.HasSuffix(seen[1].Filename, "format") || seen[1].Suberror != FORMATBADTYPE {
+		t.Errorf("Second ParseError in %s with suberror 0x%x, want format, FORMATBADTYPE=0x%x",
+			seen[1].Filename, seen[1].Suberror, FORMATBADTYPE)
+	}
+	if et := d.EntryType("junk"); et != NOENTRY {
+		t.Errorf("Ignored line defined field junk of type 0x%x", et)
+	}
+	if nt := d.NativeType("fixme"); nt != INT16 {
+		t.Errorf("Rescanned field fixme has type 0x%x, want INT16=0x%x", nt, INT16)
+	}
+	if action := seen[1].Rescan("fixme RAW INT32 8"); action != PARSERABORT {
+		t.Errorf("Rescan after the callback returned %d, want PARSERABORT", action)
+	}
+
+	aborted, err := OpenDirfileCallback(dir, RDONLY, func(pe ParseError) ParserAction {
+		return PARSERABORT
+	})
+	if err == nil {
+		t.Error("OpenDirfileCallback with an aborting callback did not return error")
+	}
+	aborted.Discard()
+
+	panicked, err := OpenDirfileCallback(dir, RDONLY, func(pe ParseError) ParserAction {
+		panic("callback failed")
+	})
+	if err == nil {
+		t.Error("OpenDirfileCallback with a panicking callback did not return error")
+	}
+	panicked.Discard()
+}
@@ -0,0 +1,152 @@
+//go:build !purego
+
+// Command csv2dirfile creates a dirfile from CSV or TSV text, with one RAW
+// field per column, or from a column of binary samples.
+//
+// Usage:
+//
+//	csv2dirfile [flags] dirfile [input.csv]
+//
+// The input is read from standard input if no file is named. Column types are
+// detected from the data unless given, with the sample rate, units and a LINCOM
+// calibration of each column, by a JSON description (-desc); see
+// importer.Description. For example,
+//
+//	csv2dirfile -desc groundtest.json -encoding gzip /data/gt01 groundtest.csv
+//
+// With -binary, the input is instead samples of type -type and byte order
+// -endian, written to the one field named by -binary, e.g.
+//
+//	csv2dirfile -binary volts -type INT16 -endian big /data/gt01 volts.bin
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/format"
+	"github.com/joefowler/gogetdata/importer"
+)
+
+// encodings are the values of the -encoding flag
+var encodings = map[string]getdata.Flags{
+	"none": getdata.UNENCODED, "text": getdata.TEXTENCODED, "slim": getdata.SLIMENCODED,
+	"gzip": getdata.GZIPENCODED, "bzip2": getdata.BZIP2ENCODED, "lzma": getdata.LZMAENCODED,
+	"sie": getdata.SIEENCODED, "zzip": getdata.ZZIPENCODED, "zzslim": getdata.ZZSLIMENCODED,
+	"flac": getdata.FLACENCODED,
+}
+
+// byteOrders are the values of the -endian flag
+var byteOrders = map[string]binary.ByteOrder{"little": binary.LittleEndian, "big": binary.BigEndian}
+
+// binaryInput describes binary input, read with -binary
+type binaryInput struct {
+	field string // the field to create, or "" for CSV input
+	t     getdata.RetType
+	order binary.ByteOrder
+}
+
+func main() {
+	var opts importer.Options
+	var spf uint
+	flag.UintVar(&spf, "spf", 1, "samples per frame of columns not in the description")
+	desc := flag.String("desc", "", "JSON description of the columns")
+	encoding := flag.String("encoding", "none", "encoding of the RAW fields: "+encodingNames())
+	names := flag.String("names", "", "comma-separated column names, if the input has no header row")
+	tsv := flag.Bool("tsv", false, "read columns separated by tabs, not commas")
+	truncate := flag.Bool("trunc", false, "replace the dirfile if it exists")
+	binField := flag.String("binary", "", "read binary samples, not CSV, into the named field")
+	binType := flag.String("type", "FLOAT64", "data type of the -binary samples, e.g. INT16")
+	endian := flag.String("endian", "little", "byte order of the -binary samples: little or big")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] dirfile [input.csv]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.SPF = spf
+	if *tsv {
+		opts.Comma = '\t'
+	}
+	if *names != "" {
+		opts.Names = strings.Split(*names, ",")
+	}
+	var ok bool
+	if opts.Encoding, ok = encodings[*encoding]; !ok {
+		fmt.Fprintf(os.Stderr, "csv2dirfile: unknown encoding %q (want one of %s)\n", *encoding, encodingNames())
+		os.Exit(2)
+	}
+	bin := binaryInput{field: *binField}
+	if bin.order, ok = byteOrders[*endian]; !ok {
+		fmt.Fprintf(os.Stderr, "csv2dirfile: unknown byte order %q (want little or big)\n", *endian)
+		os.Exit(2)
+	}
+	t, err := format.ParseType(*binType)
+	if err != nil || t == format.STRING {
+		fmt.Fprintf(os.Stderr, "csv2dirfile: bad -type %q\n", *binType)
+		os.Exit(2)
+	}
+	bin.t = getdata.RetType(t)
+	flags := getdata.RDWR | getdata.CREAT | getdata.EXCL
+	if *truncate {
+		flags = getdata.RDWR | getdata.CREAT | getdata.TRUNC
+	}
+	if err := run(flag.Arg(0), flag.Arg(1), *desc, flags, bin, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "csv2dirfile:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dirfile, input, desc string, flags getdata.Flags, bin binaryInput, opts importer.Options) error {
+	var err error
+	if desc != "" {
+		if opts.Description, err = importer.ReadDescription(desc); err != nil {
+			return err
+		}
+	}
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	df, err := getdata.OpenDirfile(dirfile, flags)
+	if err != nil {
+		return err
+	}
+	var fields []string
+	if bin.field != "" {
+		err = importer.ImportBinary(&df, r, bin.field, bin.t, bin.order, opts)
+		fields = []string{bin.field}
+	} else {
+		fields, err = importer.ImportCSV(&df, r, opts)
+	}
+	if err != nil {
+		df.Close()
+		return err
+	}
+	fmt.Printf("Wrote %d fields to %s\n", len(fields), dirfile)
+	return df.Close()
+}
+
+// encodingNames returns the names of the encodings, for usage messages
+func encodingNames() string {
+	var names []string
+	for name := range encodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,93 @@
+//go:build !purego
+
+// Command dirfile2csv writes fields of a dirfile as CSV or TSV text, or as an
+// Arrow IPC stream or Parquet file.
+//
+// Usage:
+//
+//	dirfile2csv [flags] dirfile [field ...]
+//
+// The fields are those named on the command line, or else the vector fields
+// matching the -match regular expression. Rows are aligned to the samples of
+// the reference field unless -spf is given. For example,
+//
+//	dirfile2csv -first 100 -n 50 -frame -time ctime /data/run42 az el
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/export"
+)
+
+func main() {
+	var opts export.Options
+	flag.StringVar(&opts.Match, "match", "", "write the vector fields matching this regular expression")
+	flag.IntVar(&opts.FirstFrame, "first", 0, "first frame to write")
+	flag.IntVar(&opts.NumFrames, "n", 0, "number of frames to write (0 for all)")
+	flag.IntVar(&opts.SPF, "spf", 0, "rows per frame (0 for the reference field's samples per frame)")
+	flag.BoolVar(&opts.FrameColumn, "frame", false, "write a column of frame numbers")
+	flag.StringVar(&opts.TimeField, "time", "", "write a column of times read from this field (UTC seconds since 1970)")
+	flag.StringVar(&opts.TimeLayout, "timelayout", "", "Go time layout for the time column (default RFC 3339)")
+	flag.BoolVar(&opts.NoHeader, "noheader", false, "omit the header row")
+	tsv := flag.Bool("tsv", false, "separate columns with tabs, not commas")
+	arrow := flag.Bool("arrow", false, "write an Arrow IPC stream, not text")
+	parquet := flag.Bool("parquet", false, "write a Parquet file, not text")
+	output := flag.String("o", "", "write to this file, not standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] dirfile [field ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || (flag.NArg() == 1 && opts.Match == "") || (*arrow && *parquet) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.Fields = flag.Args()[1:]
+	if *tsv {
+		opts.Comma = '\t'
+	}
+	write := export.Write
+	if *arrow {
+		write = export.WriteArrow
+	} else if *parquet {
+		write = export.WriteParquet
+	}
+	if err := run(flag.Arg(0), *output, write, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "dirfile2csv:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dirfile, output string, write func(io.Writer, getdata.ReadOnly, export.Options) error,
+	opts export.Options) error {
+	df, err := getdata.OpenDirfile(dirfile, getdata.RDONLY)
+	if err != nil {
+		return err
+	}
+	defer df.Close()
+
+	f := os.Stdout
+	if output != "" {
+		if f, err = os.Create(output); err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+	w := bufio.NewWriter(f)
+	if err = write(w, &df, opts); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if output != "" {
+		return f.Close()
+	}
+	return nil
+}
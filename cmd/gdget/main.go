@@ -0,0 +1,296 @@
+//go:build !purego
+
+// Command gdget prints samples of dirfile fields, one row per sample and one
+// column per field, like dirfile2ascii.
+//
+// Usage:
+//
+//	gdget [flags] dirfile field [field ...]
+//
+// Rows are samples of the fastest of the fields; slower fields repeat each
+// sample until the next. The range starts at -first frames plus -sample
+// samples, or where the field and value given by -at (e.g. -at ctime=1.7e9)
+// are reached, and runs for -n frames or -ns samples, or to the end of the
+// dirfile. With -follow, gdget then waits for more frames, like tail -f. For
+// example,
+//
+//	gdget -at ctime=1700000000 -ns 100 -format hex /data/run42 status
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/format"
+)
+
+// chunkFrames is the number of frames read at a time
+const chunkFrames = 1024
+
+// options are the settings from the command line
+type options struct {
+	fields       []string
+	first        int // the first frame
+	sample       int // the first sample, after first
+	at           string
+	frames       int
+	samples      int
+	skip         int
+	typeName     string
+	mode         string
+	precision    int
+	sep          string
+	header       bool
+	follow       bool
+	pollInterval time.Duration
+}
+
+func main() {
+	var opts options
+	flag.IntVar(&opts.first, "first", 0, "first frame")
+	flag.IntVar(&opts.sample, "sample", 0, "samples to skip after the first frame")
+	flag.StringVar(&opts.at, "at", "", "start where `field=value` is reached, found with Framenum")
+	flag.IntVar(&opts.frames, "n", 0, "number of frames (0 for all)")
+	flag.IntVar(&opts.samples, "ns", 0, "number of samples, instead of -n")
+	flag.IntVar(&opts.skip, "skip", 1, "print every skip'th sample")
+	flag.StringVar(&opts.typeName, "type", "native", "type to read the fields as, e.g. INT32 or FLOAT64")
+	flag.StringVar(&opts.mode, "format", "auto", "number format: auto, dec, hex or float")
+	flag.IntVar(&opts.precision, "prec", -1, "digits after the point for dec, or significant digits for float (-1 for as many as needed)")
+	flag.StringVar(&opts.sep, "sep", " ", "column separator")
+	flag.BoolVar(&opts.header, "header", false, "print a header row of field names")
+	flag.BoolVar(&opts.follow, "follow", false, "at the end of the dirfile, wait for more frames")
+	flag.DurationVar(&opts.pollInterval, "interval", time.Second, "how often -follow checks for more frames")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] dirfile field [field ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 || opts.skip < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.fields = flag.Args()[1:]
+	if err := run(flag.Arg(0), opts); err != nil {
+		fmt.Fprintln(os.Stderr, "gdget:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dirfile string, opts options) error {
+	retType := getdata.NULLTYPE
+	if opts.typeName != "native" {
+		t, err := format.ParseType(opts.typeName)
+		if err != nil {
+			return err
+		}
+		retType = getdata.RetType(t)
+	}
+	switch opts.mode {
+	case "auto", "dec", "hex", "float":
+	default:
+		return fmt.Errorf("Unknown format %q (want auto, dec, hex or float)", opts.mode)
+	}
+
+	df, err := getdata.OpenDirfile(dirfile, getdata.RDONLY)
+	if err != nil {
+		return err
+	}
+	defer df.Close()
+
+	// rows are samples of the fastest field
+	spf := 0
+	for _, field := range opts.fields {
+		n := df.SPF(field)
+		if n <= 0 {
+			return df.Error()
+		}
+		spf = max(spf, n)
+	}
+	start := opts.first*spf + opts.sample
+	if opts.at != "" {
+		if start, err = startAt(&df, opts.at, spf); err != nil {
+			return err
+		}
+	}
+	end := math.MaxInt
+	if opts.samples > 0 {
+		end = start + opts.samples
+	} else if opts.frames > 0 {
+		end = start + opts.frames*spf
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if opts.header {
+		fmt.Fprintln(w, strings.Join(header(&df, opts.fields, retType), opts.sep))
+	}
+	record := make([]string, 0, 2*len(opts.fields))
+	for row := start; row < end; {
+		stop := min(end, df.NFrames()*spf)
+		if row >= stop {
+			if !opts.follow {
+				break
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			time.Sleep(opts.pollInterval)
+			continue
+		}
+		first := row / spf
+		n := min((stop+spf-1)/spf-first, chunkFrames)
+		table, err := df.GetFramesOptions(opts.fields, first, n, getdata.FrameOptions{SPF: spf, Type: retType})
+		if err != nil {
+			return err
+		}
+		if table.NFrames == 0 { // a field ends before the reference field
+			if !opts.follow {
+				break
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			time.Sleep(opts.pollInterval)
+			continue
+		}
+		columns := make([]column, len(table.Columns))
+		for i, c := range table.Columns {
+			if columns[i], err = newColumn(c.Data, opts.mode, opts.precision); err != nil {
+				return fmt.Errorf("Field %s: %v", c.Field, err)
+			}
+		}
+		last := min(stop, (first+table.NFrames)*spf)
+		for ; row < last; row++ {
+			if (row-start)%opts.skip != 0 {
+				continue
+			}
+			record = record[:0]
+			for _, c := range columns {
+				record = c(record, row-first*spf)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(record, opts.sep)); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// header returns the names of the columns printed for fields. A complex field
+// prints two columns, named field.r and field.i as by package export.
+func header(df *getdata.Dirfile, fields []string, retType getdata.RetType) []string {
+	var names []string
+	for _, field := range fields {
+		t := retType
+		if t == getdata.NULLTYPE {
+			t = df.NativeType(field)
+		}
+		if t == getdata.COMPLEX64 || t == getdata.COMPLEX128 {
+			names = append(names, field+".r", field+".i")
+		} else {
+			names = append(names, field)
+		}
+	}
+	return names
+}
+
+// startAt returns the sample (at spf samples per frame) where the field named in
+// at, given as field=value, reaches the value
+func startAt(df *getdata.Dirfile, at string, spf int) (int, error) {
+	field, value, ok := strings.Cut(at, "=")
+	if !ok {
+		return 0, fmt.Errorf("-at wants field=value, not %q", at)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("-at value: %v", err)
+	}
+	frame := df.Framenum(field, v)
+	if math.IsNaN(frame) {
+		return 0, df.Error()
+	}
+	return max(int(math.Ceil(frame*float64(spf))), 0), nil
+}
+
+// column appends the formatted value(s) of one row of a column to a record
+type column func(record []string, row int) []string
+
+// newColumn returns the column formatting data, which must be a numeric slice,
+// in the given mode
+func newColumn(data interface{}, mode string, prec int) (column, error) {
+	switch d := data.(type) {
+	case []uint8:
+		return intColumn(d, 2, mode, prec), nil
+	case []int8:
+		return intColumn(d, 2, mode, prec), nil
+	case []uint16:
+		return intColumn(d, 4, mode, prec), nil
+	case []int16:
+		return intColumn(d, 4, mode, prec), nil
+	case []uint32:
+		return intColumn(d, 8, mode, prec), nil
+	case []int32:
+		return intColumn(d, 8, mode, prec), nil
+	case []uint64:
+		return intColumn(d, 16, mode, prec), nil
+	case []int64:
+		return intColumn(d, 16, mode, prec), nil
+	case []float32:
+		return func(record []string, row int) []string {
+			return append(record, formatFloat(float64(d[row]), 32, mode, prec))
+		}, nil
+	case []float64:
+		return func(record []string, row int) []string {
+			return append(record, formatFloat(d[row], 64, mode, prec))
+		}, nil
+	case []complex64:
+		return func(record []string, row int) []string {
+			return append(record, formatFloat(float64(real(d[row])), 32, mode, prec),
+				formatFloat(float64(imag(d[row])), 32, mode, prec))
+		}, nil
+	case []complex128:
+		return func(record []string, row int) []string {
+			return append(record, formatFloat(real(d[row]), 64, mode, prec),
+				formatFloat(imag(d[row]), 64, mode, prec))
+		}, nil
+	}
+	return nil, fmt.Errorf("Cannot print data of type %T", data)
+}
+
+// intColumn returns the column formatting integers; hex mode prints them with
+// the given number of hex digits, in two's complement if negative
+func intColumn[T uint8 | int8 | uint16 | int16 | uint32 | int32 | uint64 | int64](d []T, digits int,
+	mode string, prec int) column {
+	return func(record []string, row int) []string {
+		v := d[row]
+		switch mode {
+		case "hex":
+			mask := uint64(math.MaxUint64) >> (64 - 4*digits)
+			return append(record, fmt.Sprintf("0x%0*x", digits, uint64(v)&mask))
+		case "float":
+			return append(record, formatFloat(float64(v), 64, mode, prec))
+		}
+		if v < 0 {
+			return append(record, strconv.FormatInt(int64(v), 10))
+		}
+		return append(record, strconv.FormatUint(uint64(v), 10))
+	}
+}
+
+// formatFloat formats a floating-point value of the given bit size
+func formatFloat(v float64, bits int, mode string, prec int) string {
+	switch mode {
+	case "dec":
+		return strconv.FormatFloat(v, 'f', prec, bits)
+	case "hex":
+		return strconv.FormatFloat(v, 'x', prec, bits)
+	}
+	return strconv.FormatFloat(v, 'g', prec, bits)
+}
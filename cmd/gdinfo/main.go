@@ -0,0 +1,308 @@
+//go:build !purego
+
+// Command gdinfo prints a summary of a dirfile: its fragments, and every field
+// with its type, samples per frame, native type, inputs and end of field. It
+// reports fields that fail Validate, or whose inputs or alias targets do, and
+// exits with status 1 if there are any.
+//
+// Usage:
+//
+//	gdinfo [-json] dirfile
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/format"
+)
+
+// Fragment describes one format file
+type Fragment struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Parent      int    `json:"parent"`
+	Encoding    string `json:"encoding"`
+	Endianness  string `json:"endianness"`
+	FrameOffset uint   `json:"frame_offset"`
+	Protection  string `json:"protection"`
+	Namespace   string `json:"namespace,omitempty"`
+	Prefix      string `json:"prefix,omitempty"`
+	Suffix      string `json:"suffix,omitempty"`
+}
+
+// Field describes one field
+type Field struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Fragment   int      `json:"fragment"`
+	SPF        uint     `json:"spf,omitempty"`
+	NativeType string   `json:"native_type,omitempty"`
+	Inputs     []string `json:"inputs,omitempty"`
+	Hidden     bool     `json:"hidden,omitempty"`
+	AliasOf    string   `json:"alias_of,omitempty"`
+	EoF        *int     `json:"eof,omitempty"` // in samples, for vector fields
+	Problems   []string `json:"problems,omitempty"`
+}
+
+// Summary describes a dirfile
+type Summary struct {
+	Dirfile   string     `json:"dirfile"`
+	NFrames   int        `json:"nframes"`
+	Fragments []Fragment `json:"fragments"`
+	Fields    []Field    `json:"fields"`
+	Problems  int        `json:"problems"` // the number of fields with problems
+}
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the summary as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-json] dirfile\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	df, err := getdata.OpenDirfile(flag.Arg(0), getdata.RDONLY)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gdinfo:", err)
+		os.Exit(1)
+	}
+	s, err := summarize(&df)
+	df.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gdinfo:", err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+	} else {
+		err = printText(os.Stdout, s)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gdinfo:", err)
+		os.Exit(1)
+	}
+	if s.Problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// summarize describes the dirfile
+func summarize(df *getdata.Dirfile) (*Summary, error) {
+	s := &Summary{Dirfile: df.Dirfilename(), NFrames: df.NFrames()}
+	for i := 0; i < df.NFragments(); i++ {
+		frag, err := df.Fragment(i)
+		if err != nil {
+			return nil, err
+		}
+		s.Fragments = append(s.Fragments, Fragment{
+			Index:       i,
+			Name:        frag.Name(),
+			Parent:      frag.Parent(),
+			Encoding:    encodingName(frag.Encoding()),
+			Endianness:  endiannessName(frag.Endianness()),
+			FrameOffset: frag.FrameOffset(),
+			Protection:  protectionName(frag.Protection()),
+			Namespace:   frag.Namespace(),
+			Prefix:      frag.Prefix(),
+			Suffix:      frag.Suffix(),
+		})
+	}
+
+	for _, name := range df.EntryList("", getdata.ALLENTRIES, getdata.HIDDENENTRIES) {
+		if name == "" {
+			continue // an empty list comes back as [""]
+		}
+		s.add(describe(df, name))
+		// MFieldList omits hidden metafields, so list them with EntryList
+		for _, meta := range df.EntryList(name, getdata.ALLENTRIES, getdata.HIDDENENTRIES) {
+			if meta == "" {
+				continue
+			}
+			s.add(describe(df, name+"/"+strings.TrimPrefix(meta, name+"/")))
+		}
+	}
+	return s, nil
+}
+
+// add appends the field to the summary, counting it if it has problems
+func (s *Summary) add(f Field) {
+	if len(f.Problems) > 0 {
+		s.Problems++
+	}
+	s.Fields = append(s.Fields, f)
+}
+
+// describe describes the field name
+func describe(df *getdata.Dirfile, name string) Field {
+	f := Field{Name: name}
+	if target, err := df.AliasTarget(name); err == nil {
+		f.Type = "ALIAS"
+		f.AliasOf = target
+		f.Fragment, _ = df.FragmentIndex(name)
+		f.Hidden, _ = df.Hidden(name)
+		if err := df.Validate(target); err != nil {
+			f.Problems = append(f.Problems, fmt.Sprintf("target %s: %v", target, err))
+		}
+		return f
+	}
+	if err := df.Validate(name); err != nil {
+		f.Problems = append(f.Problems, err.Error())
+	}
+	e, err := df.Entry(name)
+	if err != nil {
+		f.Problems = append(f.Problems, err.Error())
+		return f
+	}
+	et := e.Type()
+	f.Type = format.EntryType(et).String()
+	f.Fragment, _ = df.FragmentIndex(name)
+	f.Hidden = e.Hidden()
+	if inputs, err := e.InFields(); err == nil {
+		f.Inputs = inputs
+		for _, in := range inputs {
+			if err := df.Validate(in); err != nil {
+				f.Problems = append(f.Problems, fmt.Sprintf("input %s: %v", in, err))
+			}
+		}
+	}
+	if format.EntryType(et).IsScalar() {
+		return f
+	}
+	if spf := df.SPF(name); spf > 0 {
+		f.SPF = uint(spf)
+	}
+	if t := df.NativeType(name); t != getdata.UNKNOWN {
+		f.NativeType = format.RetType(t).String()
+	}
+	if eof := df.EoF(name); eof >= 0 {
+		f.EoF = &eof
+	}
+	return f
+}
+
+// printText prints the summary as text
+func printText(w io.Writer, s *Summary) error {
+	fmt.Fprintf(w, "Dirfile %s: %d frames, %d fragments, %d fields\n\n",
+		s.Dirfile, s.NFrames, len(s.Fragments), len(s.Fields))
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FRAGMENT\tPARENT\tENCODING\tENDIANNESS\tOFFSET\tPROTECTION\tNAMESPACE\tAFFIXES\tFILE")
+	for _, f := range s.Fragments {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", f.Index, f.Parent, f.Encoding,
+			f.Endianness, f.FrameOffset, f.Protection, orDash(f.Namespace),
+			orDash(strings.Trim(f.Prefix+" "+f.Suffix, " ")), f.Name)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tFRAGMENT\tSPF\tNATIVE\tEOF\tINPUTS\tFLAGS")
+	for _, f := range s.Fields {
+		spf, eof := "-", "-"
+		if f.SPF > 0 {
+			spf = fmt.Sprint(f.SPF)
+		}
+		if f.EoF != nil {
+			eof = fmt.Sprint(*f.EoF)
+		}
+		var flags []string
+		if f.Hidden {
+			flags = append(flags, "hidden")
+		}
+		if f.AliasOf != "" {
+			flags = append(flags, "alias of "+f.AliasOf)
+		}
+		if len(f.Problems) > 0 {
+			flags = append(flags, "PROBLEM")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", f.Name, f.Type, f.Fragment, spf,
+			orDash(f.NativeType), eof, orDash(strings.Join(f.Inputs, " ")), strings.Join(flags, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if s.Problems > 0 {
+		fmt.Fprintf(w, "\n%d fields have problems:\n", s.Problems)
+		for _, f := range s.Fields {
+			for _, p := range f.Problems {
+				fmt.Fprintf(w, "  %s: %s\n", f.Name, p)
+			}
+		}
+	}
+	return nil
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// encodingName returns the name of an encoding, as in format files
+func encodingName(enc getdata.Flags) string {
+	switch enc {
+	case getdata.AUTOENCODED:
+		return "auto"
+	case getdata.UNENCODED:
+		return "none"
+	case getdata.TEXTENCODED:
+		return "text"
+	case getdata.SLIMENCODED:
+		return "slim"
+	case getdata.GZIPENCODED:
+		return "gzip"
+	case getdata.BZIP2ENCODED:
+		return "bzip2"
+	case getdata.LZMAENCODED:
+		return "lzma"
+	case getdata.SIEENCODED:
+		return "sie"
+	case getdata.ZZIPENCODED:
+		return "zzip"
+	case getdata.ZZSLIMENCODED:
+		return "zzslim"
+	case getdata.FLACENCODED:
+		return "flac"
+	}
+	return fmt.Sprintf("0x%x", int64(enc))
+}
+
+// endiannessName returns the name of a byte sex
+func endiannessName(e getdata.Flags) string {
+	name := "native"
+	switch {
+	case e&getdata.BIGENDIAN != 0:
+		name = "big"
+	case e&getdata.LITTLEENDIAN != 0:
+		name = "little"
+	}
+	if e&getdata.ARMENDIAN != 0 {
+		name += " arm"
+	}
+	return name
+}
+
+// protectionName returns the name of a protection level, as in format files
+func protectionName(p getdata.Flags) string {
+	switch p {
+	case getdata.PROTECTNONE:
+		return "none"
+	case getdata.PROTECTFORMAT:
+		return "format"
+	case getdata.PROTECTDATA:
+		return "data"
+	case getdata.PROTECTALL:
+		return "all"
+	}
+	return fmt.Sprintf("0x%x", int64(p))
+}
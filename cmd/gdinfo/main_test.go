@@ -0,0 +1,84 @@
+//go:build !purego
+
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+// createInfoDirfile writes a small dirfile, like the package getdata tests' one,
+// with an included fragment and a field whose input is missing
+func createInfoDirfile(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	format := "/ENDIAN little arm\ndata RAW INT8 8\nconst CONST FLOAT64 5.5\n" +
+		"lincom LINCOM data 1.1 2.2 INDEX 2.2 3.3 data const const\n" +
+		"bad MULTIPLY data missing\n/ALIAS alias data\n/INCLUDE form2\n" +
+		"data/units STRING V\ndata/scale CONST FLOAT64 2\n/HIDDEN data/scale\n"
+	if err := testdirfile.Write(dir, format); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSummarize(t *testing.T) {
+	d, err := getdata.OpenDirfile(createInfoDirfile(t), getdata.RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	s, err := summarize(&d)
+	if err != nil {
+		t.Fatal("summarize failed:", err)
+	}
+	if s.NFrames != 10 || len(s.Fragments) != 2 || s.Fragments[1].Parent != 0 {
+		t.Errorf("Summary has %d frames and fragments %+v, want 10 frames and 2 fragments",
+			s.NFrames, s.Fragments)
+	}
+	if e := s.Fragments[0].Endianness; e != "little arm" {
+		t.Errorf("Fragment 0 has endianness %q, want \"little arm\"", e)
+	}
+	if s.Problems != 1 {
+		t.Errorf("Summary has %d fields with problems, want 1", s.Problems)
+	}
+	fields := make(map[string]Field)
+	for _, f := range s.Fields {
+		fields[f.Name] = f
+	}
+	if f := fields["data"]; f.Type != "RAW" || f.SPF != 8 || f.NativeType != "INT8" || f.EoF == nil || *f.EoF != 80 {
+		t.Errorf("data described as %+v", f)
+	}
+	if f := fields["lincom"]; f.Type != "LINCOM" || !slices.Equal(f.Inputs, []string{"data", "INDEX", "data"}) {
+		t.Errorf("lincom described as %+v", f)
+	}
+	if f := fields["const2"]; f.Type != "CONST" || f.Fragment != 1 || f.EoF != nil {
+		t.Errorf("const2 described as %+v, want a CONST in fragment 1", f)
+	}
+	if f := fields["alias"]; f.Type != "ALIAS" || f.AliasOf != "data" {
+		t.Errorf("alias described as %+v", f)
+	}
+	if f := fields["data/units"]; f.Type != "STRING" || f.Hidden {
+		t.Errorf("data/units described as %+v, want a STRING metafield", f)
+	}
+	if f := fields["data/scale"]; f.Type != "CONST" || !f.Hidden {
+		t.Errorf("data/scale described as %+v, want a hidden CONST metafield", f)
+	}
+	if f := fields["bad"]; len(f.Problems) == 0 {
+		t.Errorf("bad, with a missing input, has no problems")
+	}
+
+	var buf bytes.Buffer
+	if err := printText(&buf, s); err != nil {
+		t.Fatal("printText failed:", err)
+	}
+	if !strings.Contains(buf.String(), "1 fields have problems") {
+		t.Errorf("printText did not report the problem field:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,379 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SyncDirfile wraps a Dirfile for use from many goroutines. The C library is
+// not safe for concurrent use of one DIRFILE, so every call through a SyncDirfile
+// is serialised by a mutex. It implements Interface and ReadOnly. For parallel
+// reads, see DirfilePool.
+type SyncDirfile struct {
+	mu sync.Mutex
+	df *Dirfile
+}
+
+// NewSyncDirfile wraps an open Dirfile. The Dirfile should not be used
+// directly once wrapped.
+func NewSyncDirfile(df *Dirfile) *SyncDirfile {
+	return &SyncDirfile{df: df}
+}
+
+// OpenSyncDirfile opens a Dirfile, as OpenDirfile does, and wraps it
+func OpenSyncDirfile(name string, flags Flags) (*SyncDirfile, error) {
+	df, err := OpenDirfile(name, flags)
+	if err != nil {
+		return nil, err
+	}
+	return NewSyncDirfile(&df), nil
+}
+
+// Do calls f with the underlying Dirfile while holding the lock, so that any
+// sequence of operations (e.g. Seek then GetData) happens without interruption.
+// f must not retain the Dirfile after it returns.
+func (s *SyncDirfile) Do(f func(df *Dirfile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return f(s.df)
+}
+
+// GetData fetches data from a vector field; see Dirfile.GetData
+func (s *SyncDirfile) GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetData(fieldcode, firstFrame, firstSample, numFrames, numSamples, out)
+}
+
+// GetDataAlloc fetches data from a vector field into a slice it sizes itself;
+// see Dirfile.GetDataAlloc
+func (s *SyncDirfile) GetDataAlloc(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetDataAlloc(fieldcode, firstFrame, firstSample, numFrames, numSamples, out)
+}
+
+// PutData stores data to a vector field; see Dirfile.PutData
+func (s *SyncDirfile) PutData(fieldcode string, firstFrame, firstSample int, data interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.PutData(fieldcode, firstFrame, firstSample, data)
+}
+
+// GetConstant fetches the value of a CONST field; see Dirfile.GetConstant
+func (s *SyncDirfile) GetConstant(fieldcode string, inptr interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetConstant(fieldcode, inptr)
+}
+
+// Entry returns the metadata of a field; see Dirfile.Entry. The Entry's write
+// methods (Move, Rename, Set...) bypass the lock, so should only be called
+// inside Do.
+func (s *SyncDirfile) Entry(fieldcode string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Entry(fieldcode)
+}
+
+// NFrames returns the number of frames in the dirfile
+func (s *SyncDirfile) NFrames() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.NFrames()
+}
+
+// EoF returns the end-of-field position, in samples
+func (s *SyncDirfile) EoF(fieldcode string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.EoF(fieldcode)
+}
+
+// Error returns the error of the last call that failed, which may have been
+// made by another goroutine; prefer the errors methods return
+func (s *SyncDirfile) Error() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Error()
+}
+
+// Dirfilename returns the name of the dirfile
+func (s *SyncDirfile) Dirfilename() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Dirfilename()
+}
+
+// NFragments returns the number of fragments in the dirfile
+func (s *SyncDirfile) NFragments() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.NFragments()
+}
+
+// FragmentIndex returns the index of the fragment holding a field
+func (s *SyncDirfile) FragmentIndex(fieldcode string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.FragmentIndex(fieldcode)
+}
+
+// Include adds a format file fragment to the dirfile; see Dirfile.Include
+func (s *SyncDirfile) Include(fragname string, flags Flags) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Include(fragname, flags)
+}
+
+// EntryType returns the type of a field
+func (s *SyncDirfile) EntryType(fieldcode string) EntryType {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.EntryType(fieldcode)
+}
+
+// GetReference returns the reference field; see Dirfile.GetReference
+func (s *SyncDirfile) GetReference() (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetReference()
+}
+
+// FieldList lists all fields (no metafields)
+func (s *SyncDirfile) FieldList() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.FieldList()
+}
+
+// VectorList lists all vector fields (no metafields)
+func (s *SyncDirfile) VectorList() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.VectorList()
+}
+
+// FieldListByType lists all fields of one type (no metafields)
+func (s *SyncDirfile) FieldListByType(et EntryType) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.FieldListByType(et)
+}
+
+// MFieldList lists the metafields of a parent field
+func (s *SyncDirfile) MFieldList(parent string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.MFieldList(parent)
+}
+
+// MatchEntries lists the fields whose names match a regular expression; see
+// Dirfile.MatchEntries
+func (s *SyncDirfile) MatchEntries(regex string, fragment int, et EntryType, flags Flags) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.MatchEntries(regex, fragment, et, flags)
+}
+
+// Hidden reports whether a field is hidden
+func (s *SyncDirfile) Hidden(fieldcode string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Hidden(fieldcode)
+}
+
+// Validate checks that a field can be read; see Dirfile.Validate
+func (s *SyncDirfile) Validate(fieldcode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Validate(fieldcode)
+}
+
+// SPF returns the samples per frame of a field
+func (s *SyncDirfile) SPF(fieldcode string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.SPF(fieldcode)
+}
+
+// BoF returns the beginning-of-field position, in samples
+func (s *SyncDirfile) BoF(fieldcode string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.BoF(fieldcode)
+}
+
+// NativeType returns the native data type of a field
+func (s *SyncDirfile) NativeType(fieldcode string) RetType {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.NativeType(fieldcode)
+}
+
+// ArrayLen returns the length of a CARRAY or SARRAY field
+func (s *SyncDirfile) ArrayLen(fieldcode string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.ArrayLen(fieldcode)
+}
+
+// Framenum returns the frame number at which a field reaches value; see
+// Dirfile.Framenum
+func (s *SyncDirfile) Framenum(fieldcode string, value float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Framenum(fieldcode, value)
+}
+
+// AddEntry adds a field to the dirfile; see Dirfile.AddEntry
+func (s *SyncDirfile) AddEntry(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.AddEntry(e)
+}
+
+// MAddEntry adds a metafield to the dirfile; see Dirfile.MAddEntry
+func (s *SyncDirfile) MAddEntry(e *Entry, parent string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.MAddEntry(e, parent)
+}
+
+// Delete removes a field from the dirfile; see Dirfile.Delete
+func (s *SyncDirfile) Delete(fieldname string, flags DeleteFlags) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Delete(fieldname, flags)
+}
+
+// PutConstant stores the value of a CONST field; see Dirfile.PutConstant
+func (s *SyncDirfile) PutConstant(fieldcode string, data interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.PutConstant(fieldcode, data)
+}
+
+// GetCarray fetches the values of a CARRAY field; see Dirfile.GetCarray
+func (s *SyncDirfile) GetCarray(fieldcode string, out interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetCarray(fieldcode, out)
+}
+
+// PutCarray stores the values of a CARRAY field; see Dirfile.PutCarray
+func (s *SyncDirfile) PutCarray(fieldcode string, array interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.PutCarray(fieldcode, array)
+}
+
+// GetString fetches the value of a STRING field
+func (s *SyncDirfile) GetString(fieldcode string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetString(fieldcode)
+}
+
+// PutString stores the value of a STRING field
+func (s *SyncDirfile) PutString(fieldcode, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.PutString(fieldcode, value)
+}
+
+// GetSarray fetches the values of a SARRAY field
+func (s *SyncDirfile) GetSarray(fieldcode string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.GetSarray(fieldcode)
+}
+
+// PutSarray stores the values of a SARRAY field
+func (s *SyncDirfile) PutSarray(fieldcode string, sarray []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.PutSarray(fieldcode, sarray)
+}
+
+// ErrorCount returns the number of errors raised since the last call
+func (s *SyncDirfile) ErrorCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.ErrorCount()
+}
+
+// Close closes the underlying Dirfile
+func (s *SyncDirfile) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.df.Close()
+}
+
+// DirfilePool holds a fixed number of independent read-only handles to one
+// dirfile, so that goroutines can read it in parallel. Each handle is used by
+// only one goroutine at a time.
+type DirfilePool struct {
+	name    string
+	handles []*Dirfile
+	free    chan *Dirfile
+}
+
+// NewDirfilePool opens size read-only handles to the named dirfile. Any RDWR,
+// CREAT, EXCL, or TRUNC bits in flags are ignored.
+func NewDirfilePool(name string, size int, flags Flags) (*DirfilePool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("DirfilePool size %d must be positive", size)
+	}
+	flags &^= RDWR | CREAT | EXCL | TRUNC
+	pool := &DirfilePool{name: name, free: make(chan *Dirfile, size)}
+	for i := 0; i < size; i++ {
+		df, err := OpenDirfile(name, flags|RDONLY)
+		if err != nil {
+			df.Close()
+			pool.Close()
+			return nil, err
+		}
+		pool.handles = append(pool.handles, &df)
+		pool.free <- &df
+	}
+	return pool, nil
+}
+
+// Get takes a handle from the pool, waiting until one is free. Return it with Put.
+func (p *DirfilePool) Get() *Dirfile {
+	return <-p.free
+}
+
+// Put returns a handle taken with Get to the pool
+func (p *DirfilePool) Put(df *Dirfile) {
+	p.free <- df
+}
+
+// Do calls f with a handle from the pool, returning the handle afterwards
+func (p *DirfilePool) Do(f func(df *Dirfile) error) error {
+	df := p.Get()
+	defer p.Put(df)
+	return f(df)
+}
+
+// Size returns the number of handles in the pool
+func (p *DirfilePool) Size() int {
+	return len(p.handles)
+}
+
+// Close closes every handle in the pool, first waiting for all to be returned.
+// It returns the first error encountered.
+func (p *DirfilePool) Close() error {
+	var firstErr error
+	for range p.handles {
+		df := <-p.free
+		if err := df.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.handles = nil
+	return firstErr
+}
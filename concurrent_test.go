@@ -0,0 +1,141 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSyncDirfile(t *testing.T) {
+	dir := "dirfile_sync"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	s, err := OpenSyncDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-only:", err)
+	}
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func(frame int) {
+			defer wg.Done()
+			var data []int32
+			if _, err := s.GetDataAlloc("data", frame, 0, 1, 0, &data); err != nil {
+				errs <- err
+				return
+			}
+			if data[0] != int32(8*frame+1) {
+				t.Errorf("Goroutine read frame %d starting with %d, want %d", frame, data[0], 8*frame+1)
+			}
+		}(g % 10)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error("Concurrent GetDataAlloc failed:", err)
+	}
+
+	// Errors raised through any method should now be counted
+	if _, err := s.Entry("xyz"); err == nil {
+		t.Error("Entry on non-existent field did not return error")
+	}
+	if err := s.Do(func(df *Dirfile) error {
+		_, err := df.GetData("xyz", 0, 0, 1, 0, &[]int8{0})
+		return err
+	}); err == nil {
+		t.Error("GetData on non-existent field did not return error")
+	}
+	if c := s.ErrorCount(); c != 2 {
+		t.Errorf("ErrorCount()=%d after two errors, want 2", c)
+	}
+}
+
+func TestSyncDirfileReadOnly(t *testing.T) {
+	dir := "dirfile_sync_readonly"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	s, err := OpenSyncDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-only:", err)
+	}
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(frame int) {
+			defer wg.Done()
+			table, err := ReadFrames(s, []string{"data", "bit"}, frame, 1, FrameOptions{})
+			if err != nil {
+				t.Error("ReadFrames through a SyncDirfile failed:", err)
+				return
+			}
+			if data, err := ColumnData[int8](table, "data"); err != nil || len(data) != 8 || data[0] != int8(8*frame+1) {
+				t.Errorf("ReadFrames of frame %d read data %v (%v)", frame, data, err)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if str, err := s.GetString("string"); err != nil || str != "Zaphod Beeblebrox" {
+		t.Errorf("GetString(string) = %q (%v)", str, err)
+	}
+	if n := s.ArrayLen("carray"); n != 6 {
+		t.Errorf("ArrayLen(carray) = %d, want 6", n)
+	}
+	if ml := s.MFieldList("data"); len(ml) != 5 {
+		t.Errorf("MFieldList(data) = %v, want 5 metafields", ml)
+	}
+}
+
+func TestDirfilePool(t *testing.T) {
+	dir := "dirfile_pool"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	pool, err := NewDirfilePool(dir, 3, RDWR)
+	if err != nil {
+		t.Fatal("Could not open DirfilePool:", err)
+	}
+	if pool.Size() != 3 {
+		t.Errorf("DirfilePool.Size()=%d, want 3", pool.Size())
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 12; g++ {
+		wg.Add(1)
+		go func(frame int) {
+			defer wg.Done()
+			err := pool.Do(func(df *Dirfile) error {
+				data, err := GetData[float64](df, "data", frame, 0, 1, 0)
+				if err == nil && data[7] != float64(8*frame+8) {
+					t.Errorf("Pool read frame %d ending with %f, want %d", frame, data[7], 8*frame+8)
+				}
+				return err
+			})
+			if err != nil {
+				t.Error("DirfilePool.Do failed:", err)
+			}
+		}(g % 10)
+	}
+	wg.Wait()
+
+	df := pool.Get()
+	if _, err := df.PutData("data", 0, 0, []int8{1}); err == nil {
+		t.Error("PutData through a pooled handle did not return error, want read-only")
+	}
+	pool.Put(df)
+
+	if err := pool.Close(); err != nil {
+		t.Error("DirfilePool.Close failed:", err)
+	}
+	if _, err := NewDirfilePool(dir, 0, RDONLY); err == nil {
+		t.Error("NewDirfilePool of size 0 did not return error")
+	}
+}
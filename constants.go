@@ -1,3 +1,5 @@
+//go:build !purego
+
 package getdata
 
 /*
@@ -28,6 +30,10 @@ const BIGENDIAN Flags = C.GD_BIG_ENDIAN
 // LITTLEENDIAN specifies little-endian raw data
 const LITTLEENDIAN Flags = C.GD_LITTLE_ENDIAN
 
+// ARMENDIAN specifies raw double-precision data in the middle-endian order of
+// old ARM processors; it is combined with BIGENDIAN or LITTLEENDIAN
+const ARMENDIAN Flags = C.GD_ARM_ENDIAN
+
 // NATIVEENDIAN specifies native-endian raw data
 const NATIVEENDIAN Flags = 0
 
@@ -134,6 +140,9 @@ const WINDOWENTRY EntryType = C.GD_WINDOW_ENTRY
 // INDEXENTRY denotes the field type of the implicit INDEX field
 const INDEXENTRY EntryType = C.GD_INDEX_ENTRY
 
+// ALIASENTRY denotes an alias of another field (used by AliasEntry and Dirfile.AddEntry)
+const ALIASENTRY EntryType = C.GD_ALIAS_ENTRY
+
 // ALLENTRIES denotes that all entry types should be counted/listed
 const ALLENTRIES EntryType = 0
 
@@ -171,6 +180,9 @@ const REGEXUNICODE EntryType = C.GD_REGEX_UNICODE
 // LOOKBACKALL searches backwards all the way to the start of a data source.
 const LOOKBACKALL int = -1
 
+// ALLFRAGMENTS selects entries from every fragment (e.g., in MatchEntries)
+const ALLFRAGMENTS int = C.GD_ALL_FRAGMENTS
+
 // FRAMEHERE indicates the current location of the I/O pointer
 const FRAMEHERE int = C.GD_HERE
 
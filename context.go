@@ -0,0 +1,269 @@
+//go:build !purego
+
+package getdata
+
+/*
+#include <getdata.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"os"
+	"time"
+	"unsafe"
+)
+
+// ContextChunkSamples is the number of samples read or written per C call by
+// GetDataContext and PutDataContext, between checks for cancellation.
+var ContextChunkSamples = 1 << 20
+
+// ProgressInterval is how often a ProgressFunc is called during a long operation
+var ProgressInterval = time.Second
+
+// ProgressFunc receives progress reports from long operations such as recoding:
+// the number of RAW data files of the fragment rewritten so far, out of total.
+// It is called every ProgressInterval and then once more, with finished true,
+// on completion. The C library does not report how far a recode has got, so
+// a file is counted as done once the recoded file has replaced it; total is 0
+// if no files are to be recoded.
+type ProgressFunc func(done, total int, finished bool)
+
+// Wait blocks until any operations abandoned by a cancelled context have
+// finished. The Dirfile must not be used, except by Wait, Close, Discard, or
+// another Context method (all of which wait first), until then.
+func (df *Dirfile) Wait() {
+	if df.pending != nil {
+		df.pending.Wait()
+	}
+}
+
+// runContext runs op, a single call into the C library that cannot itself be
+// interrupted, in a goroutine. It returns op's result, or ctx.Err() promptly if
+// the context is cancelled first. In that case op runs to completion in the
+// background, leaving the dirfile consistent, but the C library is not safe for
+// concurrent use of one DIRFILE, so the Dirfile must not be used again until
+// Wait (or Close) has waited for it. If files is not nil, progress is reported
+// by watching them be replaced; see ProgressFunc.
+func (df *Dirfile) runContext(ctx context.Context, progress ProgressFunc, files []recodeFile,
+	op func() error) error {
+	df.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	df.pending.Add(1)
+	go func() {
+		defer df.pending.Done()
+		done <- op()
+	}()
+
+	ticker := time.NewTicker(ProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if progress != nil {
+				n := len(files)
+				if err != nil {
+					n = recoded(files)
+				}
+				progress(n, len(files), true)
+			}
+			return err
+		case <-ticker.C:
+			if progress != nil {
+				progress(recoded(files), len(files), false)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// recodeFile is the data file of a RAW field, as it was before a recode
+type recodeFile struct {
+	path string
+	info os.FileInfo
+}
+
+// recodeFiles returns the existing data files of the RAW fields in fragment
+// index, or nil if recode is false
+func (df *Dirfile) recodeFiles(index int, recode bool) []recodeFile {
+	if !recode {
+		return nil
+	}
+	var files []recodeFile
+	for _, field := range df.FieldListByType(RAWENTRY) {
+		if i, err := df.FragmentIndex(field); err != nil || i != index {
+			continue
+		}
+		path, err := df.Filename(field)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			files = append(files, recodeFile{path, info})
+		}
+	}
+	return files
+}
+
+// recoded returns the number of files that have been replaced (or removed,
+// when the recode changes their name) since recodeFiles listed them
+func recoded(files []recodeFile) int {
+	n := 0
+	for _, f := range files {
+		if info, err := os.Stat(f.path); err != nil || !os.SameFile(info, f.info) {
+			n++
+		}
+	}
+	return n
+}
+
+// GetDataContext is like GetDataAlloc, but reads in chunks of ContextChunkSamples,
+// stopping early if ctx is cancelled. It returns the number of samples read so
+// far (and out holds them) along with ctx.Err() in that case.
+func (df *Dirfile) GetDataContext(ctx context.Context, fieldcode string, firstFrame, firstSample,
+	numFrames, numSamples int, out interface{}) (int, error) {
+	df.Wait()
+	requested, err := df.samplesToRead("GetDataContext", fieldcode, firstFrame, firstSample, numFrames, numSamples)
+	if err != nil {
+		return 0, err
+	}
+	if !presize(out, requested) {
+		return 0, newError(ErrArgument, "GetDataContext", fieldcode, "GetDataContext out variable was not a pointer to numeric slice")
+	}
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	retType, ptr := parray2type(out)
+	size := int(sizeof(retType))
+
+	nread := 0
+	for nread < requested {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		chunk := requested - nread
+		if chunk > ContextChunkSamples {
+			chunk = ContextChunkSamples
+		}
+		// After the first chunk, reading from FRAMEHERE continues where the last left off
+		cfirst := firstSample + nread
+		if firstFrame == FRAMEHERE {
+			cfirst = 0
+		}
+		n := int(C.gd_getdata(df.d, fcode, C.off_t(firstFrame), C.off_t(cfirst), 0, C.size_t(chunk),
+			C.gd_type_t(retType), unsafe.Add(ptr, nread*size)))
+		if n == 0 && C.gd_error(df.d) != C.GD_E_OK {
+			err = df.opError("GetDataContext", fieldcode)
+			break
+		}
+		nread += n
+		if n < chunk {
+			break
+		}
+	}
+	presize(out, nread)
+	return nread, err
+}
+
+// PutDataContext is like PutData, but writes in chunks of ContextChunkSamples,
+// stopping early if ctx is cancelled. It returns the number of samples written so
+// far along with ctx.Err() in that case.
+func (df *Dirfile) PutDataContext(ctx context.Context, fieldcode string, firstFrame, firstSample int,
+	data interface{}) (int, error) {
+	df.Wait()
+	dType, ptr, lenData := array2type(data)
+	if dType == UNKNOWN || dType == NULLTYPE || dType == STRING {
+		return 0, newError(ErrArgument, "PutDataContext", fieldcode, "PutDataContext data variable was not a numeric slice")
+	}
+	if lenData == 0 {
+		return 0, errZeroLength("PutDataContext", fieldcode)
+	}
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	size := int(sizeof(dType))
+
+	nwritten := 0
+	for nwritten < lenData {
+		if err := ctx.Err(); err != nil {
+			return nwritten, err
+		}
+		chunk := lenData - nwritten
+		if chunk > ContextChunkSamples {
+			chunk = ContextChunkSamples
+		}
+		cfirst := firstSample + nwritten
+		if firstFrame == FRAMEHERE {
+			cfirst = 0
+		}
+		n := int(C.gd_putdata(df.d, fcode, C.off_t(firstFrame), C.off_t(cfirst), 0, C.size_t(chunk),
+			C.gd_type_t(dType), unsafe.Add(ptr, nwritten*size)))
+		nwritten += n
+		if n < chunk {
+			return nwritten, df.opError("PutDataContext", fieldcode)
+		}
+	}
+	return nwritten, nil
+}
+
+// UnincludeContext is like Uninclude, but returns promptly if ctx is cancelled;
+// see Wait.
+func (df *Dirfile) UnincludeContext(ctx context.Context, index int, del bool) error {
+	return df.runContext(ctx, nil, nil, func() error {
+		return df.Uninclude(index, del)
+	})
+}
+
+// SetEncodingContext is like SetEncoding, but returns promptly if ctx is
+// cancelled, and reports progress of a recode to progress (which may be nil).
+// After cancellation the recode finishes in the background, without updating
+// frag; see Dirfile.Wait.
+func (frag *Fragment) SetEncodingContext(ctx context.Context, encoding Flags, recode bool,
+	progress ProgressFunc) error {
+	df := frag.df
+	df.Wait() // before listing the files to recode
+	err := df.runContext(ctx, progress, df.recodeFiles(frag.index, recode), func() error {
+		return df.alterEncoding(frag.index, encoding, recode)
+	})
+	if err == nil {
+		frag.encoding = encoding
+	}
+	return err
+}
+
+// SetEndiannessContext is like SetEndianness, but returns promptly if ctx is
+// cancelled, and reports progress of a recode to progress (which may be nil).
+// After cancellation the recode finishes in the background, without updating
+// frag; see Dirfile.Wait.
+func (frag *Fragment) SetEndiannessContext(ctx context.Context, bytesex Flags, recode bool,
+	progress ProgressFunc) error {
+	df := frag.df
+	df.Wait() // before listing the files to recode
+	err := df.runContext(ctx, progress, df.recodeFiles(frag.index, recode), func() error {
+		return df.alterEndianness(frag.index, bytesex, recode)
+	})
+	if err == nil {
+		frag.endianness = bytesex
+	}
+	return err
+}
+
+// SetFrameOffsetContext is like SetFrameOffset, but returns promptly if ctx is
+// cancelled, and reports progress of a recode to progress (which may be nil).
+// After cancellation the recode finishes in the background, without updating
+// frag; see Dirfile.Wait.
+func (frag *Fragment) SetFrameOffsetContext(ctx context.Context, offset uint, recode bool,
+	progress ProgressFunc) error {
+	df := frag.df
+	df.Wait() // before listing the files to recode
+	err := df.runContext(ctx, progress, df.recodeFiles(frag.index, recode), func() error {
+		return df.alterFrameOffset(frag.index, offset, recode)
+	})
+	if err == nil {
+		frag.frameoff = offset
+	}
+	return err
+}
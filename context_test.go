@@ -0,0 +1,82 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	dir := "dirfile_context"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Close()
+
+	defer func(n int) { ContextChunkSamples = n }(ContextChunkSamples)
+	ContextChunkSamples = 7
+
+	ctx := context.Background()
+	var data []int32
+	n, err := d.GetDataContext(ctx, "data", 1, 0, 20, 0, &data)
+	if err != nil || n != 72 || len(data) != 72 {
+		t.Errorf("GetDataContext returned n=%d, len=%d, %v, want 72, 72, nil", n, len(data), err)
+	} else {
+		for i, v := range data {
+			if v != int32(9+i) {
+				t.Errorf("GetDataContext returned d[%d]=%d, want %d", i, v, 9+i)
+			}
+		}
+	}
+
+	if n, err = d.PutDataContext(ctx, "data", 0, 0, []int8{-1, -2, -3, -4, -5, -6, -7, -8, -9, -10}); err != nil || n != 10 {
+		t.Errorf("PutDataContext returned %d, %v, want 10, nil", n, err)
+	}
+	if n, _ = d.GetDataContext(ctx, "data", 0, 8, 0, 3, &data); n != 3 || data[0] != -9 || data[2] != 11 {
+		t.Errorf("After PutDataContext, read %v, want [-9 -10 11]", data[:n])
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if n, err = d.GetDataContext(cancelled, "data", 0, 0, 2, 0, &data); !errors.Is(err, context.Canceled) || n != 0 {
+		t.Errorf("GetDataContext with cancelled context returned %d, %v, want 0, context.Canceled", n, err)
+	}
+	if n, err = d.PutDataContext(cancelled, "data", 0, 0, []int8{1, 2}); !errors.Is(err, context.Canceled) || n != 0 {
+		t.Errorf("PutDataContext with cancelled context returned %d, %v, want 0, context.Canceled", n, err)
+	}
+	if err = d.UnincludeContext(cancelled, 0, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("UnincludeContext with cancelled context returned %v, want context.Canceled", err)
+	}
+
+	frag, err := d.Fragment(0)
+	if err != nil {
+		t.Fatal("Could not get fragment 0:", err)
+	}
+	finished := false
+	var done, total int
+	progress := func(d, n int, fin bool) {
+		done, total, finished = d, n, fin
+	}
+	if err = frag.SetEndiannessContext(ctx, BIGENDIAN, true, progress); err != nil {
+		t.Error("SetEndiannessContext failed:", err)
+	}
+	if !finished {
+		t.Error("SetEndiannessContext did not report completion to its ProgressFunc")
+	}
+	if done != 1 || total != 1 {
+		t.Errorf("SetEndiannessContext reported %d of %d files recoded, want 1 of 1", done, total)
+	}
+	d.Wait()
+	if frag.Endianness() != BIGENDIAN {
+		t.Errorf("After SetEndiannessContext, Endianness()=0x%x, want BIGENDIAN=0x%x", frag.Endianness(), BIGENDIAN)
+	}
+	if n, _ = d.GetDataContext(ctx, "data", 0, 8, 0, 3, &data); n != 3 || data[0] != -9 || data[2] != 11 {
+		t.Errorf("After recoding to big-endian, read %v, want [-9 -10 11]", data[:n])
+	}
+}
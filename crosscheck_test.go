@@ -0,0 +1,58 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+// sameFloat reports whether a and b are the same float64, bit for bit, treating
+// all NaNs as the same (the C library's NaN payload differs from math.NaN's)
+func sameFloat(a, b float64) bool {
+	if math.IsNaN(a) && math.IsNaN(b) {
+		return true
+	}
+	return math.Float64bits(a) == math.Float64bits(b)
+}
+
+// TestPureMatchesC checks that an FSDirfile, read by package pure, returns the
+// very same samples as the C library for every vector field of the test dirfile
+func TestPureMatchesC(t *testing.T) {
+	dir := "dirfile_crosscheck"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+	p, err := OpenDirfileFS(os.DirFS(dir), ".")
+	if err != nil {
+		t.Fatal("OpenDirfileFS failed:", err)
+	}
+	defer p.Close()
+
+	fields := []string{"INDEX", "data", "alias", "lincom", "linterp", "polynom", "bit", "sbit",
+		"mplex", "mult", "div", "recip", "phase", "window", "indir"}
+	for _, field := range fields {
+		var want, got []complex128
+		nwant, err := d.GetDataAlloc(field, 0, 0, 10, 0, &want)
+		if err != nil {
+			t.Errorf("C GetDataAlloc(%s) failed: %v", field, err)
+			continue
+		}
+		ngot, err := p.GetDataAlloc(field, 0, 0, 10, 0, &got)
+		if err != nil || ngot != nwant {
+			t.Errorf("FSDirfile GetDataAlloc(%s) read %d samples (%v), C read %d", field, ngot, err, nwant)
+			continue
+		}
+		for i := range want {
+			if !sameFloat(real(got[i]), real(want[i])) || !sameFloat(imag(got[i]), imag(want[i])) {
+				t.Errorf("%s[%d] = %v from FSDirfile, %v from C", field, i, got[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"math"
+)
+
+// BinMode selects the statistic GetDataDecimated computes for each bin of samples
+type BinMode int
+
+const (
+	// BinFirst takes the first sample of each bin
+	BinFirst BinMode = iota
+	// BinMean takes the mean of each bin
+	BinMean
+	// BinMin takes the minimum of each bin
+	BinMin
+	// BinMax takes the maximum of each bin
+	BinMax
+)
+
+// DecimateChunkSamples is the (approximate) number of samples read per C call
+// by GetDataDecimated and GetDataEnvelope, which bounds the memory they use.
+var DecimateChunkSamples = 1 << 16
+
+// skipFactor is the bin size from which BinFirst reads only the first sample of
+// each bin, one C call per bin, rather than reading every sample
+const skipFactor = 64
+
+// GetDataDecimated reads numSamples samples of a vector field starting at sample
+// firstSample, in bins of factor samples, and returns one value per bin: the
+// statistic chosen by mode. The last bin may be partial, and bins end early if
+// the field does. BinMean, BinMin and BinMax ignore NaN samples (e.g., before
+// a fragment's frame offset), so a bin of NaNs yields NaN. The samples are read
+// in chunks, so that the whole range is never held in memory; with BinFirst and
+// a large factor, only the first sample of each bin is read.
+func (df *Dirfile) GetDataDecimated(fieldcode string, firstSample, numSamples, factor int, mode BinMode) ([]float64, error) {
+	if mode < BinFirst || mode > BinMax {
+		return nil, newError(ErrArgument, "GetDataDecimated", fieldcode, "GetDataDecimated given unknown mode %d", mode)
+	}
+	if mode == BinFirst && factor >= skipFactor {
+		return df.getDataSkipping(fieldcode, firstSample, numSamples, factor)
+	}
+	var result []float64
+	err := df.readBins("GetDataDecimated", fieldcode, firstSample, numSamples, factor, func(bin []float64) {
+		var v float64
+		switch mode {
+		case BinFirst:
+			v = bin[0]
+		case BinMean:
+			v = mean(bin)
+		case BinMin:
+			v, _ = extremes(bin)
+		case BinMax:
+			_, v = extremes(bin)
+		}
+		result = append(result, v)
+	})
+	return result, err
+}
+
+// GetDataEnvelope reads a vector field in bins as GetDataDecimated does, and
+// returns the minimum and maximum of each bin, for plotting the envelope of a
+// long time series in a single pass.
+func (df *Dirfile) GetDataEnvelope(fieldcode string, firstSample, numSamples, factor int) (lo, hi []float64, err error) {
+	err = df.readBins("GetDataEnvelope", fieldcode, firstSample, numSamples, factor, func(bin []float64) {
+		l, h := extremes(bin)
+		lo = append(lo, l)
+		hi = append(hi, h)
+	})
+	return lo, hi, err
+}
+
+// readBins reads numSamples samples of fieldcode from firstSample in chunks of
+// whole bins, calling visit with the samples of each bin in turn
+func (df *Dirfile) readBins(op, fieldcode string, firstSample, numSamples, factor int, visit func(bin []float64)) error {
+	if factor <= 0 {
+		return newError(ErrArgument, op, fieldcode, "%s given bin size %d, which is not positive", op, factor)
+	}
+	n, err := df.samplesToRead(op, fieldcode, 0, firstSample, 0, numSamples)
+	if err != nil {
+		return err
+	}
+	chunk := max(DecimateChunkSamples/factor, 1) * factor
+	read := func(pos int, dst []float64) (int, error) {
+		return df.GetData(fieldcode, 0, pos, 0, len(dst), &dst)
+	}
+	return binSamples(read, firstSample, n, chunk, factor, visit)
+}
+
+// binSamples reads n samples from firstSample with read, up to chunk at a time,
+// and calls visit with each bin of factor samples (the last may be partial).
+// A read may return fewer samples than asked for; a bin it splits is completed
+// by the next read. Reading stops early if read returns no samples.
+func binSamples(read func(pos int, dst []float64) (int, error), firstSample, n, chunk, factor int,
+	visit func(bin []float64)) error {
+	buf := make([]float64, min(chunk, n))
+	held := 0 // samples of a partial bin carried over from the last read
+	end := firstSample + n
+	for pos := firstSample; pos < end; {
+		nread, err := read(pos, buf[held:min(len(buf), held+end-pos)])
+		if err != nil {
+			return err
+		}
+		if nread == 0 {
+			break
+		}
+		pos += nread
+		avail := held + nread
+		whole := avail - avail%factor
+		if pos >= end {
+			whole = avail
+		}
+		for i := 0; i < whole; i += factor {
+			visit(buf[i:min(i+factor, whole)])
+		}
+		held = copy(buf, buf[whole:avail])
+	}
+	if held > 0 {
+		visit(buf[:held])
+	}
+	return nil
+}
+
+// getDataSkipping returns the first sample of each bin, reading only those
+func (df *Dirfile) getDataSkipping(fieldcode string, firstSample, numSamples, factor int) ([]float64, error) {
+	n, err := df.samplesToRead("GetDataDecimated", fieldcode, 0, firstSample, 0, numSamples)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]float64, (n+factor-1)/factor)
+	for i := range result {
+		sample := result[i : i+1]
+		if _, err := df.GetData(fieldcode, 0, firstSample+i*factor, 0, 1, &sample); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// mean returns the mean of the values that are not NaN, or NaN if there are none
+func mean(values []float64) float64 {
+	sum, n := 0.0, 0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / float64(n)
+}
+
+// extremes returns the minimum and maximum of the values that are not NaN, or
+// NaN if there are none
+func extremes(values []float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+	if lo > hi {
+		return math.NaN(), math.NaN()
+	}
+	return lo, hi
+}
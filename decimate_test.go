@@ -0,0 +1,95 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestBinStatistics(t *testing.T) {
+	nan := math.NaN()
+	if m := mean([]float64{1, nan, 2, 6}); m != 3 {
+		t.Errorf("mean = %v, want 3", m)
+	}
+	if lo, hi := extremes([]float64{nan, 4, -2, 3}); lo != -2 || hi != 4 {
+		t.Errorf("extremes = %v, %v, want -2, 4", lo, hi)
+	}
+	if m := mean([]float64{nan, nan}); !math.IsNaN(m) {
+		t.Errorf("mean of NaNs = %v, want NaN", m)
+	}
+	if lo, hi := extremes([]float64{nan}); !math.IsNaN(lo) || !math.IsNaN(hi) {
+		t.Errorf("extremes of NaNs = %v, %v, want NaN", lo, hi)
+	}
+}
+
+func TestBinSamples(t *testing.T) {
+	// reads of 4, then 3, then none, though 20 samples are asked for
+	sizes := []int{4, 3, 0}
+	read := func(pos int, dst []float64) (int, error) {
+		n := min(sizes[0], len(dst))
+		sizes = sizes[1:]
+		for i := range n {
+			dst[i] = float64(pos + i)
+		}
+		return n, nil
+	}
+	var bins [][]float64
+	err := binSamples(read, 10, 20, 6, 3, func(bin []float64) {
+		bins = append(bins, slices.Clone(bin))
+	})
+	want := [][]float64{{10, 11, 12}, {13, 14, 15}, {16}}
+	if err != nil || !slices.EqualFunc(bins, want, slices.Equal) {
+		t.Errorf("binSamples gave bins %v (%v), want %v", bins, err, want)
+	}
+}
+
+func TestGetDataDecimated(t *testing.T) {
+	dir := "dirfile_decimated"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	// read in several chunks, with the last bin partial
+	defer func(n int) { DecimateChunkSamples = n }(DecimateChunkSamples)
+	DecimateChunkSamples = 7
+
+	// "data" holds 1, 2, ..., 80
+	for mode, want := range map[BinMode][]float64{
+		BinFirst: {11, 14, 17, 20},
+		BinMean:  {12, 15, 18, 20.5},
+		BinMin:   {11, 14, 17, 20},
+		BinMax:   {13, 16, 19, 21},
+	} {
+		got, err := d.GetDataDecimated("data", 10, 11, 3, mode)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("GetDataDecimated(mode %d) = %v (%v), want %v", mode, got, err, want)
+		}
+	}
+	got, err := d.GetDataDecimated("data", 0, 1000, 100, BinFirst)
+	if err != nil || !slices.Equal(got, []float64{1}) {
+		t.Errorf("GetDataDecimated with skipping = %v (%v), want [1]", got, err)
+	}
+	got, err = d.GetDataDecimated("data", 0, 80, 64, BinFirst)
+	if err != nil || !slices.Equal(got, []float64{1, 65}) {
+		t.Errorf("GetDataDecimated with skipping = %v (%v), want [1 65]", got, err)
+	}
+
+	lo, hi, err := d.GetDataEnvelope("data", 70, 100, 4)
+	if err != nil || !slices.Equal(lo, []float64{71, 75, 79}) || !slices.Equal(hi, []float64{74, 78, 80}) {
+		t.Errorf("GetDataEnvelope = %v, %v (%v)", lo, hi, err)
+	}
+	if _, _, err = d.GetDataEnvelope("data", 0, 10, 0); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataEnvelope with zero bin size returned %v, want ErrArgument", err)
+	}
+	if _, err = d.GetDataDecimated("data", 0, 10, 2, BinMode(-1)); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataDecimated with an unknown mode returned %v, want ErrArgument", err)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !purego
+
 package getdata
 
 /*
@@ -9,18 +11,24 @@ package getdata
 */
 import "C"
 import (
-	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
 var nullCString *C.char = (*C.char)(C.NULL)
 
-// Dirfile wraps the GetData.DIRFILE opaque object.
+// Dirfile wraps the GetData.DIRFILE opaque object. The C library is not safe
+// for concurrent use of one DIRFILE, so a Dirfile must be used by only one
+// goroutine at a time (see SyncDirfile and DirfilePool), and not at all while
+// an operation abandoned by a cancelled context is still running (see Wait).
 type Dirfile struct {
-	name string
-	nerr int
-	d    *C.DIRFILE
+	name     string
+	nerr     int
+	d        *C.DIRFILE
+	pending  *sync.WaitGroup // operations abandoned by a cancelled context
+	callback unsafe.Pointer  // C copy of the parser callback handle, if any
 }
 
 // OpenDirfile returns an open Dirfile object, with read/write, encoding, and other flags
@@ -30,22 +38,32 @@ func OpenDirfile(name string, flags Flags) (Dirfile, error) {
 	defer C.free(unsafe.Pointer(cname))
 
 	result := C.gd_open(cname, C.ulong(flags))
-	dirfile := Dirfile{name: name, d: result}
+	dirfile := Dirfile{name: name, d: result, pending: new(sync.WaitGroup)}
 
 	errcode := C.gd_error(result)
 	if errcode != C.GD_E_OK {
-		return dirfile, dirfile.Error()
+		return dirfile, dirfile.opError("OpenDirfile", "")
 	}
 	return dirfile, nil
 }
 
-// Error returns the latest error as a golang error type.
+// Error returns the latest error as a *getdata.Error, carrying the GetData error code.
 // It uses C API gd_error_string to generate the underlying string.
 func (df *Dirfile) Error() error {
 	df.nerr += int(C.gd_error_count(df.d))
+	code := ErrorCode(C.gd_error(df.d))
 	cmsg := C.gd_error_string(df.d, nullCString, 0)
 	defer C.free(unsafe.Pointer(cmsg))
-	return errors.New(C.GoString(cmsg))
+	return &Error{Code: code, Msg: C.GoString(cmsg)}
+}
+
+// opError returns the latest error, as Error does, recording the operation and
+// field code involved.
+func (df *Dirfile) opError(op, fieldcode string) error {
+	err := df.Error().(*Error)
+	err.Op = op
+	err.Field = fieldcode
+	return err
 }
 
 // ErrorCount returns the number of errors raised by this Dirfile since the last
@@ -63,24 +81,29 @@ func (df *Dirfile) Flags(set Flags, reset Flags) Flags {
 	return Flags(retval)
 }
 
-// Close closes all open file handles and flushes all metadata.
+// Close closes all open file handles and flushes all metadata. It first waits
+// for any operation abandoned by a cancelled context to finish (see Wait).
 func (df *Dirfile) Close() error {
+	df.Wait()
 	errcode := C.gd_close(df.d)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("Close", "")
 	}
 	df.d = nil
+	df.releaseCallback()
 	return nil
 }
 
 // Discard closes all open file handles but discards all metadata rather than
-// flushing it to disk.
+// flushing it to disk. Like Close, it first waits for abandoned operations.
 func (df *Dirfile) Discard() error {
+	df.Wait()
 	errcode := C.gd_discard(df.d)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("Discard", "")
 	}
 	df.d = nil
+	df.releaseCallback()
 	return nil
 }
 
@@ -95,7 +118,7 @@ func (df *Dirfile) Flush(fieldcode string) error {
 	defer C.free(unsafe.Pointer(fcode))
 	errcode := C.gd_flush(df.d, fcode)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("Flush", fieldcode)
 	}
 	return nil
 }
@@ -105,7 +128,7 @@ func (df *Dirfile) Flush(fieldcode string) error {
 func (df *Dirfile) FlushAll() error {
 	errcode := C.gd_flush(df.d, nullCString)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("FlushAll", "")
 	}
 	return nil
 }
@@ -119,7 +142,7 @@ func (df *Dirfile) Sync(fieldcode string) error {
 	defer C.free(unsafe.Pointer(fcode))
 	errcode := C.gd_sync(df.d, fcode)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("Sync", fieldcode)
 	}
 	return nil
 }
@@ -129,7 +152,7 @@ func (df *Dirfile) Sync(fieldcode string) error {
 func (df *Dirfile) SyncAll() error {
 	errcode := C.gd_sync(df.d, nullCString)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("SyncAll", "")
 	}
 	return nil
 }
@@ -143,7 +166,7 @@ func (df *Dirfile) RawClose(fieldcode string) error {
 	defer C.free(unsafe.Pointer(fcode))
 	errcode := C.gd_raw_close(df.d, fcode)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("RawClose", fieldcode)
 	}
 	return nil
 }
@@ -153,7 +176,7 @@ func (df *Dirfile) RawClose(fieldcode string) error {
 func (df *Dirfile) RawCloseAll() error {
 	errcode := C.gd_raw_close(df.d, nullCString)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("RawCloseAll", "")
 	}
 	return nil
 }
@@ -162,7 +185,7 @@ func (df *Dirfile) RawCloseAll() error {
 func (df *Dirfile) MetaFlush() error {
 	errcode := C.gd_metaflush(df.d)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("MetaFlush", "")
 	}
 	return nil
 }
@@ -173,7 +196,7 @@ func (df *Dirfile) VerbosePrefix(prefix string) error {
 	defer C.free(unsafe.Pointer(vpre))
 	errcode := C.gd_verbose_prefix(df.d, vpre)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("VerbosePrefix", "")
 	}
 	return nil
 }
@@ -182,7 +205,7 @@ func (df *Dirfile) VerbosePrefix(prefix string) error {
 // a GD_E_BAD_DIRFILE error.
 func InvalidDirfile() Dirfile {
 	df := C.gd_invalid_dirfile()
-	return Dirfile{name: "invalid", d: df}
+	return Dirfile{name: "invalid", d: df, pending: new(sync.WaitGroup)}
 }
 
 // Desync detects desynchronization of a dirfile stored on disk.
@@ -197,7 +220,7 @@ func (df *Dirfile) Desync(pathcheck, reopen bool) (bool, error) {
 	}
 	result := int(C.gd_desync(df.d, flags))
 	if result < 0 {
-		return false, df.Error()
+		return false, df.opError("Desync", "")
 	}
 	return result > 0, nil
 }
@@ -207,22 +230,88 @@ func (df *Dirfile) Desync(pathcheck, reopen bool) (bool, error) {
 // d := make([]int32, 20)
 // df.GetData("field", 5, 0, 2, 0, &d)
 // Returns (n, err) where n is the number of samples read.
-func (df Dirfile) GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+func (df *Dirfile) GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
-	// expectedSamples := numSamples + numFrames*df.
 	retType, ptr := parray2type(out)
-	if retType == UNKNOWN || retType == STRING || ptr == C.NULL {
-		return 0, fmt.Errorf("GetData out variable was not a pointer to numeric slice")
+	if retType == UNKNOWN || retType == STRING {
+		return 0, newError(ErrArgument, "GetData", fieldcode, "GetData out variable was not a pointer to numeric slice")
+	}
+	if ptr == C.NULL {
+		return 0, errZeroLength("GetData", fieldcode)
 	}
 	n := C.gd_getdata(df.d, fcode, C.off_t(firstFrame), C.off_t(firstSample),
 		C.size_t(numFrames), C.size_t(numSamples), C.gd_type_t(retType), ptr)
 	if n == 0 {
-		return 0, df.Error()
+		return 0, df.opError("GetData", fieldcode)
 	}
 	return int(n), nil
 }
 
+// GetDataAlloc fetches data from a vector field in the dirfile (incl. metafields),
+// sizing the output slice itself. out should be a *pointer to* a slice of numeric
+// data of any length (even nil), e.g.
+// var d []float64
+// n, err := df.GetDataAlloc("field", 5, 0, 2, 0, &d)
+// The slice is grown (or shrunk) to hold the requested numFrames*SPF+numSamples
+// samples, limited by the end of the field, and on return holds the n samples
+// actually read.
+func (df *Dirfile) GetDataAlloc(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	requested, err := df.samplesToRead("GetDataAlloc", fieldcode, firstFrame, firstSample, numFrames, numSamples)
+	if err != nil {
+		return 0, err
+	}
+	if !presize(out, requested) {
+		return 0, newError(ErrArgument, "GetDataAlloc", fieldcode, "GetDataAlloc out variable was not a pointer to numeric slice")
+	}
+	if requested == 0 {
+		return 0, nil
+	}
+
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	retType, ptr := parray2type(out)
+	n := int(C.gd_getdata(df.d, fcode, C.off_t(firstFrame), C.off_t(firstSample),
+		0, C.size_t(requested), C.gd_type_t(retType), ptr))
+	if n == 0 && C.gd_error(df.d) != C.GD_E_OK {
+		return 0, df.opError("GetDataAlloc", fieldcode)
+	}
+	presize(out, n)
+	return n, nil
+}
+
+// samplesToRead returns the number of samples a request for numFrames frames
+// plus numSamples samples can return, limited by the end of the field. It is
+// an error to request zero samples, but not to start at or beyond the end.
+func (df *Dirfile) samplesToRead(op, fieldcode string, firstFrame, firstSample, numFrames, numSamples int) (int, error) {
+	spf := df.SPF(fieldcode)
+	if spf == 0 {
+		return 0, df.opError(op, fieldcode)
+	}
+	requested := numFrames*spf + numSamples
+	if requested <= 0 {
+		return 0, errZeroLength(op, fieldcode)
+	}
+
+	// Don't allocate beyond the end of the field, unless reading from the
+	// current position, where the start is not known in advance, or from
+	// INDEX, which has no end.
+	if firstFrame != FRAMEHERE && fieldcode != "INDEX" {
+		eof := df.EoF(fieldcode)
+		if eof < 0 {
+			return 0, df.opError(op, fieldcode)
+		}
+		available := eof - (firstFrame*spf + firstSample)
+		if available < requested {
+			requested = available
+		}
+		if requested < 0 {
+			requested = 0
+		}
+	}
+	return requested, nil
+}
+
 // MplexLookback changes how far GetData searches backwards for the initial
 // value of a field when reading a MPLEX field
 func (df *Dirfile) MplexLookback(lookback int) {
@@ -230,87 +319,87 @@ func (df *Dirfile) MplexLookback(lookback int) {
 }
 
 // GetConstant fills the numeric type pointed to by inptry with the constant or metadata field named fieldcode
-func (df Dirfile) GetConstant(fieldcode string, inptr interface{}) error {
+func (df *Dirfile) GetConstant(fieldcode string, inptr interface{}) error {
 	typecode, uptr := pointer2type(inptr)
 	if typecode == UNKNOWN {
-		return fmt.Errorf("GetConstant called with ptr not a pointer to string or numeric type")
+		return newError(ErrArgument, "GetConstant", fieldcode, "GetConstant called with ptr not a pointer to string or numeric type")
 	}
 
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	errcode := C.gd_get_constant(df.d, fcode, C.gd_type_t(typecode), uptr)
 	if errcode != C.GD_E_OK {
-		return df.Error()
+		return df.opError("GetConstant", fieldcode)
 	}
 	return nil
 }
 
 // GetConstantInt32 returns an int32 for the constant or metadata field named fieldcode
-func (df Dirfile) GetConstantInt32(fieldcode string) (int32, error) {
+func (df *Dirfile) GetConstantInt32(fieldcode string) (int32, error) {
 	var c int32
 	return c, df.GetConstant(fieldcode, &c)
 }
 
 // GetConstantInt64 returns an int64 for the constant or metadata field named fieldcode
-func (df Dirfile) GetConstantInt64(fieldcode string) (int64, error) {
+func (df *Dirfile) GetConstantInt64(fieldcode string) (int64, error) {
 	var c int64
 	return c, df.GetConstant(fieldcode, &c)
 }
 
 // GetConstantFloat32 returns a float32 for the constant or metadata field named fieldcode
-func (df Dirfile) GetConstantFloat32(fieldcode string) (float32, error) {
+func (df *Dirfile) GetConstantFloat32(fieldcode string) (float32, error) {
 	var c float32
 	return c, df.GetConstant(fieldcode, &c)
 }
 
 // GetConstantFloat64 returns a float64 for the constant or metadata field named fieldcode
-func (df Dirfile) GetConstantFloat64(fieldcode string) (float64, error) {
+func (df *Dirfile) GetConstantFloat64(fieldcode string) (float64, error) {
 	var c float64
 	return c, df.GetConstant(fieldcode, &c)
 }
 
 // GetConstantComplex64 returns a complex64 for the constant or metadata field named fieldcode
-func (df Dirfile) GetConstantComplex64(fieldcode string) (complex64, error) {
+func (df *Dirfile) GetConstantComplex64(fieldcode string) (complex64, error) {
 	var c complex64
 	return c, df.GetConstant(fieldcode, &c)
 }
 
 // GetConstantComplex128 returns a complex128 for the constant or metadata field named fieldcode
-func (df Dirfile) GetConstantComplex128(fieldcode string) (complex128, error) {
+func (df *Dirfile) GetConstantComplex128(fieldcode string) (complex128, error) {
 	var c complex128
 	return c, df.GetConstant(fieldcode, &c)
 }
 
 // Constants fills the numerical slice out with all CONST fields in the dirfile
-func (df Dirfile) Constants(out interface{}) error {
+func (df *Dirfile) Constants(out interface{}) error {
 	dType, ptr, arrayLen := array2type(out)
 	n := df.NFieldsByType(CONSTENTRY)
 	if arrayLen < int(n) {
-		return fmt.Errorf("Constants was supplied an array of length %d, but needs to be at least %d",
+		return newError(ErrArgument, "Constants", "", "Constants was supplied an array of length %d, but needs to be at least %d",
 			arrayLen, n)
 	}
 	result := C.gd_constants(df.d, C.gd_type_t(dType))
 	if result == C.NULL {
-		return fmt.Errorf("gd_constants returns error")
+		return df.opError("Constants", "")
 	}
 	C.memcpy(ptr, result, C.ulong(n*sizeof(dType)))
 	return nil
 }
 
 // MConstants fills the numerical slice out with all CONST fields for a specified parent
-func (df Dirfile) MConstants(parent string, out interface{}) error {
+func (df *Dirfile) MConstants(parent string, out interface{}) error {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 
 	dType, ptr, arrayLen := array2type(out)
 	n := df.NMFieldsByType(parent, CONSTENTRY)
 	if arrayLen < int(n) {
-		return fmt.Errorf("MConstants was supplied an array of length %d, but needs to be at least %d",
+		return newError(ErrArgument, "MConstants", parent, "MConstants was supplied an array of length %d, but needs to be at least %d",
 			arrayLen, n)
 	}
 	result := C.gd_mconstants(df.d, cparent, C.gd_type_t(dType))
 	if result == C.NULL {
-		return fmt.Errorf("gd_mconstants returns error")
+		return df.opError("MConstants", parent)
 	}
 	C.memcpy(ptr, result, C.ulong(n*sizeof(dType)))
 	return nil
@@ -318,73 +407,48 @@ func (df Dirfile) MConstants(parent string, out interface{}) error {
 
 // GetCarray fills the numeric array pointed to by out with a list of the values
 // of all elements in a CARRAY field (including metafields).
-func (df Dirfile) GetCarray(fieldcode string, out interface{}) error {
+func (df *Dirfile) GetCarray(fieldcode string, out interface{}) error {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	retType, ptr := parray2type(out)
 	if retType == UNKNOWN || retType == STRING || ptr == C.NULL {
-		return fmt.Errorf("GetCarray out variable was not a pointer to numeric slice")
+		return newError(ErrArgument, "GetCarray", fieldcode, "GetCarray out variable was not a pointer to numeric slice")
 	}
 	result := int(C.gd_get_carray(df.d, fcode, C.gd_type_t(retType), ptr))
 	if result < 0 {
-		return df.Error()
+		return df.opError("GetCarray", fieldcode)
 	}
 	return nil
 }
 
 // GetCarraySlice fills the numeric array pointed to by out with a list a portion of
 // the elements in a CARRAY field (including metafields).
-func (df Dirfile) GetCarraySlice(fieldcode string, start, n uint, out interface{}) error {
+func (df *Dirfile) GetCarraySlice(fieldcode string, start, n uint, out interface{}) error {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	retType, ptr := parray2type(out)
 	if retType == UNKNOWN || retType == STRING || ptr == C.NULL {
-		return fmt.Errorf("GetCarray out variable was not a pointer to numeric slice")
+		return newError(ErrArgument, "GetCarraySlice", fieldcode, "GetCarraySlice out variable was not a pointer to numeric slice")
 	}
 	result := int(C.gd_get_carray_slice(df.d, fcode, C.ulong(start), C.size_t(n), C.gd_type_t(retType), ptr))
 	if result < 0 {
-		return df.Error()
+		return df.opError("GetCarraySlice", fieldcode)
 	}
 	return nil
 }
 
 // CarraysFloat64 generates a slice of slices constaining all CARRAY fields in the dirfile
-func (df Dirfile) CarraysFloat64() ([][]float64, error) {
-	ptr := C.gd_carrays(df.d, C.gd_type_t(FLOAT64))
-	if ptr == (*C.gd_carray_t)(C.NULL) {
-		return nil, fmt.Errorf("gd_carrays returns error")
-	}
-	carrays := make([][]float64, 0)
-	for (*ptr).n > 0 {
-		a := make([]float64, (*ptr).n)
-		C.memcpy(unsafe.Pointer(&a[0]), (*ptr).d, (*ptr).n*8)
-		carrays = append(carrays, a)
-		ptr = (*C.gd_carray_t)(unsafe.Pointer(uintptr(unsafe.Pointer(ptr)) + unsafe.Sizeof(*ptr)))
-	}
-	return carrays, nil
+func (df *Dirfile) CarraysFloat64() ([][]float64, error) {
+	return Carrays[float64](df)
 }
 
 // MCarraysFloat64 generates a slice of slices constaining all CARRAY fields in the dirfile
-func (df Dirfile) MCarraysFloat64(parent string) ([][]float64, error) {
-	cparent := C.CString(parent)
-	defer C.free(unsafe.Pointer(cparent))
-
-	ptr := C.gd_mcarrays(df.d, cparent, C.gd_type_t(FLOAT64))
-	if ptr == (*C.gd_carray_t)(C.NULL) {
-		return nil, fmt.Errorf("gd_carrays returns error")
-	}
-	carrays := make([][]float64, 0)
-	for (*ptr).n > 0 {
-		a := make([]float64, (*ptr).n)
-		C.memcpy(unsafe.Pointer(&a[0]), (*ptr).d, (*ptr).n*8)
-		carrays = append(carrays, a)
-		ptr = (*C.gd_carray_t)(unsafe.Pointer(uintptr(unsafe.Pointer(ptr)) + unsafe.Sizeof(*ptr)))
-	}
-	return carrays, nil
+func (df *Dirfile) MCarraysFloat64(parent string) ([][]float64, error) {
+	return MCarrays[float64](df, parent)
 }
 
 // GetSarray fetches a list of the value of all elements in an SARRAY field.
-func (df Dirfile) GetSarray(fieldcode string) ([]string, error) {
+func (df *Dirfile) GetSarray(fieldcode string) ([]string, error) {
 	nstr := df.ArrayLen(fieldcode)
 	var dummyptr *C.char
 	cptr := (**C.char)(C.malloc(C.ulong(uintptr(nstr) * unsafe.Sizeof(dummyptr))))
@@ -395,7 +459,7 @@ func (df Dirfile) GetSarray(fieldcode string) ([]string, error) {
 
 	result := C.gd_get_sarray(df.d, fcode, cptr)
 	if result < 0 {
-		return nil, df.Error()
+		return nil, df.opError("GetSarray", fieldcode)
 	}
 	cstr0 := *cptr
 	sarray := make([]string, nstr)
@@ -408,10 +472,10 @@ func (df Dirfile) GetSarray(fieldcode string) ([]string, error) {
 }
 
 // GetSarraySlice fetches a portion of the elements in an SARRAY field.
-func (df Dirfile) GetSarraySlice(fieldcode string, start, n int) ([]string, error) {
+func (df *Dirfile) GetSarraySlice(fieldcode string, start, n int) ([]string, error) {
 	nstr := df.ArrayLen(fieldcode)
 	if n > nstr {
-		return nil, fmt.Errorf("GetSarraySlice called with n=%d, which exceeds ArrayLen=%d",
+		return nil, newError(ErrBounds, "GetSarraySlice", fieldcode, "GetSarraySlice called with n=%d, which exceeds ArrayLen=%d",
 			n, nstr)
 	}
 	cptr := (**C.char)(C.malloc(C.ulong(uintptr(nstr) * unsafe.Sizeof(C.NULL))))
@@ -422,7 +486,7 @@ func (df Dirfile) GetSarraySlice(fieldcode string, start, n int) ([]string, erro
 
 	result := C.gd_get_sarray_slice(df.d, fcode, C.ulong(start), C.size_t(n), cptr)
 	if result < 0 {
-		return nil, df.Error()
+		return nil, df.opError("GetSarraySlice", fieldcode)
 	}
 	cstr0 := *cptr
 	sarray := make([]string, n)
@@ -438,10 +502,10 @@ func (df Dirfile) GetSarraySlice(fieldcode string, start, n int) ([]string, erro
 }
 
 // Sarrays returns the value of all SARRAY fields (including metafields)
-func (df Dirfile) Sarrays() ([][]string, error) {
+func (df *Dirfile) Sarrays() ([][]string, error) {
 	cptr := (***C.char)(C.gd_sarrays(df.d))
 	if cptr == (***C.char)(C.NULL) {
-		return nil, fmt.Errorf("Dirfile.Sarrays returned NULL")
+		return nil, df.opError("Sarrays", "")
 	}
 
 	var result [][]string
@@ -469,13 +533,13 @@ func (df Dirfile) Sarrays() ([][]string, error) {
 }
 
 // MSarrays returns the value of all SARRAY fields for a given parent field
-func (df Dirfile) MSarrays(parent string) ([][]string, error) {
+func (df *Dirfile) MSarrays(parent string) ([][]string, error) {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 
 	cptr := (***C.char)(C.gd_msarrays(df.d, cparent))
 	if cptr == (***C.char)(C.NULL) {
-		return nil, fmt.Errorf("Dirfile.MSarrays returned NULL")
+		return nil, df.opError("MSarrays", parent)
 	}
 
 	var result [][]string
@@ -503,7 +567,7 @@ func (df Dirfile) MSarrays(parent string) ([][]string, error) {
 }
 
 // GetString returns the value of a STRING field (including metafields)
-func (df Dirfile) GetString(fieldcode string) (string, error) {
+func (df *Dirfile) GetString(fieldcode string) (string, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	bsize := C.size_t(256)
@@ -511,16 +575,16 @@ func (df Dirfile) GetString(fieldcode string) (string, error) {
 	defer C.free(unsafe.Pointer(cresult))
 	n := int(C.gd_get_string(df.d, fcode, bsize, cresult))
 	if n == 0 {
-		return "", df.Error()
+		return "", df.opError("GetString", fieldcode)
 	}
 	return C.GoString(cresult), nil
 }
 
 // Strings returns the value of all STRING fields (including metafields)
-func (df Dirfile) Strings() ([]string, error) {
+func (df *Dirfile) Strings() ([]string, error) {
 	cptr := (**C.char)(C.gd_strings(df.d))
 	if cptr == (**C.char)(C.NULL) {
-		return nil, fmt.Errorf("Dirfile.Strings returned NULL")
+		return nil, df.opError("Strings", "")
 	}
 
 	var result []string
@@ -539,13 +603,13 @@ func (df Dirfile) Strings() ([]string, error) {
 }
 
 // MStrings returns the value of all STRING fields for a specified parent
-func (df Dirfile) MStrings(parent string) ([]string, error) {
+func (df *Dirfile) MStrings(parent string) ([]string, error) {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 
 	cptr := (**C.char)(C.gd_mstrings(df.d, cparent))
 	if cptr == (**C.char)(C.NULL) {
-		return nil, fmt.Errorf("Dirfile.MStrings returned NULL")
+		return nil, df.opError("MStrings", parent)
 	}
 
 	var result []string
@@ -564,14 +628,14 @@ func (df Dirfile) MStrings(parent string) ([]string, error) {
 }
 
 // FramenumSubset performs reverse loop-up on a portion of a field. Assumes the portion is monotonic.
-func (df Dirfile) FramenumSubset(fieldcode string, value float64, start, end int) float64 {
+func (df *Dirfile) FramenumSubset(fieldcode string, value float64, start, end int) float64 {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return float64(C.gd_framenum_subset(df.d, fcode, C.double(value), C.off_t(start), C.off_t(end)))
 }
 
 // Framenum performs reverse loop-up on a field. Assumes the entire field is monotonic.
-func (df Dirfile) Framenum(fieldcode string, value float64) float64 {
+func (df *Dirfile) Framenum(fieldcode string, value float64) float64 {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return float64(C.gd_framenum(df.d, fcode, C.double(value)))
@@ -587,12 +651,12 @@ func (df *Dirfile) PutData(fieldcode string, firstFrame, firstSample int, data i
 	defer C.free(unsafe.Pointer(fcode))
 	dType, ptr, lenData := array2type(data)
 	if dType == UNKNOWN || dType == NULLTYPE || ptr == C.NULL {
-		return 0, fmt.Errorf("PutData data variable was not a numeric slice")
+		return 0, newError(ErrArgument, "PutData", fieldcode, "PutData data variable was not a numeric slice")
 	}
 	n := C.gd_putdata(df.d, fcode, C.off_t(firstFrame), C.off_t(firstSample),
 		C.size_t(0), C.size_t(lenData), C.gd_type_t(dType), ptr)
 	if n == 0 {
-		return 0, df.Error()
+		return 0, df.opError("PutData", fieldcode)
 	}
 	return int(n), nil
 }
@@ -604,11 +668,11 @@ func (df *Dirfile) PutConstant(fieldcode string, data interface{}) error {
 	defer C.free(unsafe.Pointer(fcode))
 	dType, ptr := value2type(data)
 	if dType == UNKNOWN || dType == NULLTYPE || ptr == C.NULL {
-		return fmt.Errorf("PutConstant data variable was not a numeric value")
+		return newError(ErrArgument, "PutConstant", fieldcode, "PutConstant data variable was not a numeric value")
 	}
 	n := C.gd_put_constant(df.d, fcode, C.gd_type_t(dType), ptr)
 	if n != 0 {
-		return df.Error()
+		return df.opError("PutConstant", fieldcode)
 	}
 	return nil
 }
@@ -620,7 +684,7 @@ func (df *Dirfile) PutCarray(fieldcode string, array interface{}) error {
 	dType, ptr, _ := array2type(array)
 	n := C.gd_put_carray(df.d, fcode, C.gd_type_t(dType), ptr)
 	if n != 0 {
-		return df.Error()
+		return df.opError("PutCarray", fieldcode)
 	}
 	return nil
 }
@@ -632,7 +696,7 @@ func (df *Dirfile) PutCarraySlice(fieldcode string, start, n uint, array interfa
 	dType, ptr, _ := array2type(array)
 	result := C.gd_put_carray_slice(df.d, fcode, C.ulong(start), C.size_t(n), C.gd_type_t(dType), ptr)
 	if result != 0 {
-		return df.Error()
+		return df.opError("PutCarraySlice", fieldcode)
 	}
 	return nil
 }
@@ -641,7 +705,7 @@ func (df *Dirfile) PutCarraySlice(fieldcode string, start, n uint, array interfa
 func (df *Dirfile) PutSarray(fieldcode string, sarray []string) error {
 	nstr := len(sarray)
 	if nstr != df.ArrayLen(fieldcode) {
-		return fmt.Errorf("Field %s length %d doesn't match length %d of sarray argument",
+		return newError(ErrArgument, "PutSarray", fieldcode, "Field %s length %d doesn't match length %d of sarray argument",
 			fieldcode, df.ArrayLen(fieldcode), nstr)
 	}
 
@@ -656,7 +720,7 @@ func (df *Dirfile) PutSarray(fieldcode string, sarray []string) error {
 	}
 	result := C.gd_put_sarray(df.d, fcode, (**C.char)(unsafe.Pointer(&cpointers[0])))
 	if result != 0 {
-		return df.Error()
+		return df.opError("PutSarray", fieldcode)
 	}
 	return nil
 }
@@ -665,7 +729,7 @@ func (df *Dirfile) PutSarray(fieldcode string, sarray []string) error {
 func (df *Dirfile) PutSarraySlice(fieldcode string, start, n uint, sarray []string) error {
 	nstr := len(sarray)
 	if nstr != int(n) {
-		return fmt.Errorf("Slice n=%d doesn't match length %d of sarray argument",
+		return newError(ErrArgument, "PutSarraySlice", fieldcode, "Slice n=%d doesn't match length %d of sarray argument",
 			n, nstr)
 	}
 
@@ -680,7 +744,7 @@ func (df *Dirfile) PutSarraySlice(fieldcode string, start, n uint, sarray []stri
 	}
 	result := C.gd_put_sarray_slice(df.d, fcode, C.ulong(start), C.size_t(n), (**C.char)(unsafe.Pointer(&cpointers[0])))
 	if result != 0 {
-		return df.Error()
+		return df.opError("PutSarraySlice", fieldcode)
 	}
 	return nil
 }
@@ -693,7 +757,7 @@ func (df *Dirfile) PutString(fieldcode, value string) error {
 	defer C.free(unsafe.Pointer(newval))
 	n := C.gd_put_string(df.d, fcode, newval)
 	if n != 0 {
-		return df.Error()
+		return df.opError("PutString", fieldcode)
 	}
 	return nil
 }
@@ -709,18 +773,18 @@ func (df *Dirfile) Seek(fieldcode string, framenum, samplenum int, flags SeekFla
 	defer C.free(unsafe.Pointer(fcode))
 	result := int(C.gd_seek(df.d, fcode, C.off_t(framenum), C.off_t(samplenum), C.int(flags)))
 	if result < 0 {
-		return 0, fmt.Errorf("Dirfile.Seek returned %d", result)
+		return 0, df.opError("Seek", fieldcode)
 	}
 	return result, nil
 }
 
 // Tell returns the position of the I/O pointer, in samples, of the field named fieldcode.
-func (df Dirfile) Tell(fieldcode string) (int, error) {
+func (df *Dirfile) Tell(fieldcode string) (int, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	result := int(C.gd_tell(df.d, fcode))
 	if result < 0 {
-		return 0, fmt.Errorf("Dirfile.Tell returned %d", result)
+		return 0, df.opError("Tell", fieldcode)
 	}
 	return result, nil
 }
@@ -729,7 +793,8 @@ func (df Dirfile) Tell(fieldcode string) (int, error) {
 func EncodingSupport(encoding Flags) (bool, error) {
 	result := C.gd_encoding_support(C.ulong(encoding))
 	if result < 0 {
-		return false, fmt.Errorf("EncodingSupport(0x%x) returned -1", encoding)
+		return false, &Error{Code: ErrUnsupported, Op: "EncodingSupport",
+			Msg: fmt.Sprintf("Unknown or unsupported encoding 0x%x", encoding)}
 	}
 	return (result > 0), nil
 }
@@ -737,50 +802,50 @@ func EncodingSupport(encoding Flags) (bool, error) {
 // Reading Metadata
 
 // Dirfilename returns the full path to the dirfile
-func (df Dirfile) Dirfilename() string {
+func (df *Dirfile) Dirfilename() string {
 	result := C.gd_dirfilename(df.d)
 	return C.GoString(result)
 }
 
 // NFrames returns the number of frames in the dirfile (or on error, 0)
-func (df Dirfile) NFrames() int {
+func (df *Dirfile) NFrames() int {
 	return int(C.gd_nframes(df.d))
 }
 
 // NFragments returns the number of fragments in the dirfile (or on error, 0)
-func (df Dirfile) NFragments() int {
+func (df *Dirfile) NFragments() int {
 	return int(C.gd_nfragments(df.d))
 }
 
 // Fragment returns a Fragment pointer to the nth dirfile fragment
-func (df Dirfile) Fragment(n int) (*Fragment, error) {
-	return NewFragment(&df, n)
+func (df *Dirfile) Fragment(n int) (*Fragment, error) {
+	return NewFragment(df, n)
 }
 
 // LinterpTablename returns the path to the lookup table associated with a LINTERP field
-func (df Dirfile) LinterpTablename(fieldcode string) (string, error) {
+func (df *Dirfile) LinterpTablename(fieldcode string) (string, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_linterp_tablename(df.d, fcode)
 	if result == nullCString {
-		return "", df.Error()
+		return "", df.opError("LinterpTablename", fieldcode)
 	}
 	return C.GoString(result), nil
 }
 
 // Filename returns the path to the binary file for a given RAW field
-func (df Dirfile) Filename(fieldcode string) (string, error) {
+func (df *Dirfile) Filename(fieldcode string) (string, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_raw_filename(df.d, fcode)
 	if result == nullCString {
-		return "", df.Error()
+		return "", df.opError("Filename", fieldcode)
 	}
 	return C.GoString(result), nil
 }
 
 // SPF returns the number of samples per frame for a given field
-func (df Dirfile) SPF(fieldcode string) int {
+func (df *Dirfile) SPF(fieldcode string) int {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return int(C.gd_spf(df.d, fcode))
@@ -788,7 +853,7 @@ func (df Dirfile) SPF(fieldcode string) int {
 
 // ArrayLen returns the number of elements in a scalar field (CARRAY, CONST,
 // or STRING)
-func (df Dirfile) ArrayLen(fieldcode string) int {
+func (df *Dirfile) ArrayLen(fieldcode string) int {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return int(C.gd_array_len(df.d, fcode))
@@ -802,11 +867,11 @@ func (df *Dirfile) Entry(fieldcode string) (Entry, error) {
 	result := int(C.gd_entry(df.d, fcode, &ce))
 	defer C.gd_free_entry_strings(&ce)
 	if result != 0 {
-		return Entry{}, df.Error()
+		return Entry{}, df.opError("Entry", fieldcode)
 	}
 	idx, err := df.FragmentIndex(fieldcode)
 	if err != nil {
-		return Entry{}, fmt.Errorf("FragmentIndex error: %s", err.Error())
+		return Entry{}, err
 	}
 	entry := entryFromC(df, &ce)
 	entry.fragment = idx
@@ -814,39 +879,39 @@ func (df *Dirfile) Entry(fieldcode string) (Entry, error) {
 }
 
 // EntryType returns the EntryType for the named field.
-func (df Dirfile) EntryType(fieldcode string) EntryType {
+func (df *Dirfile) EntryType(fieldcode string) EntryType {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return EntryType(C.gd_entry_type(df.d, fcode))
 }
 
 // FragmentIndex returns the index of the fragment which defines a given field or alias
-func (df Dirfile) FragmentIndex(fieldcode string) (int, error) {
+func (df *Dirfile) FragmentIndex(fieldcode string) (int, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	result := int(C.gd_fragment_index(df.d, fcode))
 	if result < 0 {
-		return 0, df.Error()
+		return 0, df.opError("FragmentIndex", fieldcode)
 	}
 	return result, nil
 }
 
 // EoF returns the end-of-field position, in frames
-func (df Dirfile) EoF(fieldcode string) int {
+func (df *Dirfile) EoF(fieldcode string) int {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return int(C.gd_eof(df.d, fcode))
 }
 
 // BoF returns the beginning-of-field position, in frames
-func (df Dirfile) BoF(fieldcode string) int {
+func (df *Dirfile) BoF(fieldcode string) int {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return int(C.gd_bof(df.d, fcode))
 }
 
 // NativeType returns the native data type of a field or alias
-func (df Dirfile) NativeType(fieldcode string) RetType {
+func (df *Dirfile) NativeType(fieldcode string) RetType {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return RetType(C.gd_native_type(df.d, fcode))
@@ -856,36 +921,36 @@ func (df Dirfile) NativeType(fieldcode string) RetType {
 // Any function which accepts a field code as an argument performs the same checks
 // as this function, so it is not necessary to call this function to verify a field
 // code before passing it to another function.
-func (df Dirfile) Validate(fieldcode string) error {
+func (df *Dirfile) Validate(fieldcode string) error {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	result := int(C.gd_validate(df.d, fcode))
 	if result < 0 {
-		return df.Error()
+		return df.opError("Validate", fieldcode)
 	}
 	return nil
 }
 
 // Hidden returns whether a given field code is hidden or not
-func (df Dirfile) Hidden(fieldcode string) (bool, error) {
+func (df *Dirfile) Hidden(fieldcode string) (bool, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_hidden(df.d, fcode)
 	if result < 0 {
-		return false, df.Error()
+		return false, df.opError("Hidden", fieldcode)
 	}
 	return result > 0, nil
 }
 
 // Aliases returns a list of aliases of a given field code
-func (df Dirfile) Aliases(fieldcode string) ([]string, error) {
+func (df *Dirfile) Aliases(fieldcode string) ([]string, error) {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 
 	var result []string
 	cptr := (**C.char)(C.gd_aliases(df.d, fcode))
 	if cptr == (**C.char)(C.NULL) {
-		return result, df.Error()
+		return result, df.opError("Aliases", fieldcode)
 	}
 
 	cstr0 := *cptr
@@ -904,15 +969,27 @@ func (df Dirfile) Aliases(fieldcode string) ([]string, error) {
 
 // NAliases returns the number of aliases of a given field code (always at least one).
 // Zero indicates an error
-func (df Dirfile) NAliases(fieldcode string) int {
+func (df *Dirfile) NAliases(fieldcode string) int {
 	fcode := C.CString(fieldcode)
 	defer C.free(unsafe.Pointer(fcode))
 	return int(C.gd_naliases(df.d, fcode))
 
 }
 
+// AliasTarget returns the field code that the alias fieldcode refers to, or an
+// error if fieldcode is not an alias
+func (df *Dirfile) AliasTarget(fieldcode string) (string, error) {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	target := C.gd_alias_target(df.d, fcode)
+	if target == nullCString {
+		return "", df.opError("AliasTarget", fieldcode)
+	}
+	return C.GoString(target), nil
+}
+
 // NEntries returns the number of fields in the dirfile satisfying various criteria.
-func (df Dirfile) NEntries(parent string, etype EntryType, flags EntryType) uint {
+func (df *Dirfile) NEntries(parent string, etype EntryType, flags EntryType) uint {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -922,23 +999,23 @@ func (df Dirfile) NEntries(parent string, etype EntryType, flags EntryType) uint
 }
 
 // NFields returns the number of fields in the dirfile
-func (df Dirfile) NFields() uint {
+func (df *Dirfile) NFields() uint {
 	return uint(C.gd_nfields(df.d))
 }
 
 // NVectors returns the number of vector fields (that is all field types except
 // CONST, CARRAY, and STRING) in the dirfile
-func (df Dirfile) NVectors() uint {
+func (df *Dirfile) NVectors() uint {
 	return uint(C.gd_nvectors(df.d))
 }
 
 // NFieldsByType returns the number of fields in the dirfile.
-func (df Dirfile) NFieldsByType(etype EntryType) uint {
+func (df *Dirfile) NFieldsByType(etype EntryType) uint {
 	return uint(C.gd_nfields_by_type(df.d, C.gd_entype_t(etype)))
 }
 
 // NMFields returns the number of metafields in the dirfile for a particular parent.
-func (df Dirfile) NMFields(parent string) uint {
+func (df *Dirfile) NMFields(parent string) uint {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -949,7 +1026,7 @@ func (df Dirfile) NMFields(parent string) uint {
 
 // NMVectors returns the number of vector metafields in the dirfile for a particular parent.
 // (That is, all field types except CONST, CARRAY, and STRING.)
-func (df Dirfile) NMVectors(parent string) uint {
+func (df *Dirfile) NMVectors(parent string) uint {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -960,7 +1037,7 @@ func (df Dirfile) NMVectors(parent string) uint {
 
 // NMFieldsByType returns the number of metafields in the dirfile for a particular
 // parent and a specified field type.
-func (df Dirfile) NMFieldsByType(parent string, etype EntryType) uint {
+func (df *Dirfile) NMFieldsByType(parent string, etype EntryType) uint {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -982,19 +1059,19 @@ func ppchar2stringSlice(listptr unsafe.Pointer) []string {
 }
 
 // MatchEntries returns a list of entries in the dirfile satisfying various criteria
-func (df Dirfile) MatchEntries(regex string, fragment int, et EntryType, flags Flags) ([]string, error) {
+func (df *Dirfile) MatchEntries(regex string, fragment int, et EntryType, flags Flags) ([]string, error) {
 	cregex := C.CString(regex)
 	defer C.free(unsafe.Pointer(cregex))
 	var ptr (**C.char)
 	n := C.gd_match_entries(df.d, cregex, C.int(fragment), C.int(et), C.uint(flags), &ptr)
 	if n < 0 {
-		return nil, df.Error()
+		return nil, df.opError("MatchEntries", "")
 	}
 	return ppchar2stringSlice(unsafe.Pointer(ptr)), nil
 }
 
 // EntryList returns a slice of strings listing all fields meeting various criteria.
-func (df Dirfile) EntryList(parent string, et EntryType, flags EntryType) []string {
+func (df *Dirfile) EntryList(parent string, et EntryType, flags EntryType) []string {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -1004,22 +1081,22 @@ func (df Dirfile) EntryList(parent string, et EntryType, flags EntryType) []stri
 }
 
 // FieldList returns a slice of strings listing all fields (no metafields).
-func (df Dirfile) FieldList() []string {
+func (df *Dirfile) FieldList() []string {
 	return ppchar2stringSlice(unsafe.Pointer(C.gd_field_list(df.d)))
 }
 
 // VectorList returns a slice of strings listing all vector fields (no metafields).
-func (df Dirfile) VectorList() []string {
+func (df *Dirfile) VectorList() []string {
 	return ppchar2stringSlice(unsafe.Pointer(C.gd_vector_list(df.d)))
 }
 
 // FieldListByType returns a slice of strings listing all fields (no metafields).
-func (df Dirfile) FieldListByType(et EntryType) []string {
+func (df *Dirfile) FieldListByType(et EntryType) []string {
 	return ppchar2stringSlice(unsafe.Pointer(C.gd_field_list_by_type(df.d, C.gd_entype_t(et))))
 }
 
 // MFieldList returns a slice of strings listing all metafields in the dirfile for a particular parent.
-func (df Dirfile) MFieldList(parent string) []string {
+func (df *Dirfile) MFieldList(parent string) []string {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -1029,7 +1106,7 @@ func (df Dirfile) MFieldList(parent string) []string {
 }
 
 // MVectorList returns a slice of strings listing all vector metafields in the dirfile for a particular parent.
-func (df Dirfile) MVectorList(parent string) []string {
+func (df *Dirfile) MVectorList(parent string) []string {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -1039,7 +1116,7 @@ func (df Dirfile) MVectorList(parent string) []string {
 }
 
 // MFieldListByType returns a slice of strings listing all metafields of a specified type for a particular parent.
-func (df Dirfile) MFieldListByType(parent string, et EntryType) []string {
+func (df *Dirfile) MFieldListByType(parent string, et EntryType) []string {
 	cparent := C.CString(parent)
 	defer C.free(unsafe.Pointer(cparent))
 	if len(parent) == 0 {
@@ -1059,7 +1136,7 @@ func (df *Dirfile) IncludeAffix(fragname string, index int, prefix, suffix strin
 	defer C.free(unsafe.Pointer(csuffix))
 	result := int(C.gd_include_affix(df.d, fragmentname, C.int(index), cprefix, csuffix, C.ulong(flags)))
 	if result < 0 {
-		return result, df.Error()
+		return result, df.opError("IncludeAffix", "")
 	}
 	return result, nil
 }
@@ -1075,7 +1152,7 @@ func (df *Dirfile) IncludeAtIndex(fragname string, index int, flags Flags) (int,
 	defer C.free(unsafe.Pointer(fragmentname))
 	result := int(C.gd_include(df.d, fragmentname, C.int(index), C.ulong(flags)))
 	if result < 0 {
-		return result, df.Error()
+		return result, df.opError("IncludeAtIndex", "")
 	}
 	return result, nil
 }
@@ -1088,7 +1165,7 @@ func (df *Dirfile) IncludeNS(fragname string, index int, namespace string, flags
 	defer C.free(unsafe.Pointer(cnamespace))
 	result := int(C.gd_include_ns(df.d, fragmentname, C.int(index), cnamespace, C.ulong(flags)))
 	if result < 0 {
-		return result, df.Error()
+		return result, df.opError("IncludeNS", "")
 	}
 	return result, nil
 }
@@ -1101,7 +1178,7 @@ func (df *Dirfile) Uninclude(index int, del bool) error {
 	}
 	result := int(C.gd_uninclude(df.d, C.int(index), cdel))
 	if result != C.GD_E_OK {
-		return df.Error()
+		return df.opError("Uninclude", "")
 	}
 	return nil
 }
@@ -1111,7 +1188,7 @@ func (df *Dirfile) GetReference() (Entry, error) {
 	result := C.gd_reference(df.d, nullCString)
 	if result == nullCString {
 		var e Entry
-		return e, df.Error()
+		return e, df.opError("GetReference", "")
 	}
 	return df.Entry(C.GoString(result))
 }
@@ -1124,22 +1201,81 @@ func (df *Dirfile) SetReference(fieldcode string) (Entry, error) {
 	result := C.gd_reference(df.d, fcode)
 	if result == nullCString {
 		var e Entry
-		return e, df.Error()
+		return e, df.opError("SetReference", fieldcode)
 	}
 	return df.Entry(C.GoString(result))
 }
 
-// AddEntry adds a field to a dirfile.
-// Avoid calling the C.gd_add() function, because it would require constructing
-// a *C.gd_entry_t from our go data.
+// AddEntry adds a field to a dirfile. Field parameters the entry gives as CONST
+// or CARRAY field codes (see Entry.Scalar) are added as those field codes, not
+// as their current values. The value of a scalar entry obtained from
+// Dirfile.Entry is read from its source dirfile, so entries can be copied
+// between dirfiles.
 func (df *Dirfile) AddEntry(e *Entry) error {
+	if err := df.addEntry(e); err != nil {
+		return err
+	}
+	if e.Hidden() {
+		return df.Hide(e.name)
+	}
+	return nil
+}
+
+// isVector reports whether fields of type et are vector fields, which gd_add
+// and gd_madd can add from a gd_entry_t
+func isVector(et EntryType) bool {
+	switch et {
+	case RAWENTRY, BITENTRY, SBITENTRY, LINCOMENTRY, LINTERPENTRY, MULTIPLYENTRY,
+		DIVIDEENTRY, PHASEENTRY, POLYNOMENTRY, RECIPENTRY, MPLEXENTRY, WINDOWENTRY,
+		INDIRENTRY, SINDIRENTRY:
+		return true
+	}
+	return false
+}
+
+func (df *Dirfile) addEntry(e *Entry) error {
+	if isVector(e.fieldType) {
+		var ce C.gd_entry_t
+		free := entryToC(e, &ce)
+		defer free()
+		if C.gd_add(df.d, &ce) < 0 {
+			return df.opError("AddEntry", e.name)
+		}
+		return nil
+	}
 	switch e.fieldType {
-	case RAWENTRY:
-		return df.AddRaw(e.name, e.dataType, e.spf, e.fragment)
-	case BITENTRY:
-		return df.AddBit(e.name, e.inFields[0], e.bitnum, e.numbits, e.fragment)
+	case ALIASENTRY:
+		return df.AddAlias(e.name, e.inFields[0], e.fragment)
+	case CONSTENTRY:
+		if err := e.loadValue("AddEntry"); err != nil {
+			return err
+		}
+		return df.AddConst(e.name, e.constType, e.value, e.fragment)
+	case CARRAYENTRY:
+		if err := e.loadValue("AddEntry"); err != nil {
+			return err
+		}
+		return df.AddCarray(e.name, e.constType, e.value, e.fragment)
+	case STRINGENTRY:
+		if err := e.loadValue("AddEntry"); err != nil {
+			return err
+		}
+		value, ok := e.value.(string)
+		if !ok {
+			return newError(ErrArgument, "AddEntry", e.name, "Entry %s has a STRING value of type %T, want string", e.name, e.value)
+		}
+		return df.AddString(e.name, value, e.fragment)
+	case SARRAYENTRY:
+		if err := e.loadValue("AddEntry"); err != nil {
+			return err
+		}
+		values, ok := e.value.([]string)
+		if !ok {
+			return newError(ErrArgument, "AddEntry", e.name, "Entry %s has a SARRAY value of type %T, want []string", e.name, e.value)
+		}
+		return df.AddSarray(e.name, values, e.fragment)
 	}
-	return fmt.Errorf("Unknown or not implemented entry type 0x%x", e.fieldType)
+	return newError(ErrBadFieldType, "AddEntry", e.name, "Unknown or not implemented entry type 0x%x", e.fieldType)
 }
 
 // AddRaw adds a RAW field to the dirfile
@@ -1148,7 +1284,7 @@ func (df *Dirfile) AddRaw(fieldname string, dataType RetType, spf uint, fragment
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_add_raw(df.d, fcode, C.gd_type_t(dataType), C.uint(spf), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddRaw", fieldname)
 	}
 	return nil
 }
@@ -1159,7 +1295,7 @@ func (df *Dirfile) AddSpec(line string, fragIndex int) error {
 	defer C.free(unsafe.Pointer(specline))
 	result := C.gd_add_spec(df.d, specline, C.int(fragIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddSpec", "")
 	}
 	return nil
 }
@@ -1172,7 +1308,7 @@ func (df *Dirfile) AddBit(fieldname, inField string, bitnum, numbits, fragmentIn
 	defer C.free(unsafe.Pointer(ifield))
 	result := C.gd_add_bit(df.d, fcode, ifield, C.int(bitnum), C.int(numbits), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddBit", fieldname)
 	}
 	return nil
 }
@@ -1186,7 +1322,7 @@ func (df *Dirfile) AddCarray(fieldname string, constType RetType, data interface
 	result := C.gd_add_carray(df.d, fcode, C.gd_type_t(constType), C.size_t(nData),
 		C.gd_type_t(dataType), pvalues, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddCarray", fieldname)
 	}
 	return nil
 }
@@ -1200,7 +1336,7 @@ func (df *Dirfile) AddConst(fieldname string, constType RetType, data interface{
 	result := C.gd_add_const(df.d, fcode, C.gd_type_t(constType),
 		C.gd_type_t(dataType), pvalue, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddConst", fieldname)
 	}
 	return nil
 }
@@ -1215,7 +1351,7 @@ func (df *Dirfile) AddDivide(fieldname, inField1, inField2 string, fragmentIndex
 	defer C.free(unsafe.Pointer(cfield2))
 	result := C.gd_add_divide(df.d, fcode, cfield1, cfield2, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddDivide", fieldname)
 	}
 	return nil
 }
@@ -1230,7 +1366,28 @@ func (df *Dirfile) AddIndir(fieldname, indexField, carrayField string, fragmentI
 	defer C.free(unsafe.Pointer(cfield2))
 	result := C.gd_add_indir(df.d, fcode, cfield1, cfield2, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddIndir", fieldname)
+	}
+	return nil
+}
+
+// checkLincom returns an error unless a LINCOM has 1 to MAXLINCOM input fields,
+// each with one of m and b
+func checkLincom(op, fieldname string, nfields, nm, nb int) error {
+	if nfields != nm || nfields != nb {
+		return newError(ErrArgument, op, fieldname, "%s needs inFields, m, and b to be of equal length", op)
+	}
+	if nfields < 1 || nfields > MAXLINCOM {
+		return newError(ErrArgument, op, fieldname, "%s needs 1 to %d input fields, not %d", op, MAXLINCOM, nfields)
+	}
+	return nil
+}
+
+// checkPolynom returns an error unless a POLYNOM has 1 to MAXPOLYORD+1
+// coefficients
+func checkPolynom(op, fieldname string, ncoef int) error {
+	if ncoef < 1 || ncoef > MAXPOLYORD+1 {
+		return newError(ErrArgument, op, fieldname, "%s needs 1 to %d coefficients, not %d", op, MAXPOLYORD+1, ncoef)
 	}
 	return nil
 }
@@ -1241,8 +1398,8 @@ func (df *Dirfile) AddLincom(fieldname string, inFields []string, m, b []float64
 	fcode := C.CString(fieldname)
 	defer C.free(unsafe.Pointer(fcode))
 	nfields := len(inFields)
-	if nfields != len(m) || nfields != len(b) {
-		return fmt.Errorf("AddLincom needs inFields, m, and b to be of equal length")
+	if err := checkLincom("AddLincom", fieldname, nfields, len(m), len(b)); err != nil {
+		return err
 	}
 	cpointers := make([]uintptr, nfields)
 	for i, infield := range inFields {
@@ -1254,7 +1411,7 @@ func (df *Dirfile) AddLincom(fieldname string, inFields []string, m, b []float64
 		(*C.double)(unsafe.Pointer(&m[0])),
 		(*C.double)(unsafe.Pointer(&b[0])), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddLincom", fieldname)
 	}
 	return nil
 }
@@ -1265,8 +1422,8 @@ func (df *Dirfile) AddCLincom(fieldname string, inFields []string, m, b []comple
 	fcode := C.CString(fieldname)
 	defer C.free(unsafe.Pointer(fcode))
 	nfields := len(inFields)
-	if nfields != len(m) || nfields != len(b) {
-		return fmt.Errorf("AddCLincom needs inFields, m, and b to be of equal length")
+	if err := checkLincom("AddCLincom", fieldname, nfields, len(m), len(b)); err != nil {
+		return err
 	}
 	cpointers := make([]uintptr, nfields)
 	for i, infield := range inFields {
@@ -1278,7 +1435,7 @@ func (df *Dirfile) AddCLincom(fieldname string, inFields []string, m, b []comple
 		(*C.double)(unsafe.Pointer(&m[0])),
 		(*C.double)(unsafe.Pointer(&b[0])), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddCLincom", fieldname)
 	}
 	return nil
 }
@@ -1293,7 +1450,7 @@ func (df *Dirfile) AddLinterp(fieldname, inField, table string, fragmentIndex in
 	defer C.free(unsafe.Pointer(ctable))
 	result := C.gd_add_linterp(df.d, fcode, cfield, ctable, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddLinterp", fieldname)
 	}
 	return nil
 }
@@ -1310,7 +1467,7 @@ func (df *Dirfile) AddMplex(fieldname, inField, countField string,
 	result := C.gd_add_mplex(df.d, fcode, cfield, ctable, C.int(countVal), C.int(period),
 		C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddMplex", fieldname)
 	}
 	return nil
 }
@@ -1325,7 +1482,7 @@ func (df *Dirfile) AddMultiply(fieldname, inField1, inField2 string, fragmentInd
 	defer C.free(unsafe.Pointer(cfield2))
 	result := C.gd_add_multiply(df.d, fcode, cfield1, cfield2, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddMultiply", fieldname)
 	}
 	return nil
 }
@@ -1338,7 +1495,7 @@ func (df *Dirfile) AddPhase(fieldname, inField string, shift int64, fragmentInde
 	defer C.free(unsafe.Pointer(cfield))
 	result := C.gd_add_phase(df.d, fcode, cfield, C.gd_int64_t(shift), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddPhase", fieldname)
 	}
 	return nil
 }
@@ -1350,12 +1507,15 @@ func (df *Dirfile) AddPolynom(fieldname, inField string, a []float64, fragmentIn
 	ifield := C.CString(inField)
 	defer C.free(unsafe.Pointer(ifield))
 
+	if err := checkPolynom("AddPolynom", fieldname, len(a)); err != nil {
+		return err
+	}
 	ncoef := len(a)
 	polyOrder := ncoef - 1
 	result := C.gd_add_polynom(df.d, fcode, C.int(polyOrder), ifield, (*C.double)(unsafe.Pointer(&a[0])),
 		C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddPolynom", fieldname)
 	}
 	return nil
 }
@@ -1367,12 +1527,15 @@ func (df *Dirfile) AddCPolynom(fieldname, inField string, a []complex128, fragme
 	ifield := C.CString(inField)
 	defer C.free(unsafe.Pointer(ifield))
 
+	if err := checkPolynom("AddCPolynom", fieldname, len(a)); err != nil {
+		return err
+	}
 	ncoef := len(a)
 	polyOrder := ncoef - 1
 	result := C.gd_add_cpolynom(df.d, fcode, C.int(polyOrder), ifield, (*C.double)(unsafe.Pointer(&a[0])),
 		C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddCPolynom", fieldname)
 	}
 	return nil
 }
@@ -1385,7 +1548,7 @@ func (df *Dirfile) AddRecip(fieldname, inField string, dividend float64, fragmen
 	defer C.free(unsafe.Pointer(ifield))
 	result := C.gd_add_recip(df.d, fcode, ifield, C.double(dividend), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddRecip", fieldname)
 	}
 	return nil
 }
@@ -1398,13 +1561,16 @@ func (df *Dirfile) AddCRecip(fieldname, inField string, dividend complex128, fra
 	defer C.free(unsafe.Pointer(ifield))
 	result := C.gd_add_crecip89(df.d, fcode, ifield, (*C.double)(unsafe.Pointer(&dividend)), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddCRecip", fieldname)
 	}
 	return nil
 }
 
 // AddSarray adds a SARRAY field to the dirfile
 func (df *Dirfile) AddSarray(fieldname string, inFields []string, fragmentIndex int) error {
+	if len(inFields) == 0 {
+		return newError(ErrArgument, "AddSarray", fieldname, "AddSarray needs at least one value")
+	}
 	fcode := C.CString(fieldname)
 	defer C.free(unsafe.Pointer(fcode))
 	nfields := len(inFields)
@@ -1417,7 +1583,7 @@ func (df *Dirfile) AddSarray(fieldname string, inFields []string, fragmentIndex
 	result := C.gd_add_sarray(df.d, fcode, C.size_t(nfields), (**C.char)(unsafe.Pointer(&cpointers[0])),
 		C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddSarray", fieldname)
 	}
 	return nil
 }
@@ -1430,7 +1596,7 @@ func (df *Dirfile) AddSbit(fieldname, inField string, bitnum, numbits, fragmentI
 	defer C.free(unsafe.Pointer(ifield))
 	result := C.gd_add_sbit(df.d, fcode, ifield, C.int(bitnum), C.int(numbits), C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddSbit", fieldname)
 	}
 	return nil
 }
@@ -1445,7 +1611,7 @@ func (df *Dirfile) AddSindir(fieldname, indexField, carrayField string, fragment
 	defer C.free(unsafe.Pointer(cfield2))
 	result := C.gd_add_sindir(df.d, fcode, cfield1, cfield2, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddSindir", fieldname)
 	}
 	return nil
 }
@@ -1458,12 +1624,13 @@ func (df *Dirfile) AddString(fieldname, value string, fragmentIndex int) error {
 	defer C.free(unsafe.Pointer(cvalue))
 	result := C.gd_add_string(df.d, fcode, cvalue, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddString", fieldname)
 	}
 	return nil
 }
 
-// AddWindow adds a WINDOW field to the dirfile
+// AddWindow adds a WINDOW field to the dirfile. The threshold should be a value
+// of any integer or floating-point type.
 func (df *Dirfile) AddWindow(fieldname, indexField, checkField string,
 	windowOp WindowOps, threshold interface{},
 	fragmentIndex int) error {
@@ -1473,14 +1640,37 @@ func (df *Dirfile) AddWindow(fieldname, indexField, checkField string,
 	defer C.free(unsafe.Pointer(cfield1))
 	cfield2 := C.CString(checkField)
 	defer C.free(unsafe.Pointer(cfield2))
+	triplet, err := threshold2triplet("AddWindow", fieldname, windowOp, threshold)
+	if err != nil {
+		return err
+	}
 	result := C.gd_add_window(df.d, fcode, cfield1, cfield2, C.gd_windop_t(windowOp),
-		(*(*C.gd_triplet_t)(unsafe.Pointer(&threshold))), C.int(fragmentIndex))
+		triplet, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddWindow", fieldname)
 	}
 	return nil
 }
 
+// threshold2triplet stores a numeric WINDOW threshold in the member of the
+// gd_triplet_t union which the C library uses for the given window operation.
+func threshold2triplet(op, fieldcode string, windowOp WindowOps, threshold interface{}) (C.gd_triplet_t, error) {
+	var triplet C.gd_triplet_t
+	i, u, r, err := thresholdValues(op, fieldcode, threshold)
+	if err != nil {
+		return triplet, err
+	}
+	switch windowOp {
+	case WINDOPEQ, WINDOPNE:
+		*(*int64)(unsafe.Pointer(&triplet)) = i
+	case WINDOPSET, WINDOPCLR:
+		*(*uint64)(unsafe.Pointer(&triplet)) = u
+	default:
+		*(*float64)(unsafe.Pointer(&triplet)) = r
+	}
+	return triplet, nil
+}
+
 // AddAlias adds a ALIAS field to the dirfile
 func (df *Dirfile) AddAlias(fieldname, target string, fragmentIndex int) error {
 	fcode := C.CString(fieldname)
@@ -1489,7 +1679,856 @@ func (df *Dirfile) AddAlias(fieldname, target string, fragmentIndex int) error {
 	defer C.free(unsafe.Pointer(ctarget))
 	result := C.gd_add_alias(df.d, fcode, ctarget, C.int(fragmentIndex))
 	if result < 0 {
-		return df.Error()
+		return df.opError("AddAlias", fieldname)
+	}
+	return nil
+}
+
+// MAddEntry adds a metafield to the field parent. As for AddEntry, field
+// parameters given as CONST or CARRAY field codes are added as those field
+// codes, and the value of a scalar entry obtained from Dirfile.Entry is read
+// from its source dirfile. The entry's name may be either the bare metafield
+// name or the full "parent/name" field code.
+func (df *Dirfile) MAddEntry(e *Entry, parent string) error {
+	name := strings.TrimPrefix(e.name, parent+"/")
+	if err := df.maddEntry(e, name, parent); err != nil {
+		return err
+	}
+	if e.Hidden() {
+		return df.Hide(parent + "/" + name)
+	}
+	return nil
+}
+
+func (df *Dirfile) maddEntry(e *Entry, name, parent string) error {
+	if isVector(e.fieldType) {
+		meta := *e
+		meta.name = name
+		var ce C.gd_entry_t
+		free := entryToC(&meta, &ce)
+		defer free()
+		cparent := C.CString(parent)
+		defer C.free(unsafe.Pointer(cparent))
+		if C.gd_madd(df.d, &ce, cparent) < 0 {
+			return df.opError("MAddEntry", parent+"/"+name)
+		}
+		return nil
+	}
+	switch e.fieldType {
+	case ALIASENTRY:
+		return df.MAddAlias(parent, name, e.inFields[0])
+	case CONSTENTRY:
+		if err := e.loadValue("MAddEntry"); err != nil {
+			return err
+		}
+		return df.MAddConst(parent, name, e.constType, e.value)
+	case CARRAYENTRY:
+		if err := e.loadValue("MAddEntry"); err != nil {
+			return err
+		}
+		return df.MAddCarray(parent, name, e.constType, e.value)
+	case STRINGENTRY:
+		if err := e.loadValue("MAddEntry"); err != nil {
+			return err
+		}
+		value, ok := e.value.(string)
+		if !ok {
+			return newError(ErrArgument, "MAddEntry", name, "Entry %s has a STRING value of type %T, want string", e.name, e.value)
+		}
+		return df.MAddString(parent, name, value)
+	case SARRAYENTRY:
+		if err := e.loadValue("MAddEntry"); err != nil {
+			return err
+		}
+		values, ok := e.value.([]string)
+		if !ok {
+			return newError(ErrArgument, "MAddEntry", name, "Entry %s has a SARRAY value of type %T, want []string", e.name, e.value)
+		}
+		return df.MAddSarray(parent, name, values)
+	case RAWENTRY:
+		return newError(ErrBadFieldType, "MAddEntry", name, "Cannot add RAW entry %s as a metafield", e.name)
+	}
+	return newError(ErrBadFieldType, "MAddEntry", name, "Unknown or not implemented entry type 0x%x", e.fieldType)
+}
+
+// MAddSpec adds a metafield specification line to the dirfile, under the field parent
+func (df *Dirfile) MAddSpec(line, parent string) error {
+	specline := C.CString(line)
+	defer C.free(unsafe.Pointer(specline))
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	result := C.gd_madd_spec(df.d, specline, cparent)
+	if result < 0 {
+		return df.opError("MAddSpec", "")
+	}
+	return nil
+}
+
+// MAddBit adds a BIT metafield to the field parent
+func (df *Dirfile) MAddBit(parent, fieldname, inField string, bitnum, numbits int) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_madd_bit(df.d, cparent, fcode, ifield, C.int(bitnum), C.int(numbits))
+	if result < 0 {
+		return df.opError("MAddBit", fieldname)
+	}
+	return nil
+}
+
+// MAddCarray adds a CARRAY metafield to the field parent
+func (df *Dirfile) MAddCarray(parent, fieldname string, constType RetType, data interface{}) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	dataType, pvalues, nData := array2type(data)
+	result := C.gd_madd_carray(df.d, cparent, fcode, C.gd_type_t(constType), C.size_t(nData),
+		C.gd_type_t(dataType), pvalues)
+	if result < 0 {
+		return df.opError("MAddCarray", fieldname)
+	}
+	return nil
+}
+
+// MAddConst adds a CONST metafield to the field parent
+func (df *Dirfile) MAddConst(parent, fieldname string, constType RetType, data interface{}) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	dataType, pvalue := value2type(data)
+	result := C.gd_madd_const(df.d, cparent, fcode, C.gd_type_t(constType),
+		C.gd_type_t(dataType), pvalue)
+	if result < 0 {
+		return df.opError("MAddConst", fieldname)
+	}
+	return nil
+}
+
+// MAddDivide adds a DIVIDE metafield to the field parent
+func (df *Dirfile) MAddDivide(parent, fieldname, inField1, inField2 string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(inField1)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(inField2)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_madd_divide(df.d, cparent, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("MAddDivide", fieldname)
+	}
+	return nil
+}
+
+// MAddIndir adds a INDIR metafield to the field parent
+func (df *Dirfile) MAddIndir(parent, fieldname, indexField, carrayField string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(indexField)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(carrayField)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_madd_indir(df.d, cparent, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("MAddIndir", fieldname)
+	}
+	return nil
+}
+
+// MAddLincom adds a LINCOM metafield to the field parent
+func (df *Dirfile) MAddLincom(parent, fieldname string, inFields []string, m, b []float64) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	nfields := len(inFields)
+	if err := checkLincom("MAddLincom", fieldname, nfields, len(m), len(b)); err != nil {
+		return err
+	}
+	cpointers := make([]uintptr, nfields)
+	for i, infield := range inFields {
+		cstr := C.CString(infield)
+		defer C.free(unsafe.Pointer(cstr))
+		cpointers[i] = uintptr(unsafe.Pointer(cstr))
+	}
+	result := C.gd_madd_lincom(df.d, cparent, fcode, C.int(nfields), (**C.char)(unsafe.Pointer(&cpointers[0])),
+		(*C.double)(unsafe.Pointer(&m[0])),
+		(*C.double)(unsafe.Pointer(&b[0])))
+	if result < 0 {
+		return df.opError("MAddLincom", fieldname)
+	}
+	return nil
+}
+
+// MAddCLincom adds a LINCOM metafield with complex parameters to the field parent
+func (df *Dirfile) MAddCLincom(parent, fieldname string, inFields []string, m, b []complex128) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	nfields := len(inFields)
+	if err := checkLincom("MAddCLincom", fieldname, nfields, len(m), len(b)); err != nil {
+		return err
+	}
+	cpointers := make([]uintptr, nfields)
+	for i, infield := range inFields {
+		cstr := C.CString(infield)
+		defer C.free(unsafe.Pointer(cstr))
+		cpointers[i] = uintptr(unsafe.Pointer(cstr))
+	}
+	result := C.gd_madd_clincom(df.d, cparent, fcode, C.int(nfields), (**C.char)(unsafe.Pointer(&cpointers[0])),
+		(*C.double)(unsafe.Pointer(&m[0])),
+		(*C.double)(unsafe.Pointer(&b[0])))
+	if result < 0 {
+		return df.opError("MAddCLincom", fieldname)
+	}
+	return nil
+}
+
+// MAddLinterp adds a LINTERP metafield to the field parent
+func (df *Dirfile) MAddLinterp(parent, fieldname, inField, table string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield))
+	ctable := C.CString(table)
+	defer C.free(unsafe.Pointer(ctable))
+	result := C.gd_madd_linterp(df.d, cparent, fcode, cfield, ctable)
+	if result < 0 {
+		return df.opError("MAddLinterp", fieldname)
+	}
+	return nil
+}
+
+// MAddMplex adds a MPLEX metafield to the field parent
+func (df *Dirfile) MAddMplex(parent, fieldname, inField, countField string, countVal, period int) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield))
+	ccount := C.CString(countField)
+	defer C.free(unsafe.Pointer(ccount))
+	result := C.gd_madd_mplex(df.d, cparent, fcode, cfield, ccount, C.int(countVal), C.int(period))
+	if result < 0 {
+		return df.opError("MAddMplex", fieldname)
+	}
+	return nil
+}
+
+// MAddMultiply adds a MULTIPLY metafield to the field parent
+func (df *Dirfile) MAddMultiply(parent, fieldname, inField1, inField2 string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(inField1)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(inField2)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_madd_multiply(df.d, cparent, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("MAddMultiply", fieldname)
+	}
+	return nil
+}
+
+// MAddPhase adds a PHASE metafield to the field parent
+func (df *Dirfile) MAddPhase(parent, fieldname, inField string, shift int64) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield))
+	result := C.gd_madd_phase(df.d, cparent, fcode, cfield, C.gd_int64_t(shift))
+	if result < 0 {
+		return df.opError("MAddPhase", fieldname)
+	}
+	return nil
+}
+
+// MAddPolynom adds a POLYNOM metafield to the field parent
+func (df *Dirfile) MAddPolynom(parent, fieldname, inField string, a []float64) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+
+	if err := checkPolynom("MAddPolynom", fieldname, len(a)); err != nil {
+		return err
+	}
+	polyOrder := len(a) - 1
+	result := C.gd_madd_polynom(df.d, cparent, fcode, C.int(polyOrder), ifield,
+		(*C.double)(unsafe.Pointer(&a[0])))
+	if result < 0 {
+		return df.opError("MAddPolynom", fieldname)
+	}
+	return nil
+}
+
+// MAddCPolynom adds a complex-valued POLYNOM metafield to the field parent
+func (df *Dirfile) MAddCPolynom(parent, fieldname, inField string, a []complex128) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+
+	if err := checkPolynom("MAddCPolynom", fieldname, len(a)); err != nil {
+		return err
+	}
+	polyOrder := len(a) - 1
+	result := C.gd_madd_cpolynom(df.d, cparent, fcode, C.int(polyOrder), ifield,
+		(*C.double)(unsafe.Pointer(&a[0])))
+	if result < 0 {
+		return df.opError("MAddCPolynom", fieldname)
+	}
+	return nil
+}
+
+// MAddRecip adds a RECIP metafield to the field parent
+func (df *Dirfile) MAddRecip(parent, fieldname, inField string, dividend float64) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_madd_recip(df.d, cparent, fcode, ifield, C.double(dividend))
+	if result < 0 {
+		return df.opError("MAddRecip", fieldname)
+	}
+	return nil
+}
+
+// MAddCRecip adds a RECIP metafield with a complex dividend to the field parent
+func (df *Dirfile) MAddCRecip(parent, fieldname, inField string, dividend complex128) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_madd_crecip89(df.d, cparent, fcode, ifield, (*C.double)(unsafe.Pointer(&dividend)))
+	if result < 0 {
+		return df.opError("MAddCRecip", fieldname)
+	}
+	return nil
+}
+
+// MAddSarray adds a SARRAY metafield to the field parent
+func (df *Dirfile) MAddSarray(parent, fieldname string, values []string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	if len(values) == 0 {
+		return newError(ErrArgument, "MAddSarray", fieldname, "MAddSarray needs at least one value")
+	}
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	nvalues := len(values)
+	cpointers := make([]uintptr, nvalues)
+	for i, value := range values {
+		cstr := C.CString(value)
+		defer C.free(unsafe.Pointer(cstr))
+		cpointers[i] = uintptr(unsafe.Pointer(cstr))
+	}
+	result := C.gd_madd_sarray(df.d, cparent, fcode, C.size_t(nvalues),
+		(**C.char)(unsafe.Pointer(&cpointers[0])))
+	if result < 0 {
+		return df.opError("MAddSarray", fieldname)
+	}
+	return nil
+}
+
+// MAddSbit adds a SBIT metafield to the field parent
+func (df *Dirfile) MAddSbit(parent, fieldname, inField string, bitnum, numbits int) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_madd_sbit(df.d, cparent, fcode, ifield, C.int(bitnum), C.int(numbits))
+	if result < 0 {
+		return df.opError("MAddSbit", fieldname)
+	}
+	return nil
+}
+
+// MAddSindir adds a SINDIR metafield to the field parent
+func (df *Dirfile) MAddSindir(parent, fieldname, indexField, sarrayField string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(indexField)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(sarrayField)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_madd_sindir(df.d, cparent, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("MAddSindir", fieldname)
+	}
+	return nil
+}
+
+// MAddString adds a STRING metafield to the field parent
+func (df *Dirfile) MAddString(parent, fieldname, value string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+	result := C.gd_madd_string(df.d, cparent, fcode, cvalue)
+	if result < 0 {
+		return df.opError("MAddString", fieldname)
+	}
+	return nil
+}
+
+// MAddWindow adds a WINDOW metafield to the field parent. The threshold should
+// be a value of any integer or floating-point type.
+func (df *Dirfile) MAddWindow(parent, fieldname, inField, checkField string,
+	windowOp WindowOps, threshold interface{}) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(checkField)
+	defer C.free(unsafe.Pointer(cfield2))
+	triplet, err := threshold2triplet("MAddWindow", fieldname, windowOp, threshold)
+	if err != nil {
+		return err
+	}
+	result := C.gd_madd_window(df.d, cparent, fcode, cfield1, cfield2, C.gd_windop_t(windowOp), triplet)
+	if result < 0 {
+		return df.opError("MAddWindow", fieldname)
+	}
+	return nil
+}
+
+// MAddAlias adds an ALIAS metafield to the field parent
+func (df *Dirfile) MAddAlias(parent, fieldname, target string) error {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	fcode := C.CString(fieldname)
+	defer C.free(unsafe.Pointer(fcode))
+	ctarget := C.CString(target)
+	defer C.free(unsafe.Pointer(ctarget))
+	result := C.gd_madd_alias(df.d, cparent, fcode, ctarget)
+	if result < 0 {
+		return df.opError("MAddAlias", fieldname)
+	}
+	return nil
+}
+
+// AlterEntry changes the metadata of the field fieldcode to match the given Entry,
+// which must be of the same field type. If recode is true, the binary file of a
+// RAW field is converted to the new data type and samples per frame; for a
+// LINTERP field, the look-up table is renamed on disk instead. Parameters the
+// entry gives as CONST or CARRAY field codes (see Entry.Scalar) are set to those
+// field codes, not to their current values.
+func (df *Dirfile) AlterEntry(fieldcode string, e *Entry, recode bool) error {
+	if et := df.EntryType(fieldcode); et != e.fieldType {
+		return newError(ErrBadFieldType, "AlterEntry", fieldcode, "Cannot alter field %s of type 0x%x to an entry of type 0x%x",
+			fieldcode, et, e.fieldType)
+	}
+	switch e.fieldType {
+	case RAWENTRY, BITENTRY, SBITENTRY, LINCOMENTRY, LINTERPENTRY, MULTIPLYENTRY,
+		DIVIDEENTRY, PHASEENTRY, POLYNOMENTRY, RECIPENTRY, MPLEXENTRY, WINDOWENTRY,
+		INDIRENTRY, SINDIRENTRY, CONSTENTRY, CARRAYENTRY, SARRAYENTRY:
+	default:
+		return newError(ErrBadFieldType, "AlterEntry", fieldcode, "Unknown or not implemented entry type 0x%x", e.fieldType)
+	}
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	var ce C.gd_entry_t
+	free := entryToC(e, &ce)
+	defer free()
+	var rc C.int
+	if recode {
+		rc = 1
+	}
+	result := C.gd_alter_entry(df.d, fcode, &ce, rc)
+	if result < 0 {
+		return df.opError("AlterEntry", fieldcode)
+	}
+	return nil
+}
+
+// AlterSpec changes an existing field to match the given field specification
+// line. If recode is true, the binary file of a RAW field is also converted.
+func (df *Dirfile) AlterSpec(line string, recode bool) error {
+	specline := C.CString(line)
+	defer C.free(unsafe.Pointer(specline))
+	var rc C.int
+	if recode {
+		rc = 1
+	}
+	result := C.gd_alter_spec(df.d, specline, rc)
+	if result < 0 {
+		return df.opError("AlterSpec", "")
+	}
+	return nil
+}
+
+// MAlterSpec changes an existing metafield of the field parent to match the
+// given field specification line.
+func (df *Dirfile) MAlterSpec(line, parent string, recode bool) error {
+	specline := C.CString(line)
+	defer C.free(unsafe.Pointer(specline))
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+	var rc C.int
+	if recode {
+		rc = 1
+	}
+	result := C.gd_malter_spec(df.d, specline, cparent, rc)
+	if result < 0 {
+		return df.opError("MAlterSpec", "")
+	}
+	return nil
+}
+
+// AlterRaw changes the data type and samples per frame of a RAW field. If recode
+// is true, the binary file on disk is rewritten to match; otherwise it is left
+// untouched (and will be reinterpreted).
+func (df *Dirfile) AlterRaw(fieldcode string, dataType RetType, spf uint, recode bool) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	var rc C.int
+	if recode {
+		rc = 1
+	}
+	result := C.gd_alter_raw(df.d, fcode, C.gd_type_t(dataType), C.uint(spf), rc)
+	if result < 0 {
+		return df.opError("AlterRaw", fieldcode)
+	}
+	return nil
+}
+
+// AlterBit changes the input field and bit range of a BIT field
+func (df *Dirfile) AlterBit(fieldcode, inField string, bitnum, numbits int) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_alter_bit(df.d, fcode, ifield, C.int(bitnum), C.int(numbits))
+	if result < 0 {
+		return df.opError("AlterBit", fieldcode)
+	}
+	return nil
+}
+
+// AlterSbit changes the input field and bit range of a SBIT field
+func (df *Dirfile) AlterSbit(fieldcode, inField string, bitnum, numbits int) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_alter_sbit(df.d, fcode, ifield, C.int(bitnum), C.int(numbits))
+	if result < 0 {
+		return df.opError("AlterSbit", fieldcode)
+	}
+	return nil
+}
+
+// AlterConst changes the storage type of a CONST field
+func (df *Dirfile) AlterConst(fieldcode string, constType RetType) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	result := C.gd_alter_const(df.d, fcode, C.gd_type_t(constType))
+	if result < 0 {
+		return df.opError("AlterConst", fieldcode)
+	}
+	return nil
+}
+
+// AlterCarray changes the storage type and length of a CARRAY field
+func (df *Dirfile) AlterCarray(fieldcode string, constType RetType, arrayLen int) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	result := C.gd_alter_carray(df.d, fcode, C.gd_type_t(constType), C.size_t(arrayLen))
+	if result < 0 {
+		return df.opError("AlterCarray", fieldcode)
+	}
+	return nil
+}
+
+// AlterSarray changes the length of a SARRAY field
+func (df *Dirfile) AlterSarray(fieldcode string, arrayLen int) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	result := C.gd_alter_sarray(df.d, fcode, C.size_t(arrayLen))
+	if result < 0 {
+		return df.opError("AlterSarray", fieldcode)
+	}
+	return nil
+}
+
+// AlterDivide changes the input fields of a DIVIDE field
+func (df *Dirfile) AlterDivide(fieldcode, inField1, inField2 string) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(inField1)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(inField2)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_alter_divide(df.d, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("AlterDivide", fieldcode)
+	}
+	return nil
+}
+
+// AlterIndir changes the input fields of an INDIR field
+func (df *Dirfile) AlterIndir(fieldcode, indexField, carrayField string) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(indexField)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(carrayField)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_alter_indir(df.d, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("AlterIndir", fieldcode)
+	}
+	return nil
+}
+
+// AlterLincom changes the input fields and parameters of a LINCOM field
+// (empty inFields, m, and b leave them unchanged)
+func (df *Dirfile) AlterLincom(fieldcode string, inFields []string, m, b []float64) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	nfields := len(inFields)
+	if nfields > 0 || len(m) > 0 || len(b) > 0 {
+		if err := checkLincom("AlterLincom", fieldcode, nfields, len(m), len(b)); err != nil {
+			return err
+		}
+	}
+	cpointers := make([]uintptr, nfields)
+	for i, infield := range inFields {
+		cstr := C.CString(infield)
+		defer C.free(unsafe.Pointer(cstr))
+		cpointers[i] = uintptr(unsafe.Pointer(cstr))
+	}
+	result := C.gd_alter_lincom(df.d, fcode, C.int(nfields), (**C.char)(firstElement(cpointers)),
+		(*C.double)(firstElement(m)),
+		(*C.double)(firstElement(b)))
+	if result < 0 {
+		return df.opError("AlterLincom", fieldcode)
+	}
+	return nil
+}
+
+// AlterCLincom changes the input fields and complex parameters of a LINCOM field
+// (empty inFields, m, and b leave them unchanged)
+func (df *Dirfile) AlterCLincom(fieldcode string, inFields []string, m, b []complex128) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	nfields := len(inFields)
+	if nfields > 0 || len(m) > 0 || len(b) > 0 {
+		if err := checkLincom("AlterCLincom", fieldcode, nfields, len(m), len(b)); err != nil {
+			return err
+		}
+	}
+	cpointers := make([]uintptr, nfields)
+	for i, infield := range inFields {
+		cstr := C.CString(infield)
+		defer C.free(unsafe.Pointer(cstr))
+		cpointers[i] = uintptr(unsafe.Pointer(cstr))
+	}
+	result := C.gd_alter_clincom(df.d, fcode, C.int(nfields), (**C.char)(firstElement(cpointers)),
+		(*C.double)(firstElement(m)),
+		(*C.double)(firstElement(b)))
+	if result < 0 {
+		return df.opError("AlterCLincom", fieldcode)
+	}
+	return nil
+}
+
+// AlterLinterp changes the input field and look-up table of a LINTERP field.
+// If renameTable is true, the existing look-up table file is renamed to table.
+func (df *Dirfile) AlterLinterp(fieldcode, inField, table string, renameTable bool) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield))
+	ctable := C.CString(table)
+	defer C.free(unsafe.Pointer(ctable))
+	var rt C.int
+	if renameTable {
+		rt = 1
+	}
+	result := C.gd_alter_linterp(df.d, fcode, cfield, ctable, rt)
+	if result < 0 {
+		return df.opError("AlterLinterp", fieldcode)
+	}
+	return nil
+}
+
+// AlterMplex changes the input fields, count value, and period of a MPLEX field
+func (df *Dirfile) AlterMplex(fieldcode, inField, countField string, countVal, period int) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield))
+	ccount := C.CString(countField)
+	defer C.free(unsafe.Pointer(ccount))
+	result := C.gd_alter_mplex(df.d, fcode, cfield, ccount, C.int(countVal), C.int(period))
+	if result < 0 {
+		return df.opError("AlterMplex", fieldcode)
+	}
+	return nil
+}
+
+// AlterMultiply changes the input fields of a MULTIPLY field
+func (df *Dirfile) AlterMultiply(fieldcode, inField1, inField2 string) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(inField1)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(inField2)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_alter_multiply(df.d, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("AlterMultiply", fieldcode)
+	}
+	return nil
+}
+
+// AlterPhase changes the input field and shift of a PHASE field
+func (df *Dirfile) AlterPhase(fieldcode, inField string, shift int64) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield))
+	result := C.gd_alter_phase(df.d, fcode, cfield, C.gd_int64_t(shift))
+	if result < 0 {
+		return df.opError("AlterPhase", fieldcode)
+	}
+	return nil
+}
+
+// AlterPolynom changes the input field and coefficients of a POLYNOM field
+// (empty a leaves them unchanged)
+func (df *Dirfile) AlterPolynom(fieldcode, inField string, a []float64) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+
+	if len(a) > 0 {
+		if err := checkPolynom("AlterPolynom", fieldcode, len(a)); err != nil {
+			return err
+		}
+	}
+	polyOrder := max(len(a)-1, 0)
+	result := C.gd_alter_polynom(df.d, fcode, C.int(polyOrder), ifield, (*C.double)(firstElement(a)))
+	if result < 0 {
+		return df.opError("AlterPolynom", fieldcode)
+	}
+	return nil
+}
+
+// AlterCPolynom changes the input field and complex coefficients of a POLYNOM field
+// (empty a leaves them unchanged)
+func (df *Dirfile) AlterCPolynom(fieldcode, inField string, a []complex128) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+
+	if len(a) > 0 {
+		if err := checkPolynom("AlterCPolynom", fieldcode, len(a)); err != nil {
+			return err
+		}
+	}
+	polyOrder := max(len(a)-1, 0)
+	result := C.gd_alter_cpolynom(df.d, fcode, C.int(polyOrder), ifield, (*C.double)(firstElement(a)))
+	if result < 0 {
+		return df.opError("AlterCPolynom", fieldcode)
+	}
+	return nil
+}
+
+// AlterRecip changes the input field and dividend of a RECIP field
+func (df *Dirfile) AlterRecip(fieldcode, inField string, dividend float64) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_alter_recip(df.d, fcode, ifield, C.double(dividend))
+	if result < 0 {
+		return df.opError("AlterRecip", fieldcode)
+	}
+	return nil
+}
+
+// AlterCRecip changes the input field and complex dividend of a RECIP field
+func (df *Dirfile) AlterCRecip(fieldcode, inField string, dividend complex128) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	ifield := C.CString(inField)
+	defer C.free(unsafe.Pointer(ifield))
+	result := C.gd_alter_crecip89(df.d, fcode, ifield, (*C.double)(unsafe.Pointer(&dividend)))
+	if result < 0 {
+		return df.opError("AlterCRecip", fieldcode)
+	}
+	return nil
+}
+
+// AlterSindir changes the input fields of a SINDIR field
+func (df *Dirfile) AlterSindir(fieldcode, indexField, sarrayField string) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(indexField)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(sarrayField)
+	defer C.free(unsafe.Pointer(cfield2))
+	result := C.gd_alter_sindir(df.d, fcode, cfield1, cfield2)
+	if result < 0 {
+		return df.opError("AlterSindir", fieldcode)
+	}
+	return nil
+}
+
+// AlterWindow changes the input fields, operation, and threshold of a WINDOW
+// field. The threshold should be a value of any integer or floating-point type.
+func (df *Dirfile) AlterWindow(fieldcode, inField, checkField string,
+	windowOp WindowOps, threshold interface{}) error {
+	fcode := C.CString(fieldcode)
+	defer C.free(unsafe.Pointer(fcode))
+	cfield1 := C.CString(inField)
+	defer C.free(unsafe.Pointer(cfield1))
+	cfield2 := C.CString(checkField)
+	defer C.free(unsafe.Pointer(cfield2))
+	triplet, err := threshold2triplet("AlterWindow", fieldcode, windowOp, threshold)
+	if err != nil {
+		return err
+	}
+	result := C.gd_alter_window(df.d, fcode, cfield1, cfield2, C.gd_windop_t(windowOp), triplet)
+	if result < 0 {
+		return df.opError("AlterWindow", fieldcode)
 	}
 	return nil
 }
@@ -1500,7 +2539,7 @@ func (df *Dirfile) Delete(fieldname string, flags DeleteFlags) error {
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_delete(df.d, fcode, C.uint(flags))
 	if result < 0 {
-		return df.Error()
+		return df.opError("Delete", fieldname)
 	}
 	return nil
 }
@@ -1511,7 +2550,7 @@ func (df *Dirfile) Hide(fieldcode string) error {
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_hide(df.d, fcode)
 	if result < 0 {
-		return df.Error()
+		return df.opError("Hide", fieldcode)
 	}
 	return nil
 }
@@ -1522,7 +2561,7 @@ func (df *Dirfile) Unhide(fieldcode string) error {
 	defer C.free(unsafe.Pointer(fcode))
 	result := C.gd_unhide(df.d, fcode)
 	if result < 0 {
-		return df.Error()
+		return df.opError("Unhide", fieldcode)
 	}
 	return nil
 }
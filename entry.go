@@ -1,3 +1,5 @@
+//go:build !purego
+
 package getdata
 
 /*
@@ -8,7 +10,11 @@ package getdata
 */
 import "C"
 import (
+	"reflect"
+	"slices"
 	"unsafe"
+
+	"github.com/joefowler/gogetdata/format"
 )
 
 type raw struct {
@@ -77,30 +83,136 @@ type Entry struct {
 	window
 	constType RetType
 	arrayLen  int
+	scalars   [MAXPOLYORD + 1]string
+	scalarInd [MAXPOLYORD + 1]int
+	value     interface{} // value of a scalar (CONST, CARRAY, STRING, or SARRAY) entry
 }
 
-// func entryToC(e *Entry) *C.gd_entry_t {
-// 	cp := (*C.gd_entry_t)(C.malloc(C.sizeof_gd_entry_t))
-// 	ce := *cp
-// 	ce.field = C.CString(e.name)
-// 	ce.field_type = C.gd_entype_t(e.fieldType)
-// 	ce.flags = C.uint(e.flags)
-// 	for i := 0; i < MAXLINCOM; i++ {
-// 		ce.in_fields[i] = C.CString(e.inFields[i])
-// 	}
-// 	return cp
-// }
+// entryToC fills ce from e, for C functions taking a gd_entry_t. Its strings are
+// allocated in C; call the returned function to free them.
+func entryToC(e *Entry, ce *C.gd_entry_t) (free func()) {
+	var cstrings []*C.char
+	cstring := func(s string) *C.char {
+		cs := C.CString(s)
+		cstrings = append(cstrings, cs)
+		return cs
+	}
+	free = func() {
+		for _, cs := range cstrings {
+			C.free(unsafe.Pointer(cs))
+		}
+	}
+
+	ce.field = cstring(e.name)
+	ce.field_type = C.gd_entype_t(e.fieldType)
+	ce.fragment_index = C.int(e.fragment)
+	ce.flags = C.uint(e.flags)
+	for i := 0; i < e.nInputs(); i++ {
+		ce.in_fields[i] = cstring(e.inFields[i])
+	}
+	for i := 0; i < MAXPOLYORD+1; i++ {
+		if e.scalars[i] != "" {
+			ce.scalar[i] = cstring(e.scalars[i])
+			ce.scalar_ind[i] = C.int(e.scalarInd[i])
+		}
+	}
+
+	base := uintptr(unsafe.Pointer(&ce.flags)) + unsafe.Sizeof(ce.flags)
+	switch e.fieldType {
+	case RAWENTRY:
+		*(*C.uint)(unsafe.Pointer(base)) = C.uint(e.spf)
+		base += unsafe.Sizeof(C.uint(0))
+		*(*C.gd_type_t)(unsafe.Pointer(base)) = C.gd_type_t(e.dataType)
+
+	case LINCOMENTRY:
+		*(*C.long)(unsafe.Pointer(base)) = C.long(e.nFields)
+		base += unsafe.Sizeof(C.long(0))
+		for i := 0; i < MAXLINCOM; i++ {
+			*(*C.double)(unsafe.Pointer(base)) = C.double(e.m[i])
+			base += unsafe.Sizeof(C.double(0))
+		}
+		for i := 0; i < MAXLINCOM; i++ {
+			*(*C.complexdouble)(unsafe.Pointer(base)) = C.complexdouble(e.cm[i])
+			base += unsafe.Sizeof(C.complexdouble(0))
+		}
+		for i := 0; i < MAXLINCOM; i++ {
+			*(*C.double)(unsafe.Pointer(base)) = C.double(e.b[i])
+			base += unsafe.Sizeof(C.double(0))
+		}
+		for i := 0; i < MAXLINCOM; i++ {
+			*(*C.complexdouble)(unsafe.Pointer(base)) = C.complexdouble(e.cb[i])
+			base += unsafe.Sizeof(C.complexdouble(0))
+		}
+
+	case POLYNOMENTRY:
+		*(*C.int)(unsafe.Pointer(base)) = C.int(e.polyOrder)
+		base += unsafe.Sizeof(C.int(0))
+		base += 4 // because of alignment
+		for i := 0; i < MAXPOLYORD+1; i++ {
+			*(*C.double)(unsafe.Pointer(base)) = C.double(e.a[i])
+			base += unsafe.Sizeof(C.double(0))
+		}
+		for i := 0; i < MAXPOLYORD+1; i++ {
+			*(*C.complexdouble)(unsafe.Pointer(base)) = C.complexdouble(e.ca[i])
+			base += unsafe.Sizeof(C.complexdouble(0))
+		}
+
+	case LINTERPENTRY:
+		*(**C.char)(unsafe.Pointer(base)) = cstring(e.table)
+
+	case BITENTRY, SBITENTRY:
+		*(*C.int)(unsafe.Pointer(base)) = C.int(e.bitnum)
+		base += unsafe.Sizeof(C.int(0))
+		*(*C.int)(unsafe.Pointer(base)) = C.int(e.numbits)
+
+	case RECIPENTRY:
+		*(*C.double)(unsafe.Pointer(base)) = C.double(e.dividend)
+		base += unsafe.Sizeof(C.double(0))
+		*(*C.complexdouble)(unsafe.Pointer(base)) = C.complexdouble(e.cdividend)
+
+	case PHASEENTRY:
+		*(*C.gd_int64_t)(unsafe.Pointer(base)) = C.gd_int64_t(e.phaseShift)
+
+	case MPLEXENTRY:
+		*(*C.int)(unsafe.Pointer(base)) = C.int(e.countVal)
+		base += unsafe.Sizeof(C.int(0))
+		*(*C.int)(unsafe.Pointer(base)) = C.int(e.period)
+
+	case CONSTENTRY, CARRAYENTRY, SARRAYENTRY:
+		*(*C.gd_type_t)(unsafe.Pointer(base)) = C.gd_type_t(e.constType)
+		base += unsafe.Sizeof(C.long(0))
+		*(*C.size_t)(unsafe.Pointer(base)) = C.size_t(e.arrayLen)
+
+	case WINDOWENTRY:
+		*(*C.gd_windop_t)(unsafe.Pointer(base)) = C.gd_windop_t(e.windOp)
+		base += unsafe.Sizeof(C.gd_windop_t(0))
+		switch e.windOp {
+		case WINDOPEQ, WINDOPNE:
+			*(*int64)(unsafe.Pointer(base)) = e.iThreshold
+		case WINDOPSET, WINDOPCLR:
+			*(*uint64)(unsafe.Pointer(base)) = e.uThreshold
+		default:
+			*(*float64)(unsafe.Pointer(base)) = e.fThreshold
+		}
+	}
+	return free
+}
 
 func entryFromC(df *Dirfile, ce *C.gd_entry_t) Entry {
 	e := Entry{
 		df:        df,
 		name:      C.GoString(ce.field),
 		fieldType: EntryType(ce.field_type),
+		fragment:  int(ce.fragment_index),
 		flags:     uint(ce.flags),
 	}
 	for i := 0; i < MAXLINCOM; i++ {
 		e.inFields[i] = C.GoString(ce.in_fields[i])
 	}
+	for i := 0; i < MAXPOLYORD+1; i++ {
+		e.scalars[i] = C.GoString(ce.scalar[i])
+		e.scalarInd[i] = int(ce.scalar_ind[i])
+	}
 
 	base := uintptr(unsafe.Pointer(&ce.flags)) + unsafe.Sizeof(ce.flags)
 	switch e.fieldType {
@@ -163,7 +275,7 @@ func entryFromC(df *Dirfile, ce *C.gd_entry_t) Entry {
 		base += unsafe.Sizeof(C.int(0))
 		e.period = int(*(*C.int)(unsafe.Pointer(base)))
 
-	case CONSTENTRY, SARRAYENTRY:
+	case CONSTENTRY, CARRAYENTRY, SARRAYENTRY:
 		e.constType = RetType(*(*C.gd_type_t)(unsafe.Pointer(base)))
 		base += unsafe.Sizeof(C.long(0))
 		e.arrayLen = int(*(*C.size_t)(unsafe.Pointer(base)))
@@ -183,6 +295,152 @@ func entryFromC(df *Dirfile, ce *C.gd_entry_t) Entry {
 	return e
 }
 
+// entryFromFormat converts fe, an entry parsed by package format, to an Entry
+// not associated with any Dirfile. param returns the value of a parameter given
+// by a CONST or CARRAY field. Scalar entries hold their values, in the widest Go
+// type of the same kind as the stored type, as loadValue reads them.
+func entryFromFormat(fe *format.Entry, param func(format.Param) (complex128, error)) (Entry, error) {
+	e := Entry{
+		name:      fe.Name,
+		fieldType: EntryType(fe.Type),
+		fragment:  fe.Fragment,
+	}
+	if fe.Hidden {
+		e.flags |= C.GD_EN_HIDDEN
+	}
+	copy(e.inFields[:], fe.InFields)
+
+	// value returns parameter p, recording the field code of parameter i if it
+	// is not literal
+	var err error
+	value := func(i int, p format.Param) complex128 {
+		if err != nil {
+			return 0
+		}
+		if p.IsLiteral() {
+			var v complex128
+			v, err = p.Complex()
+			return v
+		}
+		e.scalars[i] = p.Field
+		e.scalarInd[i] = p.Index
+		v, perr := param(p)
+		err = perr
+		return v
+	}
+	compscal := func(v complex128) {
+		if imag(v) != 0 {
+			e.flags |= C.GD_EN_COMPSCAL
+		}
+	}
+
+	switch e.fieldType {
+	case RAWENTRY:
+		e.spf = uint(real(value(0, fe.SPF)))
+		e.dataType = RetType(fe.DataType)
+
+	case LINCOMENTRY:
+		e.nFields = min(len(fe.InFields), MAXLINCOM)
+		for i := 0; i < e.nFields; i++ {
+			e.cm[i] = value(i, fe.Scale[i])
+			e.cb[i] = value(i+MAXLINCOM, fe.Offset[i])
+			e.m[i], e.b[i] = real(e.cm[i]), real(e.cb[i])
+			compscal(e.cm[i])
+			compscal(e.cb[i])
+		}
+
+	case POLYNOMENTRY:
+		e.polyOrder = min(len(fe.Coeffs), MAXPOLYORD+1) - 1
+		for i := 0; i <= e.polyOrder; i++ {
+			e.ca[i] = value(i, fe.Coeffs[i])
+			e.a[i] = real(e.ca[i])
+			compscal(e.ca[i])
+		}
+
+	case LINTERPENTRY:
+		e.table = fe.Table
+
+	case BITENTRY, SBITENTRY:
+		e.bitnum = int(real(value(0, fe.Bitnum)))
+		e.numbits = int(real(value(1, fe.Numbits)))
+
+	case RECIPENTRY:
+		e.cdividend = value(0, fe.Dividend)
+		e.dividend = real(e.cdividend)
+		compscal(e.cdividend)
+
+	case PHASEENTRY:
+		e.phaseShift = int64(real(value(0, fe.Shift)))
+
+	case MPLEXENTRY:
+		e.countVal = int(real(value(0, fe.CountVal)))
+		e.period = int(real(value(1, fe.Period)))
+
+	case WINDOWENTRY:
+		e.windOp = WindowOps(fe.WindowOp)
+		v := value(0, fe.Threshold)
+		e.iThreshold, e.uThreshold, e.fThreshold = int64(real(v)), uint64(real(v)), real(v)
+		if fe.Threshold.IsLiteral() {
+			// integer thresholds are exact only if parsed as integers
+			switch e.windOp {
+			case WINDOPEQ, WINDOPNE:
+				e.iThreshold, err = fe.Threshold.Int()
+			case WINDOPSET, WINDOPCLR:
+				e.uThreshold, err = fe.Threshold.Uint()
+			}
+		}
+
+	case CONSTENTRY, CARRAYENTRY:
+		e.constType = RetType(fe.DataType)
+		if e.fieldType == CARRAYENTRY {
+			e.arrayLen = len(fe.Values)
+		}
+		e.value, err = constValues(e.constType, fe.Values)
+		if err == nil && e.fieldType == CONSTENTRY {
+			e.value = reflect.ValueOf(e.value).Index(0).Interface()
+		}
+
+	case STRINGENTRY:
+		e.value = fe.Values[0]
+
+	case SARRAYENTRY:
+		e.arrayLen = len(fe.Values)
+		e.value = slices.Clone(fe.Values)
+	}
+	if err != nil {
+		return Entry{}, newError(ErrBadScalar, "Entry", fe.Name, "Bad parameter of field %s: %v", fe.Name, err)
+	}
+	return e, nil
+}
+
+// constValues parses the literal values of a CONST or CARRAY field stored as
+// type t, returning them as a slice of the widest Go type of the same kind:
+// []complex128, []float64, []uint64, or []int64
+func constValues(t RetType, values []string) (interface{}, error) {
+	switch t {
+	case COMPLEX64, COMPLEX128:
+		return parseValues(values, format.Param.Complex)
+	case FLOAT32, FLOAT64:
+		return parseValues(values, format.Param.Float)
+	case UINT8, UINT16, UINT32, UINT64:
+		return parseValues(values, format.Param.Uint)
+	}
+	return parseValues(values, format.Param.Int)
+}
+
+// parseValues parses each of values with parse
+func parseValues[T any](values []string, parse func(format.Param) (T, error)) ([]T, error) {
+	out := make([]T, len(values))
+	for i, text := range values {
+		v, err := parse(format.Param{Literal: text, Index: -1})
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
 // RawEntry creates an Entry of RAW type without adding to any Dirfile
 func RawEntry(name string, fragmentIndex int, samplesPerFrame uint, dType RetType) Entry {
 	var e = Entry{
@@ -208,21 +466,878 @@ func BitEntry(name, inField string, bitnum, numbits, fragmentIndex int) Entry {
 	return e
 }
 
+// SbitEntry creates an Entry of SBIT type without adding to any Dirfile
+func SbitEntry(name, inField string, bitnum, numbits, fragmentIndex int) Entry {
+	e := BitEntry(name, inField, bitnum, numbits, fragmentIndex)
+	e.fieldType = SBITENTRY
+	return e
+}
+
+// LincomEntry creates an Entry of LINCOM type without adding to any Dirfile
+func LincomEntry(name string, inFields []string, m, b []float64, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: LINCOMENTRY,
+		fragment:  fragmentIndex,
+	}
+	e.nFields = copy(e.inFields[:], inFields)
+	copy(e.m[:], m)
+	copy(e.b[:], b)
+	for i := 0; i < e.nFields; i++ {
+		e.cm[i] = complex(e.m[i], 0)
+		e.cb[i] = complex(e.b[i], 0)
+	}
+	return e
+}
+
+// CLincomEntry creates an Entry of LINCOM type with complex parameters without
+// adding to any Dirfile
+func CLincomEntry(name string, inFields []string, m, b []complex128, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: LINCOMENTRY,
+		flags:     C.GD_EN_COMPSCAL,
+		fragment:  fragmentIndex,
+	}
+	e.nFields = copy(e.inFields[:], inFields)
+	copy(e.cm[:], m)
+	copy(e.cb[:], b)
+	for i := 0; i < e.nFields; i++ {
+		e.m[i] = real(e.cm[i])
+		e.b[i] = real(e.cb[i])
+	}
+	return e
+}
+
+// LinterpEntry creates an Entry of LINTERP type without adding to any Dirfile
+func LinterpEntry(name, inField, table string, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: LINTERPENTRY,
+		fragment:  fragmentIndex,
+		table:     table,
+	}
+	e.inFields[0] = inField
+	return e
+}
+
+// twoInputEntry creates an Entry of any type whose only parameters are two input fields
+func twoInputEntry(name string, fieldType EntryType, inField1, inField2 string, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: fieldType,
+		fragment:  fragmentIndex,
+	}
+	e.inFields[0] = inField1
+	e.inFields[1] = inField2
+	return e
+}
+
+// MultiplyEntry creates an Entry of MULTIPLY type without adding to any Dirfile
+func MultiplyEntry(name, inField1, inField2 string, fragmentIndex int) Entry {
+	return twoInputEntry(name, MULTIPLYENTRY, inField1, inField2, fragmentIndex)
+}
+
+// DivideEntry creates an Entry of DIVIDE type without adding to any Dirfile
+func DivideEntry(name, inField1, inField2 string, fragmentIndex int) Entry {
+	return twoInputEntry(name, DIVIDEENTRY, inField1, inField2, fragmentIndex)
+}
+
+// IndirEntry creates an Entry of INDIR type without adding to any Dirfile
+func IndirEntry(name, indexField, carrayField string, fragmentIndex int) Entry {
+	return twoInputEntry(name, INDIRENTRY, indexField, carrayField, fragmentIndex)
+}
+
+// SindirEntry creates an Entry of SINDIR type without adding to any Dirfile
+func SindirEntry(name, indexField, sarrayField string, fragmentIndex int) Entry {
+	return twoInputEntry(name, SINDIRENTRY, indexField, sarrayField, fragmentIndex)
+}
+
+// PhaseEntry creates an Entry of PHASE type without adding to any Dirfile
+func PhaseEntry(name, inField string, shift int64, fragmentIndex int) Entry {
+	var e = Entry{
+		name:       name,
+		fieldType:  PHASEENTRY,
+		fragment:   fragmentIndex,
+		phaseShift: shift,
+	}
+	e.inFields[0] = inField
+	return e
+}
+
+// PolynomEntry creates an Entry of POLYNOM type without adding to any Dirfile.
+// The coefficients a start with the constant term.
+func PolynomEntry(name, inField string, a []float64, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: POLYNOMENTRY,
+		fragment:  fragmentIndex,
+	}
+	e.inFields[0] = inField
+	e.polyOrder = copy(e.a[:], a) - 1
+	for i := 0; i <= e.polyOrder; i++ {
+		e.ca[i] = complex(e.a[i], 0)
+	}
+	return e
+}
+
+// CPolynomEntry creates an Entry of POLYNOM type with complex coefficients
+// without adding to any Dirfile. The coefficients a start with the constant term.
+func CPolynomEntry(name, inField string, a []complex128, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: POLYNOMENTRY,
+		flags:     C.GD_EN_COMPSCAL,
+		fragment:  fragmentIndex,
+	}
+	e.inFields[0] = inField
+	e.polyOrder = copy(e.ca[:], a) - 1
+	for i := 0; i <= e.polyOrder; i++ {
+		e.a[i] = real(e.ca[i])
+	}
+	return e
+}
+
+// RecipEntry creates an Entry of RECIP type without adding to any Dirfile
+func RecipEntry(name, inField string, dividend float64, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: RECIPENTRY,
+		fragment:  fragmentIndex,
+	}
+	e.inFields[0] = inField
+	e.dividend = dividend
+	e.cdividend = complex(dividend, 0)
+	return e
+}
+
+// CRecipEntry creates an Entry of RECIP type with a complex dividend without
+// adding to any Dirfile
+func CRecipEntry(name, inField string, dividend complex128, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: RECIPENTRY,
+		flags:     C.GD_EN_COMPSCAL,
+		fragment:  fragmentIndex,
+	}
+	e.inFields[0] = inField
+	e.dividend = real(dividend)
+	e.cdividend = dividend
+	return e
+}
+
+// MplexEntry creates an Entry of MPLEX type without adding to any Dirfile
+func MplexEntry(name, inField, countField string, countVal, period, fragmentIndex int) Entry {
+	e := twoInputEntry(name, MPLEXENTRY, inField, countField, fragmentIndex)
+	e.countVal = countVal
+	e.period = period
+	return e
+}
+
+// WindowEntry creates an Entry of WINDOW type without adding to any Dirfile.
+// The threshold should be a value of any integer or floating-point type.
+func WindowEntry(name, inField, checkField string, windowOp WindowOps,
+	threshold interface{}, fragmentIndex int) Entry {
+	e := twoInputEntry(name, WINDOWENTRY, inField, checkField, fragmentIndex)
+	e.windOp = windowOp
+	e.iThreshold, e.uThreshold, e.fThreshold, _ = thresholdValues("WindowEntry", name, threshold)
+	return e
+}
+
+// ConstEntry creates an Entry of CONST type without adding to any Dirfile.
+// The value should be of some numeric type; it will be stored as constType.
+func ConstEntry(name string, constType RetType, value interface{}, fragmentIndex int) Entry {
+	return Entry{
+		name:      name,
+		fieldType: CONSTENTRY,
+		fragment:  fragmentIndex,
+		constType: constType,
+		value:     value,
+	}
+}
+
+// CarrayEntry creates an Entry of CARRAY type without adding to any Dirfile.
+// The values should be a slice of some numeric type; they will be stored as constType.
+func CarrayEntry(name string, constType RetType, values interface{}, fragmentIndex int) Entry {
+	_, _, n := array2type(values)
+	return Entry{
+		name:      name,
+		fieldType: CARRAYENTRY,
+		fragment:  fragmentIndex,
+		constType: constType,
+		arrayLen:  n,
+		value:     values,
+	}
+}
+
+// StringEntry creates an Entry of STRING type without adding to any Dirfile
+func StringEntry(name, value string, fragmentIndex int) Entry {
+	return Entry{
+		name:      name,
+		fieldType: STRINGENTRY,
+		fragment:  fragmentIndex,
+		value:     value,
+	}
+}
+
+// SarrayEntry creates an Entry of SARRAY type without adding to any Dirfile
+func SarrayEntry(name string, values []string, fragmentIndex int) Entry {
+	return Entry{
+		name:      name,
+		fieldType: SARRAYENTRY,
+		fragment:  fragmentIndex,
+		arrayLen:  len(values),
+		value:     values,
+	}
+}
+
+// AliasEntry creates an alias named name for the field target, without
+// adding to any Dirfile
+func AliasEntry(name, target string, fragmentIndex int) Entry {
+	var e = Entry{
+		name:      name,
+		fieldType: ALIASENTRY,
+		fragment:  fragmentIndex,
+	}
+	e.inFields[0] = target
+	return e
+}
+
+// thresholdValues converts a numeric WINDOW threshold to the three possible
+// representations (integer, unsigned, and real) used by the C library.
+func thresholdValues(op, fieldcode string, threshold interface{}) (int64, uint64, float64, error) {
+	switch v := threshold.(type) {
+	case int:
+		return int64(v), uint64(v), float64(v), nil
+	case int8:
+		return int64(v), uint64(v), float64(v), nil
+	case int16:
+		return int64(v), uint64(v), float64(v), nil
+	case int32:
+		return int64(v), uint64(v), float64(v), nil
+	case int64:
+		return v, uint64(v), float64(v), nil
+	case uint:
+		return int64(v), uint64(v), float64(v), nil
+	case uint8:
+		return int64(v), uint64(v), float64(v), nil
+	case uint16:
+		return int64(v), uint64(v), float64(v), nil
+	case uint32:
+		return int64(v), uint64(v), float64(v), nil
+	case uint64:
+		return int64(v), v, float64(v), nil
+	case float32:
+		return int64(v), uint64(v), float64(v), nil
+	case float64:
+		return int64(v), uint64(v), v, nil
+	}
+	return 0, 0, 0, newError(ErrArgument, op, fieldcode,
+		"Window threshold of type %T is not an integer or floating-point value", threshold)
+}
+
+// loadValue reads the value of a scalar (CONST, CARRAY, STRING, or SARRAY) entry
+// from the Dirfile it was read from, unless the value is already known.
+// Numeric values are read in the widest Go type of the same kind as the stored type.
+func (e *Entry) loadValue(op string) error {
+	if e.value != nil {
+		return nil
+	}
+	if e.df == nil {
+		return newError(ErrArgument, op, e.name, "Entry %s has no value and is not associated with a Dirfile", e.name)
+	}
+	switch e.fieldType {
+	case CONSTENTRY:
+		var err error
+		switch e.constType {
+		case COMPLEX64, COMPLEX128:
+			e.value, err = e.df.GetConstantComplex128(e.name)
+		case FLOAT32, FLOAT64:
+			e.value, err = e.df.GetConstantFloat64(e.name)
+		case UINT8, UINT16, UINT32, UINT64:
+			var v uint64
+			err = e.df.GetConstant(e.name, &v)
+			e.value = v
+		default:
+			e.value, err = e.df.GetConstantInt64(e.name)
+		}
+		if err != nil {
+			e.value = nil
+		}
+		return err
+
+	case CARRAYENTRY:
+		var out interface{}
+		switch e.constType {
+		case COMPLEX64, COMPLEX128:
+			v := make([]complex128, e.arrayLen)
+			out = &v
+		case FLOAT32, FLOAT64:
+			v := make([]float64, e.arrayLen)
+			out = &v
+		case UINT8, UINT16, UINT32, UINT64:
+			v := make([]uint64, e.arrayLen)
+			out = &v
+		default:
+			v := make([]int64, e.arrayLen)
+			out = &v
+		}
+		if err := e.df.GetCarray(e.name, out); err != nil {
+			return err
+		}
+		switch v := out.(type) {
+		case *[]complex128:
+			e.value = *v
+		case *[]float64:
+			e.value = *v
+		case *[]uint64:
+			e.value = *v
+		case *[]int64:
+			e.value = *v
+		}
+
+	case STRINGENTRY:
+		v, err := e.df.GetString(e.name)
+		if err != nil {
+			return err
+		}
+		e.value = v
+
+	case SARRAYENTRY:
+		v, err := e.df.GetSarray(e.name)
+		if err != nil {
+			return err
+		}
+		e.value = v
+
+	default:
+		return e.errNoParameter(op, "value")
+	}
+	return nil
+}
+
 // Filename returns the raw dirfile's filename
 func (e Entry) Filename() (string, error) {
+	if e.df == nil {
+		return "", e.errNoDirfile("Filename")
+	}
 	return e.df.Filename(e.name)
 }
 
-// func (e *Entry) SetType(t RetType)
+// errNoDirfile is returned when changing an Entry that was not read from a
+// Dirfile (e.g. one from a MemDirfile or FSDirfile)
+func (e *Entry) errNoDirfile(op string) error {
+	return newError(ErrArgument, op, e.name, "Entry %s is not associated with a Dirfile", e.name)
+}
+
+// errNoParameter is returned when asking an Entry for a parameter that its
+// field type does not have.
+func (e Entry) errNoParameter(op, param string) error {
+	return newError(ErrBadFieldType, op, e.name, "Entry %s of type 0x%x has no %s", e.name, e.fieldType, param)
+}
+
+// nInputs returns the number of input fields used by this entry's field type.
+func (e Entry) nInputs() int {
+	switch e.fieldType {
+	case LINCOMENTRY:
+		return e.nFields
+	case LINTERPENTRY, BITENTRY, SBITENTRY, PHASEENTRY, POLYNOMENTRY, RECIPENTRY:
+		return 1
+	case MULTIPLYENTRY, DIVIDEENTRY, WINDOWENTRY, MPLEXENTRY, INDIRENTRY, SINDIRENTRY:
+		return 2
+	}
+	return 0
+}
+
+// Name returns the field code of the entry
+func (e Entry) Name() string {
+	return e.name
+}
+
+// Type returns the field type of the entry
+func (e Entry) Type() EntryType {
+	return e.fieldType
+}
+
+// Fragment returns the index of the format fragment which defines the entry
+func (e Entry) Fragment() int {
+	return e.fragment
+}
+
+// Hidden returns whether the entry is hidden
+func (e Entry) Hidden() bool {
+	return e.flags&C.GD_EN_HIDDEN != 0
+}
+
+// ComplexScalars returns whether the entry has complex-valued scalar parameters
+// (possible for LINCOM, POLYNOM, and RECIP entries)
+func (e Entry) ComplexScalars() bool {
+	return e.flags&C.GD_EN_COMPSCAL != 0
+}
+
+// SPF returns the samples per frame of a RAW entry
+func (e Entry) SPF() (uint, error) {
+	if e.fieldType != RAWENTRY {
+		return 0, e.errNoParameter("SPF", "samples per frame")
+	}
+	return e.spf, nil
+}
+
+// DataType returns the data type stored on disk for a RAW entry
+func (e Entry) DataType() (RetType, error) {
+	if e.fieldType != RAWENTRY {
+		return UNKNOWN, e.errNoParameter("DataType", "data type")
+	}
+	return e.dataType, nil
+}
+
+// ConstType returns the storage type of a CONST or CARRAY entry
+func (e Entry) ConstType() (RetType, error) {
+	if e.fieldType != CONSTENTRY && e.fieldType != CARRAYENTRY {
+		return UNKNOWN, e.errNoParameter("ConstType", "constant type")
+	}
+	return e.constType, nil
+}
+
+// ArrayLen returns the number of elements in a CARRAY or SARRAY entry
+func (e Entry) ArrayLen() (int, error) {
+	if e.fieldType != CARRAYENTRY && e.fieldType != SARRAYENTRY {
+		return 0, e.errNoParameter("ArrayLen", "array length")
+	}
+	return e.arrayLen, nil
+}
+
+// InFields returns the field codes of all inputs to a derived entry. For
+// WINDOW, MPLEX, INDIR and SINDIR entries the second element is the check
+// field, count field, or CARRAY/SARRAY field, respectively.
+func (e Entry) InFields() ([]string, error) {
+	n := e.nInputs()
+	if n == 0 {
+		return nil, e.errNoParameter("InFields", "input fields")
+	}
+	result := make([]string, n)
+	copy(result, e.inFields[:n])
+	return result, nil
+}
+
+// NFields returns the number of input fields of a LINCOM entry
+func (e Entry) NFields() (int, error) {
+	if e.fieldType != LINCOMENTRY {
+		return 0, e.errNoParameter("NFields", "linear combination")
+	}
+	return e.nFields, nil
+}
+
+// checkLincomInput verifies that input i exists for this LINCOM entry
+func (e Entry) checkLincomInput(op string, i int) error {
+	if e.fieldType != LINCOMENTRY {
+		return e.errNoParameter(op, "linear combination")
+	}
+	if i < 0 || i >= e.nFields {
+		return newError(ErrBounds, op, e.name, "Entry %s has %d inputs, cannot access input %d", e.name, e.nFields, i)
+	}
+	return nil
+}
+
+// Scale returns the (real) scale factor applied to LINCOM input i
+func (e Entry) Scale(i int) (float64, error) {
+	if err := e.checkLincomInput("Scale", i); err != nil {
+		return 0, err
+	}
+	return e.m[i], nil
+}
+
+// CScale returns the complex scale factor applied to LINCOM input i
+func (e Entry) CScale(i int) (complex128, error) {
+	if err := e.checkLincomInput("CScale", i); err != nil {
+		return 0, err
+	}
+	return e.cm[i], nil
+}
+
+// Offset returns the (real) offset added to LINCOM input i
+func (e Entry) Offset(i int) (float64, error) {
+	if err := e.checkLincomInput("Offset", i); err != nil {
+		return 0, err
+	}
+	return e.b[i], nil
+}
+
+// COffset returns the complex offset added to LINCOM input i
+func (e Entry) COffset(i int) (complex128, error) {
+	if err := e.checkLincomInput("COffset", i); err != nil {
+		return 0, err
+	}
+	return e.cb[i], nil
+}
+
+// PolyOrder returns the order of a POLYNOM entry
+func (e Entry) PolyOrder() (int, error) {
+	if e.fieldType != POLYNOMENTRY {
+		return 0, e.errNoParameter("PolyOrder", "polynomial")
+	}
+	return e.polyOrder, nil
+}
+
+// PolyCoefficients returns the (real) coefficients of a POLYNOM entry, starting
+// with the constant term.
+func (e Entry) PolyCoefficients() ([]float64, error) {
+	if e.fieldType != POLYNOMENTRY {
+		return nil, e.errNoParameter("PolyCoefficients", "polynomial")
+	}
+	result := make([]float64, e.polyOrder+1)
+	copy(result, e.a[:e.polyOrder+1])
+	return result, nil
+}
+
+// CPolyCoefficients returns the complex coefficients of a POLYNOM entry, starting
+// with the constant term.
+func (e Entry) CPolyCoefficients() ([]complex128, error) {
+	if e.fieldType != POLYNOMENTRY {
+		return nil, e.errNoParameter("CPolyCoefficients", "polynomial")
+	}
+	result := make([]complex128, e.polyOrder+1)
+	copy(result, e.ca[:e.polyOrder+1])
+	return result, nil
+}
+
+// BitRange returns the first bit and the number of bits of a BIT or SBIT entry
+func (e Entry) BitRange() (bitnum, numbits int, err error) {
+	if e.fieldType != BITENTRY && e.fieldType != SBITENTRY {
+		return 0, 0, e.errNoParameter("BitRange", "bit range")
+	}
+	return e.bitnum, e.numbits, nil
+}
+
+// Table returns the path to the look-up table of a LINTERP entry, as given in
+// the format file. See also Dirfile.LinterpTablename.
+func (e Entry) Table() (string, error) {
+	if e.fieldType != LINTERPENTRY {
+		return "", e.errNoParameter("Table", "look-up table")
+	}
+	return e.table, nil
+}
+
+// Shift returns the phase shift, in samples, of a PHASE entry
+func (e Entry) Shift() (int64, error) {
+	if e.fieldType != PHASEENTRY {
+		return 0, e.errNoParameter("Shift", "phase shift")
+	}
+	return e.phaseShift, nil
+}
+
+// Dividend returns the (real) dividend of a RECIP entry
+func (e Entry) Dividend() (float64, error) {
+	if e.fieldType != RECIPENTRY {
+		return 0, e.errNoParameter("Dividend", "dividend")
+	}
+	return e.dividend, nil
+}
+
+// CDividend returns the complex dividend of a RECIP entry
+func (e Entry) CDividend() (complex128, error) {
+	if e.fieldType != RECIPENTRY {
+		return 0, e.errNoParameter("CDividend", "dividend")
+	}
+	return e.cdividend, nil
+}
+
+// CountVal returns the value of the count field which selects a MPLEX entry
+func (e Entry) CountVal() (int, error) {
+	if e.fieldType != MPLEXENTRY {
+		return 0, e.errNoParameter("CountVal", "count value")
+	}
+	return e.countVal, nil
+}
+
+// Period returns the number of samples between successive occurrences of the
+// count value in a MPLEX entry (zero if not specified)
+func (e Entry) Period() (int, error) {
+	if e.fieldType != MPLEXENTRY {
+		return 0, e.errNoParameter("Period", "count period")
+	}
+	return e.period, nil
+}
+
+// WindowOp returns the comparison operation of a WINDOW entry
+func (e Entry) WindowOp() (WindowOps, error) {
+	if e.fieldType != WINDOWENTRY {
+		return WINDOPUNK, e.errNoParameter("WindowOp", "window operation")
+	}
+	return e.windOp, nil
+}
+
+// Threshold returns the threshold of a WINDOW entry. Its type depends on the
+// window operation: int64 for WINDOPEQ and WINDOPNE, uint64 for WINDOPSET and
+// WINDOPCLR, and float64 for the remaining (ordering) operations.
+func (e Entry) Threshold() (interface{}, error) {
+	if e.fieldType != WINDOWENTRY {
+		return nil, e.errNoParameter("Threshold", "window threshold")
+	}
+	switch e.windOp {
+	case WINDOPEQ, WINDOPNE:
+		return e.iThreshold, nil
+	case WINDOPSET, WINDOPCLR:
+		return e.uThreshold, nil
+	}
+	return e.fThreshold, nil
+}
+
+// Scalar returns the field code of the CONST or CARRAY field used in place of
+// literal parameter i (see the C API documentation of gd_entry_t for the order of
+// parameters), together with the CARRAY element index (or -1 for a CONST).
+// The field code is empty if parameter i is given as a literal.
+func (e Entry) Scalar(i int) (string, int, error) {
+	if i < 0 || i > MAXPOLYORD {
+		return "", 0, newError(ErrBounds, "Scalar", e.name, "Scalar index %d out of range [0,%d]", i, MAXPOLYORD)
+	}
+	return e.scalars[i], e.scalarInd[i], nil
+}
+
+// clearScalars makes parameters i of the entry literal values, dropping any
+// CONST or CARRAY field codes they were given as
+func (e *Entry) clearScalars(i ...int) {
+	for _, j := range i {
+		e.scalars[j] = ""
+		e.scalarInd[j] = 0
+	}
+}
+
+// alter writes the modified copy of this entry through to its Dirfile and, if
+// that succeeds, replaces this entry with it. Parameters the modified entry
+// still takes from CONST or CARRAY fields keep those references.
+func (e *Entry) alter(modified Entry, recode bool) error {
+	if e.df == nil {
+		return e.errNoDirfile("AlterEntry")
+	}
+	if err := e.df.AlterEntry(e.name, &modified, recode); err != nil {
+		return err
+	}
+	*e = modified
+	return nil
+}
+
+// SetDataType changes the data type of a RAW entry. If recode is true, the
+// binary file on disk is converted to the new type.
+func (e *Entry) SetDataType(t RetType, recode bool) error {
+	if e.fieldType != RAWENTRY {
+		return e.errNoParameter("SetDataType", "data type")
+	}
+	modified := *e
+	modified.dataType = t
+	return e.alter(modified, recode)
+}
+
+// SetSPF changes the samples per frame of a RAW entry. If recode is true, the
+// binary file on disk is converted to the new sample rate.
+func (e *Entry) SetSPF(spf uint, recode bool) error {
+	if e.fieldType != RAWENTRY {
+		return e.errNoParameter("SetSPF", "samples per frame")
+	}
+	modified := *e
+	modified.spf = spf
+	modified.clearScalars(0)
+	return e.alter(modified, recode)
+}
+
+// SetInFields changes the input fields of a derived entry. The number of inputs
+// must match the number the entry already has; to change the number of inputs of
+// a LINCOM entry, use SetLincom.
+func (e *Entry) SetInFields(inFields ...string) error {
+	n := e.nInputs()
+	if n == 0 {
+		return e.errNoParameter("SetInFields", "input fields")
+	}
+	if len(inFields) != n {
+		return newError(ErrArgument, "SetInFields", e.name, "Entry %s needs %d input fields, not %d", e.name, n, len(inFields))
+	}
+	modified := *e
+	copy(modified.inFields[:], inFields)
+	return e.alter(modified, false)
+}
+
+// SetLincom changes the input fields, scale factors, and offsets of a LINCOM
+// entry. It needs 1 to MAXLINCOM input fields, each with one of m and b.
+func (e *Entry) SetLincom(inFields []string, m, b []float64) error {
+	if e.fieldType != LINCOMENTRY {
+		return e.errNoParameter("SetLincom", "linear combination")
+	}
+	if err := checkLincom("SetLincom", e.name, len(inFields), len(m), len(b)); err != nil {
+		return err
+	}
+	modified := LincomEntry(e.name, inFields, m, b, e.fragment)
+	modified.df = e.df
+	modified.flags = e.flags &^ C.GD_EN_COMPSCAL
+	return e.alter(modified, false)
+}
+
+// SetCLincom changes the input fields, and complex scale factors and offsets, of
+// a LINCOM entry. It needs 1 to MAXLINCOM input fields, each with one of m and b.
+func (e *Entry) SetCLincom(inFields []string, m, b []complex128) error {
+	if e.fieldType != LINCOMENTRY {
+		return e.errNoParameter("SetCLincom", "linear combination")
+	}
+	if err := checkLincom("SetCLincom", e.name, len(inFields), len(m), len(b)); err != nil {
+		return err
+	}
+	modified := CLincomEntry(e.name, inFields, m, b, e.fragment)
+	modified.df = e.df
+	modified.flags = e.flags | C.GD_EN_COMPSCAL
+	return e.alter(modified, false)
+}
+
+// SetBitRange changes the first bit and the number of bits of a BIT or SBIT entry
+func (e *Entry) SetBitRange(bitnum, numbits int) error {
+	if e.fieldType != BITENTRY && e.fieldType != SBITENTRY {
+		return e.errNoParameter("SetBitRange", "bit range")
+	}
+	modified := *e
+	modified.bitnum = bitnum
+	modified.numbits = numbits
+	modified.clearScalars(0, 1)
+	return e.alter(modified, false)
+}
+
+// SetPolyCoefficients changes the coefficients of a POLYNOM entry, starting with
+// the constant term. The polynomial order is len(a)-1, from 0 to MAXPOLYORD.
+func (e *Entry) SetPolyCoefficients(a []float64) error {
+	if e.fieldType != POLYNOMENTRY {
+		return e.errNoParameter("SetPolyCoefficients", "polynomial")
+	}
+	if err := checkPolynom("SetPolyCoefficients", e.name, len(a)); err != nil {
+		return err
+	}
+	modified := PolynomEntry(e.name, e.inFields[0], a, e.fragment)
+	modified.df = e.df
+	modified.flags = e.flags &^ C.GD_EN_COMPSCAL
+	return e.alter(modified, false)
+}
+
+// SetCPolyCoefficients changes the complex coefficients of a POLYNOM entry,
+// starting with the constant term. The polynomial order is len(a)-1, from 0 to
+// MAXPOLYORD.
+func (e *Entry) SetCPolyCoefficients(a []complex128) error {
+	if e.fieldType != POLYNOMENTRY {
+		return e.errNoParameter("SetCPolyCoefficients", "polynomial")
+	}
+	if err := checkPolynom("SetCPolyCoefficients", e.name, len(a)); err != nil {
+		return err
+	}
+	modified := CPolynomEntry(e.name, e.inFields[0], a, e.fragment)
+	modified.df = e.df
+	modified.flags = e.flags | C.GD_EN_COMPSCAL
+	return e.alter(modified, false)
+}
+
+// SetTable changes the look-up table of a LINTERP entry. If renameTable is true,
+// the existing table file is moved to the new path.
+func (e *Entry) SetTable(table string, renameTable bool) error {
+	if e.fieldType != LINTERPENTRY {
+		return e.errNoParameter("SetTable", "look-up table")
+	}
+	modified := *e
+	modified.table = table
+	return e.alter(modified, renameTable)
+}
+
+// SetShift changes the phase shift, in samples, of a PHASE entry
+func (e *Entry) SetShift(shift int64) error {
+	if e.fieldType != PHASEENTRY {
+		return e.errNoParameter("SetShift", "phase shift")
+	}
+	modified := *e
+	modified.phaseShift = shift
+	modified.clearScalars(0)
+	return e.alter(modified, false)
+}
+
+// SetDividend changes the dividend of a RECIP entry
+func (e *Entry) SetDividend(dividend float64) error {
+	if e.fieldType != RECIPENTRY {
+		return e.errNoParameter("SetDividend", "dividend")
+	}
+	modified := *e
+	modified.dividend = dividend
+	modified.cdividend = complex(dividend, 0)
+	modified.clearScalars(0)
+	modified.flags &^= C.GD_EN_COMPSCAL
+	return e.alter(modified, false)
+}
+
+// SetCDividend changes the complex dividend of a RECIP entry
+func (e *Entry) SetCDividend(dividend complex128) error {
+	if e.fieldType != RECIPENTRY {
+		return e.errNoParameter("SetCDividend", "dividend")
+	}
+	modified := *e
+	modified.dividend = real(dividend)
+	modified.cdividend = dividend
+	modified.clearScalars(0)
+	modified.flags |= C.GD_EN_COMPSCAL
+	return e.alter(modified, false)
+}
+
+// SetMplex changes the count value and period of a MPLEX entry
+func (e *Entry) SetMplex(countVal, period int) error {
+	if e.fieldType != MPLEXENTRY {
+		return e.errNoParameter("SetMplex", "count value")
+	}
+	modified := *e
+	modified.countVal = countVal
+	modified.period = period
+	modified.clearScalars(0, 1)
+	return e.alter(modified, false)
+}
+
+// SetWindow changes the operation and threshold of a WINDOW entry. The threshold
+// may be of any integer or floating-point type.
+func (e *Entry) SetWindow(windowOp WindowOps, threshold interface{}) error {
+	if e.fieldType != WINDOWENTRY {
+		return e.errNoParameter("SetWindow", "window operation")
+	}
+	modified := *e
+	modified.windOp = windowOp
+	var err error
+	modified.iThreshold, modified.uThreshold, modified.fThreshold, err = thresholdValues("SetWindow", e.name, threshold)
+	if err != nil {
+		return err
+	}
+	modified.clearScalars(0)
+	return e.alter(modified, false)
+}
+
+// SetConstType changes the storage type of a CONST or CARRAY entry
+func (e *Entry) SetConstType(t RetType) error {
+	if e.fieldType != CONSTENTRY && e.fieldType != CARRAYENTRY {
+		return e.errNoParameter("SetConstType", "constant type")
+	}
+	modified := *e
+	modified.constType = t
+	return e.alter(modified, false)
+}
+
+// SetArrayLen changes the length of a CARRAY or SARRAY entry. New elements are
+// zero (or empty strings); excess elements are discarded.
+func (e *Entry) SetArrayLen(n int) error {
+	if e.fieldType != CARRAYENTRY && e.fieldType != SARRAYENTRY {
+		return e.errNoParameter("SetArrayLen", "array length")
+	}
+	modified := *e
+	modified.arrayLen = n
+	return e.alter(modified, false)
+}
 
 // Move moves this entry to a new fragment number
 func (e *Entry) Move(newfrag int, flags RenameFlags) error {
+	if e.df == nil {
+		return e.errNoDirfile("Move")
+	}
 	fcode := C.CString(e.name)
 	defer C.free(unsafe.Pointer(fcode))
 	cidx := C.int(newfrag)
 	result := C.gd_move(e.df.d, fcode, cidx, C.uint(flags))
 	if result < 0 {
-		return e.df.Error()
+		return e.df.opError("Move", e.name)
 	}
 	e.fragment = newfrag
 	return nil
@@ -230,13 +1345,16 @@ func (e *Entry) Move(newfrag int, flags RenameFlags) error {
 
 // Rename renames this entry to the indicated name
 func (e *Entry) Rename(newname string, flags RenameFlags) error {
+	if e.df == nil {
+		return e.errNoDirfile("Rename")
+	}
 	fcode := C.CString(e.name)
 	defer C.free(unsafe.Pointer(fcode))
 	ncode := C.CString(newname)
 	defer C.free(unsafe.Pointer(ncode))
 	result := C.gd_rename(e.df.d, fcode, ncode, C.uint(flags))
 	if result < 0 {
-		return e.df.Error()
+		return e.df.opError("Rename", e.name)
 	}
 	e.name = newname
 	return nil
@@ -0,0 +1,552 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEntryAccessors(t *testing.T) {
+	dir := "dirfile_entry"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-only:", err)
+	}
+	defer d.Close()
+
+	// RAW
+	e, err := d.Entry("data")
+	if err != nil {
+		t.Fatal("Could not get Entry for raw type:", err)
+	}
+	if e.Name() != "data" {
+		t.Errorf("Entry.Name()=%q, want %q", e.Name(), "data")
+	}
+	if e.Type() != RAWENTRY {
+		t.Errorf("Entry.Type()=0x%x, want RAW=0x%x", e.Type(), RAWENTRY)
+	}
+	if e.Fragment() != 0 {
+		t.Errorf("Entry.Fragment()=%d, want 0", e.Fragment())
+	}
+	if e.Hidden() {
+		t.Error("Entry.Hidden()=true, want false")
+	}
+	if spf, err := e.SPF(); err != nil || spf != 8 {
+		t.Errorf("Entry.SPF()=%d, %v, want 8, nil", spf, err)
+	}
+	if dt, err := e.DataType(); err != nil || dt != INT8 {
+		t.Errorf("Entry.DataType()=0x%x, %v, want INT8=0x%x, nil", dt, err, INT8)
+	}
+	if _, err := e.InFields(); err == nil {
+		t.Error("Entry.InFields() on a RAW entry did not return error")
+	}
+	if _, _, err := e.BitRange(); err == nil {
+		t.Error("Entry.BitRange() on a RAW entry did not return error")
+	}
+
+	// LINCOM
+	e, err = d.Entry("lincom")
+	if err != nil {
+		t.Fatal("Could not get Entry for lincom type:", err)
+	}
+	if n, err := e.NFields(); err != nil || n != 3 {
+		t.Errorf("Entry.NFields()=%d, %v, want 3, nil", n, err)
+	}
+	expectedf := []string{"data", "INDEX", "linterp"}
+	expectedm := []complex128{1.1, 2.2, 5.5}
+	expectedb := []complex128{2.2, complex(3.3, 4.4), 5.5}
+	if in, err := e.InFields(); err != nil || len(in) != len(expectedf) {
+		t.Errorf("Entry.InFields()=%v, %v, want %v, nil", in, err, expectedf)
+	} else {
+		for i := range expectedf {
+			if in[i] != expectedf[i] {
+				t.Errorf("Entry.InFields()[%d]=%q, want %q", i, in[i], expectedf[i])
+			}
+		}
+	}
+	if !e.ComplexScalars() {
+		t.Error("Entry.ComplexScalars()=false for complex LINCOM, want true")
+	}
+	for i := range expectedf {
+		if m, err := e.Scale(i); err != nil || m != real(expectedm[i]) {
+			t.Errorf("Entry.Scale(%d)=%f, %v, want %f, nil", i, m, err, real(expectedm[i]))
+		}
+		if cm, err := e.CScale(i); err != nil || cm != expectedm[i] {
+			t.Errorf("Entry.CScale(%d)=%f, %v, want %f, nil", i, cm, err, expectedm[i])
+		}
+		if b, err := e.Offset(i); err != nil || b != real(expectedb[i]) {
+			t.Errorf("Entry.Offset(%d)=%f, %v, want %f, nil", i, b, err, real(expectedb[i]))
+		}
+		if cb, err := e.COffset(i); err != nil || cb != expectedb[i] {
+			t.Errorf("Entry.COffset(%d)=%f, %v, want %f, nil", i, cb, err, expectedb[i])
+		}
+	}
+	if _, err := e.Scale(3); err == nil {
+		t.Error("Entry.Scale(3) on a 3-input LINCOM did not return error")
+	}
+	if s, idx, err := e.Scalar(2); err != nil || s != "const" || idx != -1 {
+		t.Errorf("Entry.Scalar(2)=%q, %d, %v, want \"const\", -1, nil", s, idx, err)
+	}
+	if s, _, err := e.Scalar(0); err != nil || s != "" {
+		t.Errorf("Entry.Scalar(0)=%q, %v, want \"\", nil", s, err)
+	}
+
+	// POLYNOM
+	e, err = d.Entry("polynom")
+	if err != nil {
+		t.Fatal("Could not get Entry for polynom type:", err)
+	}
+	expecteda := []complex128{1.1, 2.2, 2.2, complex(3.3, 4.4), 5.5, 5.5}
+	if order, err := e.PolyOrder(); err != nil || order != 5 {
+		t.Errorf("Entry.PolyOrder()=%d, %v, want 5, nil", order, err)
+	}
+	if a, err := e.PolyCoefficients(); err != nil || len(a) != len(expecteda) {
+		t.Errorf("Entry.PolyCoefficients() returns length %d, %v, want %d, nil", len(a), err, len(expecteda))
+	} else {
+		for i := range a {
+			if a[i] != real(expecteda[i]) {
+				t.Errorf("Entry.PolyCoefficients()[%d]=%f, want %f", i, a[i], real(expecteda[i]))
+			}
+		}
+	}
+	if ca, err := e.CPolyCoefficients(); err != nil || len(ca) != len(expecteda) {
+		t.Errorf("Entry.CPolyCoefficients() returns length %d, %v, want %d, nil", len(ca), err, len(expecteda))
+	} else {
+		for i := range ca {
+			if ca[i] != expecteda[i] {
+				t.Errorf("Entry.CPolyCoefficients()[%d]=%f, want %f", i, ca[i], expecteda[i])
+			}
+		}
+	}
+
+	// LINTERP
+	e, err = d.Entry("linterp")
+	if err != nil {
+		t.Fatal("Could not get Entry for linterp type:", err)
+	}
+	if table, err := e.Table(); err != nil || table != "./lut" {
+		t.Errorf("Entry.Table()=%q, %v, want \"./lut\", nil", table, err)
+	}
+
+	// BIT and SBIT
+	e, err = d.Entry("bit")
+	if err != nil {
+		t.Fatal("Could not get Entry for bit type:", err)
+	}
+	if bitnum, numbits, err := e.BitRange(); err != nil || bitnum != 3 || numbits != 4 {
+		t.Errorf("Entry.BitRange()=%d, %d, %v, want 3, 4, nil", bitnum, numbits, err)
+	}
+	e, err = d.Entry("sbit")
+	if err != nil {
+		t.Fatal("Could not get Entry for sbit type:", err)
+	}
+	if bitnum, numbits, err := e.BitRange(); err != nil || bitnum != 5 || numbits != 6 {
+		t.Errorf("Entry.BitRange()=%d, %d, %v, want 5, 6, nil", bitnum, numbits, err)
+	}
+
+	// RECIP
+	e, err = d.Entry("recip")
+	if err != nil {
+		t.Fatal("Could not get Entry for recip type:", err)
+	}
+	if div, err := e.Dividend(); err != nil || div != 6.5 {
+		t.Errorf("Entry.Dividend()=%f, %v, want 6.5, nil", div, err)
+	}
+	if cdiv, err := e.CDividend(); err != nil || cdiv != complex(6.5, 4.3) {
+		t.Errorf("Entry.CDividend()=%f, %v, want (6.5+4.3i), nil", cdiv, err)
+	}
+
+	// PHASE
+	e, err = d.Entry("phase")
+	if err != nil {
+		t.Fatal("Could not get Entry for phase type:", err)
+	}
+	if shift, err := e.Shift(); err != nil || shift != 11 {
+		t.Errorf("Entry.Shift()=%d, %v, want 11, nil", shift, err)
+	}
+	if _, err := e.Dividend(); err == nil {
+		t.Error("Entry.Dividend() on a PHASE entry did not return error")
+	}
+
+	// MPLEX
+	e, err = d.Entry("mplex")
+	if err != nil {
+		t.Fatal("Could not get Entry for mplex type:", err)
+	}
+	if cv, err := e.CountVal(); err != nil || cv != 1 {
+		t.Errorf("Entry.CountVal()=%d, %v, want 1, nil", cv, err)
+	}
+	if p, err := e.Period(); err != nil || p != 10 {
+		t.Errorf("Entry.Period()=%d, %v, want 10, nil", p, err)
+	}
+
+	// WINDOW
+	e, err = d.Entry("window")
+	if err != nil {
+		t.Fatal("Could not get Entry for window type:", err)
+	}
+	if op, err := e.WindowOp(); err != nil || op != WINDOPLT {
+		t.Errorf("Entry.WindowOp()=0x%x, %v, want WINDOPLT=0x%x, nil", op, err, WINDOPLT)
+	}
+	if thresh, err := e.Threshold(); err != nil || thresh != 4.1 {
+		t.Errorf("Entry.Threshold()=%v, %v, want 4.1, nil", thresh, err)
+	}
+	if in, err := e.InFields(); err != nil || len(in) != 2 || in[0] != "linterp" || in[1] != "mult" {
+		t.Errorf("Entry.InFields()=%v, %v, want [linterp mult], nil", in, err)
+	}
+
+	// CONST and CARRAY
+	e, err = d.Entry("const")
+	if err != nil {
+		t.Fatal("Could not get Entry for const type:", err)
+	}
+	if ct, err := e.ConstType(); err != nil || ct != FLOAT64 {
+		t.Errorf("Entry.ConstType()=0x%x, %v, want FLOAT64=0x%x, nil", ct, err, FLOAT64)
+	}
+	e, err = d.Entry("carray")
+	if err != nil {
+		t.Fatal("Could not get Entry for carray type:", err)
+	}
+	if n, err := e.ArrayLen(); err != nil || n != 6 {
+		t.Errorf("Entry.ArrayLen()=%d, %v, want 6, nil", n, err)
+	}
+	if _, err := e.SPF(); err == nil {
+		t.Error("Entry.SPF() on a CARRAY entry did not return error")
+	}
+}
+
+func TestEntryFragment(t *testing.T) {
+	dir := "dirfile_entry_fragment"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Close()
+	idx, err := d.Include("form2", 0)
+	if err != nil {
+		t.Fatal("Could not Include(\"form2\"):", err)
+	}
+	e, err := d.Entry("const2")
+	if err != nil {
+		t.Fatal("Could not get Entry for const2:", err)
+	}
+	if e.Fragment() != idx {
+		t.Errorf("Entry.Fragment()=%d, want %d", e.Fragment(), idx)
+	}
+}
+
+func TestAddEntry(t *testing.T) {
+	dir := "dirfile_entry"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+	copydir := "dirfile_copy"
+	defer removeTestDirfile(copydir)
+
+	src, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-only:", err)
+	}
+	defer src.Close()
+	dst, err := OpenDirfile(copydir, RDWR|CREAT|TRUNC)
+	if err != nil {
+		t.Fatal("Could not create dirfile:", err)
+	}
+	defer dst.Close()
+
+	// Copy entries verbatim from one dirfile to another
+	fields := []string{"data", "lincom", "const", "carray", "linterp", "polynom", "bit",
+		"sbit", "mplex", "mult", "div", "recip", "phase", "window", "string", "sarray",
+		"indir", "sindir"}
+	for _, f := range fields {
+		e, err := src.Entry(f)
+		if err != nil {
+			t.Errorf("Could not read Entry %s: %v", f, err)
+			continue
+		}
+		if err = dst.AddEntry(&e); err != nil {
+			t.Errorf("Could not AddEntry %s: %v", f, err)
+			continue
+		}
+		if et := dst.EntryType(f); et != e.Type() {
+			t.Errorf("Copied entry %s has type 0x%x, want 0x%x", f, et, e.Type())
+		}
+	}
+	if c, err := dst.GetConstantFloat64("const"); err != nil || c != 5.5 {
+		t.Errorf("Copied const has value %f, %v, want 5.5, nil", c, err)
+	}
+	if s, err := dst.GetString("string"); err != nil || s != "Zaphod Beeblebrox" {
+		t.Errorf("Copied string has value %q, %v, want \"Zaphod Beeblebrox\", nil", s, err)
+	}
+	if sa, err := dst.GetSarray("sarray"); err != nil || len(sa) != 7 || sa[6] != "seven" {
+		t.Errorf("Copied sarray has value %v, %v, want 7 values ending in \"seven\"", sa, err)
+	}
+	if e, err := dst.Entry("lincom"); err != nil {
+		t.Error("Could not read copied lincom:", err)
+	} else if cb, err := e.COffset(1); err != nil || cb != complex(3.3, 4.4) {
+		t.Errorf("Copied lincom has COffset(1)=%f, %v, want (3.3+4.4i), nil", cb, err)
+	} else if code, ind, _ := e.Scalar(2); code != "const" || ind != -1 {
+		t.Errorf("Copied lincom has Scalar(2)=%q, %d, want \"const\", -1", code, ind)
+	}
+	if e, err := dst.Entry("polynom"); err != nil {
+		t.Error("Could not read copied polynom:", err)
+	} else if code, _, _ := e.Scalar(5); code != "const" {
+		t.Errorf("Copied polynom has Scalar(5)=%q, want \"const\"", code)
+	}
+
+	// Add entries created by the constructors
+	entries := []Entry{
+		LincomEntry("c_lincom", []string{"data", "bit"}, []float64{1, 2}, []float64{3, 4}, 0),
+		CPolynomEntry("c_cpolynom", "data", []complex128{1, complex(2, 3)}, 0),
+		CRecipEntry("c_crecip", "data", complex(1, 2), 0),
+		WindowEntry("c_window", "data", "bit", WINDOPSET, 0x10, 0),
+		ConstEntry("c_const", INT32, int32(-17), 0),
+		CarrayEntry("c_carray", FLOAT32, []float64{1.5, 2.5, 3.5}, 0),
+		StringEntry("c_string", "value", 0),
+		SarrayEntry("c_sarray", []string{"a", "b"}, 0),
+		AliasEntry("c_alias", "c_lincom", 0),
+	}
+	for i := range entries {
+		if err = dst.AddEntry(&entries[i]); err != nil {
+			t.Errorf("Could not AddEntry %s: %v", entries[i].Name(), err)
+		}
+	}
+	if e, err := dst.Entry("c_window"); err != nil {
+		t.Error("Could not read c_window:", err)
+	} else if thresh, err := e.Threshold(); err != nil || thresh != uint64(0x10) {
+		t.Errorf("c_window has Threshold()=%v, %v, want 0x10, nil", thresh, err)
+	}
+	if e, err := dst.Entry("c_cpolynom"); err != nil {
+		t.Error("Could not read c_cpolynom:", err)
+	} else if a, err := e.CPolyCoefficients(); err != nil || len(a) != 2 || a[1] != complex(2, 3) {
+		t.Errorf("c_cpolynom has CPolyCoefficients()=%v, %v, want [1, (2+3i)], nil", a, err)
+	}
+	if c, err := dst.GetConstantInt32("c_const"); err != nil || c != -17 {
+		t.Errorf("c_const has value %d, %v, want -17, nil", c, err)
+	}
+	if n := dst.ArrayLen("c_carray"); n != 3 {
+		t.Errorf("c_carray has ArrayLen=%d, want 3", n)
+	}
+	if n := dst.NAliases("c_lincom"); n != 2 {
+		t.Errorf("c_lincom has NAliases=%d, want 2", n)
+	}
+}
+
+func TestAlterEntry(t *testing.T) {
+	dir := "dirfile_alter"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Discard()
+
+	e, err := d.Entry("data")
+	if err != nil {
+		t.Fatal("Could not get Entry for raw type:", err)
+	}
+	if err = e.SetSPF(4, true); err != nil {
+		t.Error("Entry.SetSPF() failed:", err)
+	}
+	if err = e.SetDataType(INT16, true); err != nil {
+		t.Error("Entry.SetDataType() failed:", err)
+	}
+	if e, err = d.Entry("data"); err != nil {
+		t.Fatal("Could not re-read Entry for raw type:", err)
+	}
+	if spf, _ := e.SPF(); spf != 4 {
+		t.Errorf("After SetSPF, SPF()=%d, want 4", spf)
+	}
+	if dt, _ := e.DataType(); dt != INT16 {
+		t.Errorf("After SetDataType, DataType()=0x%x, want INT16=0x%x", dt, INT16)
+	}
+	if err = e.SetShift(3); err == nil {
+		t.Error("Entry.SetShift() on a RAW entry did not return error")
+	}
+
+	e, _ = d.Entry("bit")
+	if err = e.SetBitRange(2, 5); err != nil {
+		t.Error("Entry.SetBitRange() failed:", err)
+	}
+	if err = e.SetInFields("sbit"); err != nil {
+		t.Error("Entry.SetInFields() failed:", err)
+	}
+	if err = e.SetInFields("sbit", "data"); err == nil {
+		t.Error("Entry.SetInFields() with too many inputs did not return error")
+	}
+	e, _ = d.Entry("bit")
+	if bitnum, numbits, _ := e.BitRange(); bitnum != 2 || numbits != 5 {
+		t.Errorf("After SetBitRange, BitRange()=%d,%d, want 2,5", bitnum, numbits)
+	}
+	if in, _ := e.InFields(); len(in) != 1 || in[0] != "sbit" {
+		t.Errorf("After SetInFields, InFields()=%v, want [sbit]", in)
+	}
+
+	// Changing other parameters keeps those given as CONST field codes
+	e, _ = d.Entry("polynom")
+	if err = e.SetInFields("sbit"); err != nil {
+		t.Error("Entry.SetInFields() failed:", err)
+	}
+	e, _ = d.Entry("polynom")
+	if code, ind, _ := e.Scalar(5); code != "const" || ind != -1 {
+		t.Errorf("After SetInFields, Scalar(5)=%q,%d, want \"const\",-1", code, ind)
+	}
+	if err = e.SetPolyCoefficients([]float64{1, 2}); err != nil {
+		t.Error("Entry.SetPolyCoefficients() failed:", err)
+	}
+	e, _ = d.Entry("polynom")
+	if code, _, _ := e.Scalar(0); code != "" {
+		t.Errorf("After SetPolyCoefficients, Scalar(0)=%q, want \"\"", code)
+	}
+
+	e, _ = d.Entry("lincom")
+	if err = e.SetLincom([]string{"data", "bit"}, []float64{2, 3}, []float64{-1, 1}); err != nil {
+		t.Error("Entry.SetLincom() failed:", err)
+	}
+	e, _ = d.Entry("lincom")
+	if n, _ := e.NFields(); n != 2 || e.ComplexScalars() {
+		t.Errorf("After SetLincom, NFields()=%d, ComplexScalars()=%t, want 2, false", n, e.ComplexScalars())
+	}
+	if m, _ := e.Scale(1); m != 3 {
+		t.Errorf("After SetLincom, Scale(1)=%f, want 3", m)
+	}
+
+	bad := map[string]error{
+		"SetLincom":            e.SetLincom([]string{"data", "bit", "sbit", "mult"}, make([]float64, 4), make([]float64, 4)),
+		"SetCLincom":           e.SetCLincom([]string{"data", "bit"}, []complex128{1}, []complex128{0, 1}),
+		"SetPolyCoefficients":  e.SetPolyCoefficients(nil),
+		"SetCPolyCoefficients": e.SetCPolyCoefficients(make([]complex128, MAXPOLYORD+2)),
+		"AlterLincom":          d.AlterLincom("lincom", []string{"data"}, []float64{1, 2}, []float64{0}),
+		"AlterPolynom":         d.AlterPolynom("polynom", "data", make([]float64, MAXPOLYORD+2)),
+	}
+	for op, err := range bad {
+		if !errors.Is(err, ErrArgument) {
+			t.Errorf("%s with bad lengths returned %v, want ErrArgument", op, err)
+		}
+	}
+	if err = d.AlterLincom("lincom", nil, nil, nil); err != nil {
+		t.Error("AlterLincom leaving the inputs unchanged failed:", err)
+	}
+
+	e, _ = d.Entry("polynom")
+	if err = e.SetCPolyCoefficients([]complex128{1, complex(0, 2)}); err != nil {
+		t.Error("Entry.SetCPolyCoefficients() failed:", err)
+	}
+	e, _ = d.Entry("polynom")
+	if a, _ := e.CPolyCoefficients(); len(a) != 2 || a[1] != complex(0, 2) {
+		t.Errorf("After SetCPolyCoefficients, CPolyCoefficients()=%v, want [1 2i]", a)
+	}
+
+	e, _ = d.Entry("phase")
+	if err = e.SetShift(-4); err != nil {
+		t.Error("Entry.SetShift() failed:", err)
+	}
+	e, _ = d.Entry("recip")
+	if err = e.SetDividend(2.5); err != nil {
+		t.Error("Entry.SetDividend() failed:", err)
+	}
+	e, _ = d.Entry("mplex")
+	if err = e.SetMplex(2, 5); err != nil {
+		t.Error("Entry.SetMplex() failed:", err)
+	}
+	e, _ = d.Entry("window")
+	if err = e.SetWindow(WINDOPSET, uint32(0x10)); err != nil {
+		t.Error("Entry.SetWindow() failed:", err)
+	}
+	e, _ = d.Entry("carray")
+	if err = e.SetArrayLen(4); err != nil {
+		t.Error("Entry.SetArrayLen() failed:", err)
+	}
+	if err = e.SetConstType(INT32); err != nil {
+		t.Error("Entry.SetConstType() failed:", err)
+	}
+
+	if e, _ = d.Entry("phase"); e.phaseShift != -4 {
+		t.Errorf("After SetShift, Shift()=%d, want -4", e.phaseShift)
+	}
+	if e, _ = d.Entry("recip"); e.ComplexScalars() || e.dividend != 2.5 {
+		t.Errorf("After SetDividend, Dividend()=%f, ComplexScalars()=%t, want 2.5, false",
+			e.dividend, e.ComplexScalars())
+	}
+	if e, _ = d.Entry("mplex"); e.countVal != 2 || e.period != 5 {
+		t.Errorf("After SetMplex, count,period=%d,%d, want 2,5", e.countVal, e.period)
+	}
+	if th, _ := d.Entry("window"); th.windOp != WINDOPSET || th.uThreshold != 0x10 {
+		t.Errorf("After SetWindow, op,threshold=%d,%d, want %d,16", th.windOp, th.uThreshold, WINDOPSET)
+	}
+	if n := d.ArrayLen("carray"); n != 4 {
+		t.Errorf("After SetArrayLen, ArrayLen()=%d, want 4", n)
+	}
+
+	// Spec lines
+	if err = d.AlterSpec("const CONST INT64 0", false); err != nil {
+		t.Error("Dirfile.AlterSpec() failed:", err)
+	}
+	if e, _ = d.Entry("const"); e.constType != INT64 {
+		t.Errorf("After AlterSpec, ConstType()=0x%x, want INT64=0x%x", e.constType, INT64)
+	}
+	if err = d.MAlterSpec("mconst CONST FLOAT32 0", "data", false); err != nil {
+		t.Error("Dirfile.MAlterSpec() failed:", err)
+	}
+	if e, _ = d.Entry("data/mconst"); e.constType != FLOAT32 {
+		t.Errorf("After MAlterSpec, ConstType()=0x%x, want FLOAT32=0x%x", e.constType, FLOAT32)
+	}
+
+	// AlterEntry requires a matching field type
+	bit := BitEntry("x", "data", 1, 1, 0)
+	if err = d.AlterEntry("phase", &bit, false); err == nil {
+		t.Error("Dirfile.AlterEntry() with mismatched type did not return error")
+	}
+}
+
+func TestErrorOp(t *testing.T) {
+	dir := "dirfile_error_op"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Close()
+
+	// Errors from the C library should name the method that failed
+	ops := map[string]error{
+		"AddConst":   d.AddConst("data", FLOAT64, 1.0, 0),
+		"AddCarray":  d.AddCarray("data", FLOAT64, []float64{1}, 0),
+		"AddLincom":  d.AddLincom("data", []string{"data"}, []float64{1}, []float64{0}, 0),
+		"AddCLincom": d.AddCLincom("data", []string{"data"}, []complex128{1}, []complex128{0}, 0),
+		"AddMplex":   d.AddMplex("data", "data", "data", 1, 2, 0),
+		"AddWindow":  d.AddWindow("data", "data", "data", WINDOPEQ, int64(1), 0),
+	}
+	for op, err := range ops {
+		var gderr *Error
+		if !errors.As(err, &gderr) || gderr.Op != op || gderr.Field != "data" {
+			t.Errorf("%s of a duplicate field returned %v, want an *Error from %s", op, err, op)
+		}
+	}
+
+	// Go-side validation errors should be *Error too
+	if err = d.AddLincom("lin", []string{"data"}, []float64{1, 2}, []float64{0}, 0); !errors.Is(err, ErrArgument) {
+		t.Errorf("AddLincom with mismatched lengths returned %v, want ErrArgument", err)
+	}
+	var out int
+	if _, err = d.GetData("data", 0, 0, 1, 0, out); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetData into a non-pointer returned %v, want ErrArgument", err)
+	}
+	e, err := d.Entry("data")
+	if err != nil {
+		t.Fatal("Could not get Entry for data:", err)
+	}
+	if _, err = e.InFields(); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("RAW Entry.InFields returned %v, want ErrBadFieldType", err)
+	}
+}
@@ -0,0 +1,50 @@
+package getdata
+
+import "fmt"
+
+// ErrorCode is a GetData error code, as returned by the C API gd_error. Each
+// non-zero ErrorCode is itself an error, so the exported values (ErrBadCode,
+// etc.) can be used as sentinels with errors.Is.
+type ErrorCode int
+
+// Error returns the name of the C API error code, e.g. "GD_E_BAD_CODE"
+func (c ErrorCode) Error() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("GetData error %d", int(c))
+}
+
+// Error is the error type returned by operations on a Dirfile. It records the
+// GetData error code along with the operation and field code (if any) involved.
+type Error struct {
+	Code  ErrorCode // the GetData error code
+	Op    string    // the operation that failed, e.g. "GetData"
+	Field string    // the field code involved, or "" if none
+	Msg   string    // the description generated by the GetData library, or by package pure
+}
+
+// Error returns the description generated by the GetData library. This already
+// names the field involved (if any), so the operation is not repeated here.
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// Unwrap returns the ErrorCode, so that errors.Is(err, ErrBadCode) etc. work.
+func (e *Error) Unwrap() error {
+	if e.Code == 0 {
+		return nil
+	}
+	return e.Code
+}
+
+// newError returns an *Error with the given code for a failure detected in Go,
+// before (or without) calling the C library
+func newError(code ErrorCode, op, fieldcode, format string, args ...interface{}) error {
+	return &Error{Code: code, Op: op, Field: fieldcode, Msg: fmt.Sprintf(format, args...)}
+}
+
+// errZeroLength is the error returned when asked to read data into no space
+func errZeroLength(op, fieldcode string) error {
+	return newError(ErrArgument, op, fieldcode, "Zero-length data request for field %s", fieldcode)
+}
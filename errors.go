@@ -0,0 +1,131 @@
+//go:build !purego
+
+package getdata
+
+/*
+#include <getdata.h>
+*/
+import "C"
+
+// ErrFormat indicates a syntax error in a format file
+const ErrFormat ErrorCode = C.GD_E_FORMAT
+
+// ErrCreat indicates that a dirfile could not be created
+const ErrCreat ErrorCode = C.GD_E_CREAT
+
+// ErrBadCode indicates a field code that was not found in the dirfile
+const ErrBadCode ErrorCode = C.GD_E_BAD_CODE
+
+// ErrBadType indicates an invalid data type
+const ErrBadType ErrorCode = C.GD_E_BAD_TYPE
+
+// ErrIO indicates an I/O error while accessing a file on disk
+const ErrIO ErrorCode = C.GD_E_IO
+
+// ErrInternal indicates an internal error in the GetData library
+const ErrInternal ErrorCode = C.GD_E_INTERNAL_ERROR
+
+// ErrAlloc indicates a failed memory allocation
+const ErrAlloc ErrorCode = C.GD_E_ALLOC
+
+// ErrRange indicates a request for data outside the available range
+const ErrRange ErrorCode = C.GD_E_RANGE
+
+// ErrLUT indicates a malformed LINTERP look-up table
+const ErrLUT ErrorCode = C.GD_E_LUT
+
+// ErrRecurseLevel indicates too many levels of recursion in field definitions
+const ErrRecurseLevel ErrorCode = C.GD_E_RECURSE_LEVEL
+
+// ErrBadDirfile indicates an invalid Dirfile object
+const ErrBadDirfile ErrorCode = C.GD_E_BAD_DIRFILE
+
+// ErrBadFieldType indicates an operation not allowed on fields of this type
+const ErrBadFieldType ErrorCode = C.GD_E_BAD_FIELD_TYPE
+
+// ErrAccMode indicates a write attempted on a dirfile opened read-only
+const ErrAccMode ErrorCode = C.GD_E_ACCMODE
+
+// ErrUnsupported indicates an operation not supported by the encoding
+const ErrUnsupported ErrorCode = C.GD_E_UNSUPPORTED
+
+// ErrUnknownEncoding indicates that the encoding of a file could not be determined
+const ErrUnknownEncoding ErrorCode = C.GD_E_UNKNOWN_ENCODING
+
+// ErrBadEntry indicates invalid field metadata
+const ErrBadEntry ErrorCode = C.GD_E_BAD_ENTRY
+
+// ErrDuplicate indicates a field code that already exists
+const ErrDuplicate ErrorCode = C.GD_E_DUPLICATE
+
+// ErrDimension indicates a scalar field used where a vector was expected, or vice versa
+const ErrDimension ErrorCode = C.GD_E_DIMENSION
+
+// ErrBadIndex indicates an invalid fragment index
+const ErrBadIndex ErrorCode = C.GD_E_BAD_INDEX
+
+// ErrBadScalar indicates a field parameter given by a missing or invalid scalar field
+const ErrBadScalar ErrorCode = C.GD_E_BAD_SCALAR
+
+// ErrBadReference indicates an invalid reference field
+const ErrBadReference ErrorCode = C.GD_E_BAD_REFERENCE
+
+// ErrProtected indicates an operation prohibited by fragment protection
+const ErrProtected ErrorCode = C.GD_E_PROTECTED
+
+// ErrDelete indicates a field that could not be deleted
+const ErrDelete ErrorCode = C.GD_E_DELETE
+
+// ErrArgument indicates an invalid function argument
+const ErrArgument ErrorCode = C.GD_E_ARGUMENT
+
+// ErrCallback indicates an invalid return value from a parser callback
+const ErrCallback ErrorCode = C.GD_E_CALLBACK
+
+// ErrExists indicates that a dirfile already exists
+const ErrExists ErrorCode = C.GD_E_EXISTS
+
+// ErrUncleanDB indicates an error that may have left the database in an unclean state
+const ErrUncleanDB ErrorCode = C.GD_E_UNCLEAN_DB
+
+// ErrDomain indicates an improper domain
+const ErrDomain ErrorCode = C.GD_E_DOMAIN
+
+// ErrBounds indicates a CARRAY access out of bounds
+const ErrBounds ErrorCode = C.GD_E_BOUNDS
+
+// ErrLineTooLong indicates a format file line that is too long
+const ErrLineTooLong ErrorCode = C.GD_E_LINE_TOO_LONG
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrFormat:          "GD_E_FORMAT",
+	ErrCreat:           "GD_E_CREAT",
+	ErrBadCode:         "GD_E_BAD_CODE",
+	ErrBadType:         "GD_E_BAD_TYPE",
+	ErrIO:              "GD_E_IO",
+	ErrInternal:        "GD_E_INTERNAL_ERROR",
+	ErrAlloc:           "GD_E_ALLOC",
+	ErrRange:           "GD_E_RANGE",
+	ErrLUT:             "GD_E_LUT",
+	ErrRecurseLevel:    "GD_E_RECURSE_LEVEL",
+	ErrBadDirfile:      "GD_E_BAD_DIRFILE",
+	ErrBadFieldType:    "GD_E_BAD_FIELD_TYPE",
+	ErrAccMode:         "GD_E_ACCMODE",
+	ErrUnsupported:     "GD_E_UNSUPPORTED",
+	ErrUnknownEncoding: "GD_E_UNKNOWN_ENCODING",
+	ErrBadEntry:        "GD_E_BAD_ENTRY",
+	ErrDuplicate:       "GD_E_DUPLICATE",
+	ErrDimension:       "GD_E_DIMENSION",
+	ErrBadIndex:        "GD_E_BAD_INDEX",
+	ErrBadScalar:       "GD_E_BAD_SCALAR",
+	ErrBadReference:    "GD_E_BAD_REFERENCE",
+	ErrProtected:       "GD_E_PROTECTED",
+	ErrDelete:          "GD_E_DELETE",
+	ErrArgument:        "GD_E_ARGUMENT",
+	ErrCallback:        "GD_E_CALLBACK",
+	ErrExists:          "GD_E_EXISTS",
+	ErrUncleanDB:       "GD_E_UNCLEAN_DB",
+	ErrDomain:          "GD_E_DOMAIN",
+	ErrBounds:          "GD_E_BOUNDS",
+	ErrLineTooLong:     "GD_E_LINE_TOO_LONG",
+}
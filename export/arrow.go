@@ -0,0 +1,323 @@
+//go:build !purego
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/format"
+)
+
+// arrowTypes are the Arrow data types matching each RetType; the real and
+// imaginary parts of complex types are separate columns
+var arrowTypes = map[getdata.RetType]arrow.DataType{
+	getdata.UINT8: arrow.PrimitiveTypes.Uint8, getdata.INT8: arrow.PrimitiveTypes.Int8,
+	getdata.UINT16: arrow.PrimitiveTypes.Uint16, getdata.INT16: arrow.PrimitiveTypes.Int16,
+	getdata.UINT32: arrow.PrimitiveTypes.Uint32, getdata.INT32: arrow.PrimitiveTypes.Int32,
+	getdata.UINT64: arrow.PrimitiveTypes.Uint64, getdata.INT64: arrow.PrimitiveTypes.Int64,
+	getdata.FLOAT32: arrow.PrimitiveTypes.Float32, getdata.FLOAT64: arrow.PrimitiveTypes.Float64,
+	getdata.COMPLEX64: arrow.PrimitiveTypes.Float32, getdata.COMPLEX128: arrow.PrimitiveTypes.Float64,
+}
+
+// timeType is the Arrow type of the time column
+var timeType = &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}
+
+// ArrowType returns the Arrow data type holding values of type t, or their real
+// and imaginary parts if t is complex
+func ArrowType(t getdata.RetType) (arrow.DataType, error) {
+	if dt, ok := arrowTypes[t]; ok {
+		return dt, nil
+	}
+	return nil, fmt.Errorf("No Arrow type for data type 0x%x", t)
+}
+
+// NewArrowSchema returns the Arrow schema of the records that ArrowRecords
+// returns for opts: the time (as a timestamp in nanoseconds, UTC) and frame
+// number columns if requested, then one column per field, or two for complex
+// fields, named as by Write. Each field's column metadata holds its CONST and
+// STRING metafields (e.g. units or calibrations), and the schema metadata
+// records where the fields came from, with keys getdata.dirfile,
+// getdata.first_frame and getdata.spf, and getdata.field.NAME.fragment, .spf,
+// .entry_type and .native_type for each field NAME.
+func NewArrowSchema(df getdata.ReadOnly, opts Options) (*arrow.Schema, error) {
+	fields, err := SelectFields(df, opts.Fields, opts.Match)
+	if err != nil {
+		return nil, err
+	}
+	spf := opts.SPF
+	if spf <= 0 {
+		ref, err := df.GetReference()
+		if err != nil {
+			return nil, err
+		}
+		spf = df.SPF(ref.Name())
+	}
+	meta := map[string]string{
+		"getdata.dirfile":     df.Dirfilename(),
+		"getdata.first_frame": strconv.Itoa(opts.FirstFrame),
+		"getdata.spf":         strconv.Itoa(spf),
+	}
+	var columns []arrow.Field
+	if opts.TimeField != "" {
+		columns = append(columns, arrow.Field{Name: "time", Type: timeType, Nullable: true})
+		meta["getdata.time_field"] = opts.TimeField
+	}
+	if opts.FrameColumn {
+		columns = append(columns, arrow.Field{Name: "frame", Type: arrow.PrimitiveTypes.Float64})
+	}
+
+	for _, field := range fields {
+		t := df.NativeType(field)
+		dt, err := ArrowType(t)
+		if err != nil {
+			return nil, fmt.Errorf("Field %s: %v", field, err)
+		}
+		fragment, err := df.FragmentIndex(field)
+		if err != nil {
+			return nil, err
+		}
+		prefix := "getdata.field." + field + "."
+		meta[prefix+"fragment"] = strconv.Itoa(fragment)
+		meta[prefix+"spf"] = strconv.Itoa(df.SPF(field))
+		meta[prefix+"entry_type"] = format.EntryType(df.EntryType(field)).String()
+		meta[prefix+"native_type"] = format.RetType(t).String()
+
+		fieldMeta, err := FieldMetadata(df, field)
+		if err != nil {
+			return nil, err
+		}
+		md := arrow.MetadataFrom(fieldMeta)
+		if t == getdata.COMPLEX64 || t == getdata.COMPLEX128 {
+			columns = append(columns,
+				arrow.Field{Name: field + ".r", Type: dt, Metadata: md},
+				arrow.Field{Name: field + ".i", Type: dt, Metadata: md})
+		} else {
+			columns = append(columns, arrow.Field{Name: field, Type: dt, Metadata: md})
+		}
+	}
+	md := arrow.MetadataFrom(meta)
+	return arrow.NewSchema(columns, &md), nil
+}
+
+// FieldMetadata returns the values of the CONST and STRING metafields of field,
+// keyed by metafield name. Complex constants are formatted as by
+// strconv.FormatComplex, unless their imaginary part is zero.
+func FieldMetadata(df getdata.ReadOnly, field string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, name := range df.MFieldList(field) {
+		name = strings.TrimPrefix(name, field+"/")
+		code := field + "/" + name
+		switch df.EntryType(code) {
+		case getdata.STRINGENTRY:
+			s, err := df.GetString(code)
+			if err != nil {
+				return nil, err
+			}
+			meta[name] = s
+		case getdata.CONSTENTRY:
+			var v complex128
+			if err := df.GetConstant(code, &v); err != nil {
+				return nil, err
+			}
+			if imag(v) == 0 {
+				meta[name] = strconv.FormatFloat(real(v), 'g', -1, 64)
+			} else {
+				meta[name] = strconv.FormatComplex(v, 'g', -1, 128)
+			}
+		}
+	}
+	return meta, nil
+}
+
+// ArrowRecords returns an iterator over the frames selected by opts as Arrow
+// record batches of schema, one per chunk read by Chunks, allocated from mem.
+// The caller must Release each record. Iteration stops after an error.
+func ArrowRecords(df getdata.ReadOnly, opts Options, schema *arrow.Schema,
+	mem memory.Allocator) iter.Seq2[arrow.RecordBatch, error] {
+	return func(yield func(arrow.RecordBatch, error) bool) {
+		b := array.NewRecordBuilder(mem, schema)
+		defer b.Release()
+		for chunk, err := range Chunks(df, opts) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if err = appendChunk(b, chunk, opts); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(b.NewRecordBatch(), nil) {
+				return
+			}
+		}
+	}
+}
+
+// appendChunk appends the rows of chunk to the columns of b, in the order
+// given by NewArrowSchema
+func appendChunk(b *array.RecordBuilder, chunk Chunk, opts Options) error {
+	nrows := chunk.NFrames * chunk.SPF
+	col := 0
+	if opts.TimeField != "" {
+		tb := b.Field(col).(*array.TimestampBuilder)
+		tb.Reserve(nrows)
+		for _, s := range chunk.Times[:nrows] {
+			if math.IsNaN(s) {
+				tb.AppendNull()
+				continue
+			}
+			tb.Append(arrow.Timestamp(math.Round(s * 1e9)))
+		}
+		col++
+	}
+	if opts.FrameColumn {
+		fb := b.Field(col).(*array.Float64Builder)
+		fb.Reserve(nrows)
+		for row := 0; row < nrows; row++ {
+			fb.Append(float64(chunk.FirstFrame) + float64(row)/float64(chunk.SPF))
+		}
+		col++
+	}
+	for _, c := range chunk.Columns {
+		n, err := appendColumn(b.Fields()[col:], c.Field, c.Data)
+		if err != nil {
+			return err
+		}
+		col += n
+	}
+	return nil
+}
+
+// appendColumn appends data, a numeric slice, to the builder of its column (or
+// the two builders of a complex column) at the start of builders, and returns
+// the number of builders used
+func appendColumn(builders []array.Builder, field string, data interface{}) (int, error) {
+	ok := true
+	switch d := data.(type) {
+	case []uint8:
+		ok = appendValues[*array.Uint8Builder](builders[0], d)
+	case []int8:
+		ok = appendValues[*array.Int8Builder](builders[0], d)
+	case []uint16:
+		ok = appendValues[*array.Uint16Builder](builders[0], d)
+	case []int16:
+		ok = appendValues[*array.Int16Builder](builders[0], d)
+	case []uint32:
+		ok = appendValues[*array.Uint32Builder](builders[0], d)
+	case []int32:
+		ok = appendValues[*array.Int32Builder](builders[0], d)
+	case []uint64:
+		ok = appendValues[*array.Uint64Builder](builders[0], d)
+	case []int64:
+		ok = appendValues[*array.Int64Builder](builders[0], d)
+	case []float32:
+		ok = appendValues[*array.Float32Builder](builders[0], d)
+	case []float64:
+		ok = appendValues[*array.Float64Builder](builders[0], d)
+	case []complex64:
+		re, im := make([]float32, len(d)), make([]float32, len(d))
+		for i, v := range d {
+			re[i], im[i] = real(v), imag(v)
+		}
+		ok = len(builders) > 1 && appendValues[*array.Float32Builder](builders[0], re) &&
+			appendValues[*array.Float32Builder](builders[1], im)
+		return 2, checkAppended(ok, field, data)
+	case []complex128:
+		re, im := make([]float64, len(d)), make([]float64, len(d))
+		for i, v := range d {
+			re[i], im[i] = real(v), imag(v)
+		}
+		ok = len(builders) > 1 && appendValues[*array.Float64Builder](builders[0], re) &&
+			appendValues[*array.Float64Builder](builders[1], im)
+		return 2, checkAppended(ok, field, data)
+	default:
+		ok = false
+	}
+	return 1, checkAppended(ok, field, data)
+}
+
+// appendValues appends values to b, returning false if b is not a B
+func appendValues[B interface{ AppendValues([]T, []bool) }, T any](b array.Builder, values []T) bool {
+	typed, ok := b.(B)
+	if ok {
+		typed.AppendValues(values, nil)
+	}
+	return ok
+}
+
+// checkAppended returns the error for data that did not match its column
+func checkAppended(ok bool, field string, data interface{}) error {
+	if !ok {
+		return fmt.Errorf("Cannot export field %s of type %T to its Arrow column", field, data)
+	}
+	return nil
+}
+
+// WriteArrow writes the fields selected by opts to w as an Arrow IPC stream of
+// record batches, one per chunk, with the schema given by NewArrowSchema.
+// Writing stops early, without error, at the end of the shortest field.
+func WriteArrow(w io.Writer, df getdata.ReadOnly, opts Options) error {
+	schema, err := NewArrowSchema(df, opts)
+	if err != nil {
+		return err
+	}
+	mem := memory.NewGoAllocator()
+	out := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+	for rec, err := range ArrowRecords(df, opts, schema, mem) {
+		if err != nil {
+			out.Close()
+			return err
+		}
+		err = out.Write(rec)
+		rec.Release()
+		if err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// WriteParquet writes the fields selected by opts to w as a Parquet file, one
+// row group per chunk, with the schema given by NewArrowSchema. The Arrow
+// schema, with its metadata, is stored in the file so that Arrow readers
+// recover it. Writing stops early, without error, at the end of the shortest
+// field.
+func WriteParquet(w io.Writer, df getdata.ReadOnly, opts Options) error {
+	schema, err := NewArrowSchema(df, opts)
+	if err != nil {
+		return err
+	}
+	mem := memory.NewGoAllocator()
+	out, err := pqarrow.NewFileWriter(schema, w, parquet.NewWriterProperties(parquet.WithAllocator(mem)),
+		pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema(), pqarrow.WithAllocator(mem)))
+	if err != nil {
+		return err
+	}
+	for rec, err := range ArrowRecords(df, opts, schema, mem) {
+		if err != nil {
+			out.Close()
+			return err
+		}
+		err = out.Write(rec)
+		rec.Release()
+		if err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
@@ -0,0 +1,272 @@
+//go:build !purego
+
+// Package export writes the vector fields of a dirfile (any getdata.ReadOnly,
+// such as a Dirfile or FSDirfile) as delimited text (CSV or TSV), one row per
+// sample, or as Arrow record batches or Parquet, with the fields aligned in
+// columns by getdata.ReadFrames. Frames are read a chunk at a time, so a range
+// of any length can be written in bounded memory.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/joefowler/gogetdata"
+)
+
+// DefaultChunkFrames is the number of frames read at a time when
+// Options.ChunkFrames is 0
+const DefaultChunkFrames = 1024
+
+// Options control what Write writes. Only one of Fields and Match is used.
+type Options struct {
+	Fields      []string // the fields to write, in order
+	Match       string   // if Fields is empty, a regular expression selecting vector fields
+	FirstFrame  int
+	NumFrames   int  // the number of frames to write, or 0 to write to the end of the dirfile
+	SPF         int  // rows per frame, or 0 for the reference field's samples per frame
+	Comma       rune // the field delimiter, or 0 for ','
+	FrameColumn bool // write the (fractional) frame number of each row first
+	TimeField   string
+	TimeLayout  string // the time.Time layout for the time column, or "" for RFC 3339
+	NoHeader    bool   // omit the header row of column names
+	ChunkFrames int    // frames read at a time, or 0 for DefaultChunkFrames
+}
+
+// Chunk is a range of frames of the fields selected by Options
+type Chunk struct {
+	*getdata.FrameTable
+	Times []float64 // UTC seconds since the Unix epoch of each row, if Options.TimeField is set
+}
+
+// Chunks returns an iterator over the frames selected by opts, a chunk of at
+// most opts.ChunkFrames frames at a time, for writing them in formats other
+// than text (e.g., one Arrow record batch per chunk). Iteration stops after an
+// error, or at the end of the shortest field, including the time field.
+func Chunks(df getdata.ReadOnly, opts Options) iter.Seq2[Chunk, error] {
+	return func(yield func(Chunk, error) bool) {
+		fields, err := SelectFields(df, opts.Fields, opts.Match)
+		if err != nil {
+			yield(Chunk{}, err)
+			return
+		}
+		numFrames := opts.NumFrames
+		if numFrames <= 0 {
+			numFrames = df.NFrames() - opts.FirstFrame
+		}
+		size := opts.ChunkFrames
+		if size <= 0 {
+			size = DefaultChunkFrames
+		}
+		end := opts.FirstFrame + numFrames
+		for frame := opts.FirstFrame; frame < end; frame += size {
+			n := min(size, end-frame)
+			table, err := getdata.ReadFrames(df, fields, frame, n, getdata.FrameOptions{SPF: opts.SPF})
+			if err != nil {
+				yield(Chunk{}, err)
+				return
+			}
+			chunk := Chunk{FrameTable: table}
+			if opts.TimeField != "" && table.NFrames > 0 {
+				tt, err := getdata.ReadFrames(df, []string{opts.TimeField}, frame, table.NFrames,
+					getdata.FrameOptions{SPF: table.SPF, Upsampling: getdata.UpsampleLinear, Type: getdata.FLOAT64})
+				if err != nil {
+					yield(Chunk{}, err)
+					return
+				}
+				chunk.Times = tt.Columns[0].Data.([]float64)
+				if tt.NFrames < table.NFrames {
+					// the time field ends first: keep only the rows it covers
+					table.NFrames = tt.NFrames
+					for i := range table.Columns {
+						c := &table.Columns[i]
+						c.Data = reflect.ValueOf(c.Data).Slice(0, table.NFrames*table.SPF).Interface()
+					}
+				}
+			}
+			if !yield(chunk, nil) || table.NFrames < n {
+				return
+			}
+		}
+	}
+}
+
+// Write writes the fields selected by opts to w, one row per sample. If
+// opts.TimeField is set, each row starts with the time read from that field
+// (UTC seconds since the Unix epoch, interpolated between samples), and if
+// opts.FrameColumn is set, with the frame number. Complex fields take two
+// columns, named with the Dirfile representation suffixes .r and .i. Writing
+// stops early, without error, at the end of the shortest field.
+func Write(w io.Writer, df getdata.ReadOnly, opts Options) error {
+	out := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		out.Comma = opts.Comma
+	}
+	layout := opts.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+
+	header := !opts.NoHeader
+	for chunk, err := range Chunks(df, opts) {
+		if err != nil {
+			return err
+		}
+		cols := make([]column, len(chunk.Columns))
+		width := len(cols)
+		for i, c := range chunk.Columns {
+			if cols[i], err = newColumn(c.Field, c.Data); err != nil {
+				return err
+			}
+			width += len(cols[i].names) - 1
+		}
+		if opts.FrameColumn {
+			width++
+		}
+		if opts.TimeField != "" {
+			width++
+		}
+		record := make([]string, 0, width)
+
+		if header {
+			if opts.TimeField != "" {
+				record = append(record, "time")
+			}
+			if opts.FrameColumn {
+				record = append(record, "frame")
+			}
+			for _, c := range cols {
+				record = append(record, c.names...)
+			}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+			header = false
+		}
+
+		for row := 0; row < chunk.NFrames*chunk.SPF; row++ {
+			record = record[:0]
+			if opts.TimeField != "" {
+				record = append(record, formatTime(chunk.Times[row], layout))
+			}
+			if opts.FrameColumn {
+				f := float64(chunk.FirstFrame) + float64(row)/float64(chunk.SPF)
+				record = append(record, strconv.FormatFloat(f, 'g', -1, 64))
+			}
+			for _, c := range cols {
+				record = c.format(record, row)
+			}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// SelectFields returns fields if it is not empty, or else the vector fields in
+// any fragment of the dirfile whose names match the regular expression match
+func SelectFields(df getdata.ReadOnly, fields []string, match string) ([]string, error) {
+	if len(fields) > 0 {
+		return fields, nil
+	}
+	if match == "" {
+		return nil, fmt.Errorf("Export needs a list of fields or a regular expression to match")
+	}
+	matched, err := df.MatchEntries(match, getdata.ALLFRAGMENTS, getdata.VECTORENTRIES, 0)
+	if err != nil {
+		return nil, err
+	}
+	matched = slices.DeleteFunc(matched, func(name string) bool { return name == "" })
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("No vector fields match %q", match)
+	}
+	return matched, nil
+}
+
+// formatTime formats s seconds since the Unix epoch as a UTC time, or NaN as ""
+func formatTime(s float64, layout string) string {
+	if math.IsNaN(s) {
+		return ""
+	}
+	sec, frac := math.Modf(s)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC().Format(layout)
+}
+
+// column formats the values of one field as one column, or two if complex
+type column struct {
+	names  []string
+	format func(record []string, row int) []string
+}
+
+// newColumn returns the column formatting data, which must be a numeric slice
+func newColumn(field string, data interface{}) (column, error) {
+	c := column{names: []string{field}}
+	switch d := data.(type) {
+	case []uint8:
+		c.format = formatUint(d)
+	case []uint16:
+		c.format = formatUint(d)
+	case []uint32:
+		c.format = formatUint(d)
+	case []uint64:
+		c.format = formatUint(d)
+	case []int8:
+		c.format = formatInt(d)
+	case []int16:
+		c.format = formatInt(d)
+	case []int32:
+		c.format = formatInt(d)
+	case []int64:
+		c.format = formatInt(d)
+	case []float32:
+		c.format = func(record []string, row int) []string {
+			return append(record, strconv.FormatFloat(float64(d[row]), 'g', -1, 32))
+		}
+	case []float64:
+		c.format = func(record []string, row int) []string {
+			return append(record, strconv.FormatFloat(d[row], 'g', -1, 64))
+		}
+	case []complex64:
+		c.names = []string{field + ".r", field + ".i"}
+		c.format = func(record []string, row int) []string {
+			return append(record, strconv.FormatFloat(float64(real(d[row])), 'g', -1, 32),
+				strconv.FormatFloat(float64(imag(d[row])), 'g', -1, 32))
+		}
+	case []complex128:
+		c.names = []string{field + ".r", field + ".i"}
+		c.format = func(record []string, row int) []string {
+			return append(record, strconv.FormatFloat(real(d[row]), 'g', -1, 64),
+				strconv.FormatFloat(imag(d[row]), 'g', -1, 64))
+		}
+	default:
+		return c, fmt.Errorf("Cannot export field %s of type %T", field, data)
+	}
+	return c, nil
+}
+
+// formatUint returns a column's format function for unsigned integers
+func formatUint[T uint8 | uint16 | uint32 | uint64](d []T) func([]string, int) []string {
+	return func(record []string, row int) []string {
+		return append(record, strconv.FormatUint(uint64(d[row]), 10))
+	}
+}
+
+// formatInt returns a column's format function for signed integers
+func formatInt[T int8 | int16 | int32 | int64](d []T) func([]string, int) []string {
+	return func(record []string, row int) []string {
+		return append(record, strconv.FormatInt(int64(d[row]), 10))
+	}
+}
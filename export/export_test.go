@@ -0,0 +1,301 @@
+//go:build !purego
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+func TestNewColumn(t *testing.T) {
+	for _, test := range []struct {
+		data  interface{}
+		names []string
+		want  []string
+	}{
+		{[]uint16{7, 65535}, []string{"f"}, []string{"65535"}},
+		{[]int8{3, -4}, []string{"f"}, []string{"-4"}},
+		{[]float32{1.5, 0.1}, []string{"f"}, []string{"0.1"}},
+		{[]float64{1, 1e-20}, []string{"f"}, []string{"1e-20"}},
+		{[]complex64{0, 2.5 - 1i}, []string{"f.r", "f.i"}, []string{"2.5", "-1"}},
+		{[]complex128{0, 1i}, []string{"f.r", "f.i"}, []string{"0", "1"}},
+	} {
+		c, err := newColumn("f", test.data)
+		if err != nil {
+			t.Errorf("newColumn(%T) failed: %v", test.data, err)
+			continue
+		}
+		if !slices.Equal(c.names, test.names) {
+			t.Errorf("newColumn(%T) names = %v, want %v", test.data, c.names, test.names)
+		}
+		if got := c.format([]string{"x"}, 1); !slices.Equal(got[1:], test.want) || got[0] != "x" {
+			t.Errorf("newColumn(%T) formats %v, want [x %v]", test.data, got, test.want)
+		}
+	}
+	if _, err := newColumn("f", []int{1}); err == nil {
+		t.Error("newColumn([]int) did not return error")
+	}
+	if s := formatTime(math.NaN(), time.RFC3339); s != "" {
+		t.Errorf("formatTime(NaN) = %q, want empty", s)
+	}
+	if s := formatTime(1025.5, "15:04:05.0"); s != "00:17:05.5" {
+		t.Errorf("formatTime(1025.5) = %q, want 00:17:05.5", s)
+	}
+}
+
+// createExportDirfile writes a dirfile of 3 frames: "data" holds 1, 2, ... at
+// 8 samples per frame, "slow" holds 10, 20, 30, and "time" is slow+1000. "data"
+// has metafields "units" and "gain".
+func createExportDirfile(t *testing.T) string {
+	dir := t.TempDir()
+	format := "/ENDIAN little\n/REFERENCE data\ndata RAW INT8 8\nslow RAW UINT16 1\ntime LINCOM slow 1 1000\n" +
+		"early RAW UINT16 1\netime LINCOM early 1 1000\n/META data units STRING V\n/META data gain CONST FLOAT64 2.5\n"
+	data := testdirfile.Data()[:24]
+	slow := binary.LittleEndian.AppendUint16(nil, 10)
+	slow = binary.LittleEndian.AppendUint16(slow, 20)
+	slow = binary.LittleEndian.AppendUint16(slow, 30)
+	early := slow[:4] // a time field ending before the others
+	for name, contents := range map[string][]byte{"format": []byte(format), "data": data, "slow": slow, "early": early} {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestWrite(t *testing.T) {
+	d, err := getdata.OpenDirfile(createExportDirfile(t), getdata.RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	var buf bytes.Buffer
+	opts := Options{Fields: []string{"data", "slow"}, FirstFrame: 1, SPF: 2,
+		FrameColumn: true, TimeField: "time", ChunkFrames: 1}
+	if err = Write(&buf, &d, opts); err != nil {
+		t.Fatal("Write failed:", err)
+	}
+	want := `time,frame,data,slow
+1970-01-01T00:17:00Z,1,9,20
+1970-01-01T00:17:05Z,1.5,13,20
+1970-01-01T00:17:10Z,2,17,30
+1970-01-01T00:17:10Z,2.5,21,30
+`
+	if buf.String() != want {
+		t.Errorf("Write wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	opts = Options{Match: "^(data|slow)$", NumFrames: 1, SPF: 1, Comma: '\t', NoHeader: true}
+	if err = Write(&buf, &d, opts); err != nil {
+		t.Fatal("Write of matched fields failed:", err)
+	}
+	if lines := strings.Split(buf.String(), "\n"); len(lines) != 2 ||
+		(lines[0] != "1\t10" && lines[0] != "10\t1") {
+		t.Errorf("Write of matched fields wrote %q", buf.String())
+	}
+
+	if err = Write(&buf, &d, Options{Match: "^nothing$"}); err == nil {
+		t.Error("Write with no matching fields did not return error")
+	}
+	if err = Write(&buf, &d, Options{}); err == nil {
+		t.Error("Write with no fields did not return error")
+	}
+}
+
+func TestChunks(t *testing.T) {
+	d, err := getdata.OpenDirfile(createExportDirfile(t), getdata.RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	var frames []int
+	for chunk, err := range Chunks(&d, Options{Fields: []string{"slow"}, TimeField: "time", ChunkFrames: 2}) {
+		if err != nil {
+			t.Fatal("Chunks failed:", err)
+		}
+		frames = append(frames, chunk.FirstFrame, chunk.NFrames)
+		if len(chunk.Times) != chunk.NFrames*chunk.SPF {
+			t.Errorf("Chunk at frame %d has %d times, want %d", chunk.FirstFrame, len(chunk.Times), chunk.NFrames*chunk.SPF)
+		}
+	}
+	if want := []int{0, 2, 2, 1}; !slices.Equal(frames, want) {
+		t.Errorf("Chunks (first frame, frames) = %v, want %v", frames, want)
+	}
+
+	var buf bytes.Buffer
+	if err = Write(&buf, &d, Options{Fields: []string{"data"}, TimeField: "etime", NoHeader: true}); err != nil {
+		t.Fatal("Write with a short time field failed:", err)
+	}
+	if rows := strings.Count(buf.String(), "\n"); rows != 16 {
+		t.Errorf("Write with a time field of 2 frames wrote %d rows, want 16", rows)
+	}
+}
+
+func TestArrowType(t *testing.T) {
+	for rt, want := range map[getdata.RetType]arrow.DataType{
+		getdata.UINT8: arrow.PrimitiveTypes.Uint8, getdata.INT64: arrow.PrimitiveTypes.Int64,
+		getdata.FLOAT32: arrow.PrimitiveTypes.Float32, getdata.COMPLEX128: arrow.PrimitiveTypes.Float64,
+	} {
+		if dt, err := ArrowType(rt); err != nil || !arrow.TypeEqual(dt, want) {
+			t.Errorf("ArrowType(0x%x) = %v (%v), want %v", rt, dt, err, want)
+		}
+	}
+	if _, err := ArrowType(getdata.STRING); err == nil {
+		t.Error("ArrowType(STRING) did not return error")
+	}
+}
+
+func TestNewArrowSchema(t *testing.T) {
+	d, err := getdata.OpenDirfile(createExportDirfile(t), getdata.RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	schema, err := NewArrowSchema(&d, Options{Fields: []string{"data", "time"}, FrameColumn: true})
+	if err != nil {
+		t.Fatal("NewArrowSchema failed:", err)
+	}
+	var names, types []string
+	for _, f := range schema.Fields() {
+		names = append(names, f.Name)
+		types = append(types, f.Type.String())
+	}
+	if want := []string{"frame", "data", "time"}; !slices.Equal(names, want) {
+		t.Errorf("Schema columns = %v, want %v", names, want)
+	}
+	if want := []string{"float64", "int8", "float64"}; !slices.Equal(types, want) {
+		t.Errorf("Schema types = %v, want %v", types, want)
+	}
+	m := schema.Field(1).Metadata
+	if units, _ := m.GetValue("units"); units != "V" || m.Len() != 2 {
+		t.Errorf("Metadata of data = %v, want units V and gain 2.5", m)
+	}
+	if gain, _ := m.GetValue("gain"); gain != "2.5" {
+		t.Errorf("Metadata gain of data = %q, want 2.5", gain)
+	}
+	for key, want := range map[string]string{
+		"getdata.spf":                    "8",
+		"getdata.first_frame":            "0",
+		"getdata.field.data.fragment":    "0",
+		"getdata.field.data.spf":         "8",
+		"getdata.field.data.entry_type":  "RAW",
+		"getdata.field.data.native_type": "INT8",
+		"getdata.field.time.entry_type":  "LINCOM",
+		"getdata.field.time.native_type": "FLOAT64",
+	} {
+		if got, _ := schema.Metadata().GetValue(key); got != want {
+			t.Errorf("Schema metadata %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestWriteArrow(t *testing.T) {
+	d, err := getdata.OpenDirfile(createExportDirfile(t), getdata.RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	var buf bytes.Buffer
+	opts := Options{Fields: []string{"data", "slow"}, SPF: 2, TimeField: "time", ChunkFrames: 2}
+	if err = WriteArrow(&buf, &d, opts); err != nil {
+		t.Fatal("WriteArrow failed:", err)
+	}
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatal("Could not read Arrow stream:", err)
+	}
+	defer r.Release()
+	if units, _ := r.Schema().Field(1).Metadata.GetValue("units"); units != "V" {
+		t.Errorf("Arrow stream units of data = %q, want V", units)
+	}
+	var data []int8
+	var slow []uint16
+	var times []arrow.Timestamp
+	nrec := 0
+	for r.Next() {
+		rec := r.RecordBatch()
+		nrec++
+		times = append(times, rec.Column(0).(*array.Timestamp).TimestampValues()...)
+		data = append(data, rec.Column(1).(*array.Int8).Int8Values()...)
+		slow = append(slow, rec.Column(2).(*array.Uint16).Uint16Values()...)
+	}
+	if err = r.Err(); err != nil {
+		t.Fatal("Reading Arrow stream failed:", err)
+	}
+	if nrec != 2 {
+		t.Errorf("Arrow stream has %d record batches, want 2", nrec)
+	}
+	if want := []int8{1, 5, 9, 13, 17, 21}; !slices.Equal(data, want) {
+		t.Errorf("Arrow data column = %v, want %v", data, want)
+	}
+	if want := []uint16{10, 10, 20, 20, 30, 30}; !slices.Equal(slow, want) {
+		t.Errorf("Arrow slow column = %v, want %v", slow, want)
+	}
+	if len(times) != 6 || times[2] != arrow.Timestamp(1020e9) {
+		t.Errorf("Arrow time column = %v, want 6 times with 1020 s third", times)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	d, err := getdata.OpenDirfile(createExportDirfile(t), getdata.RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	var buf bytes.Buffer
+	if err = WriteParquet(&buf, &d, Options{Fields: []string{"data"}, FrameColumn: true}); err != nil {
+		t.Fatal("WriteParquet failed:", err)
+	}
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal("Could not read Parquet file:", err)
+	}
+	defer pf.Close()
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatal("Could not read Parquet table:", err)
+	}
+	defer table.Release()
+	if table.NumRows() != 24 || table.NumCols() != 2 {
+		t.Errorf("Parquet table is %d rows by %d columns, want 24 by 2", table.NumRows(), table.NumCols())
+	}
+	schema := table.Schema()
+	if units, _ := schema.Field(1).Metadata.GetValue("units"); units != "V" {
+		t.Errorf("Parquet units of data = %q, want V", units)
+	}
+	if et, _ := schema.Metadata().GetValue("getdata.field.data.entry_type"); et != "RAW" {
+		t.Errorf("Parquet entry type of data = %q, want RAW", et)
+	}
+	data := table.Column(1).Data().Chunk(0).(*array.Int8).Int8Values()
+	if data[0] != 1 || data[23] != 24 {
+		t.Errorf("Parquet data column = %v, want 1 to 24", data)
+	}
+}
@@ -0,0 +1,489 @@
+// Package format parses and writes dirfile format specification files in pure
+// Go, without the GetData C library. It follows the Dirfile Standards, Versions
+// 1 through 10; see http://getdata.sourceforge.net/dirfile.html.
+//
+// The model mirrors that of package getdata: a Format holds the Entry for each
+// field and the Fragment for each format file.
+package format
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// RetType is a data type, with the same values as the GetData library uses
+type RetType int
+
+// The data types
+const (
+	NULLTYPE   RetType = 0x000
+	UINT8      RetType = 0x001
+	INT8       RetType = 0x041
+	UINT16     RetType = 0x002
+	INT16      RetType = 0x042
+	UINT32     RetType = 0x004
+	INT32      RetType = 0x044
+	UINT64     RetType = 0x008
+	INT64      RetType = 0x048
+	FLOAT32    RetType = 0x084
+	FLOAT64    RetType = 0x088
+	COMPLEX64  RetType = 0x108
+	COMPLEX128 RetType = 0x110
+	STRING     RetType = 0x200
+	UNKNOWN    RetType = 0x400
+)
+
+var typeNames = map[RetType]string{
+	UINT8: "UINT8", INT8: "INT8", UINT16: "UINT16", INT16: "INT16",
+	UINT32: "UINT32", INT32: "INT32", UINT64: "UINT64", INT64: "INT64",
+	FLOAT32: "FLOAT32", FLOAT64: "FLOAT64", COMPLEX64: "COMPLEX64", COMPLEX128: "COMPLEX128",
+}
+
+// Size returns the number of bytes in one sample of the type (0 if unknown)
+func (t RetType) Size() int {
+	if _, ok := typeNames[t]; !ok {
+		return 0
+	}
+	return int(t & 0x1f)
+}
+
+// IsComplex reports whether the type is complex
+func (t RetType) IsComplex() bool {
+	return t == COMPLEX64 || t == COMPLEX128
+}
+
+// IsFloat reports whether the type is a (real) floating-point type
+func (t RetType) IsFloat() bool {
+	return t == FLOAT32 || t == FLOAT64
+}
+
+// IsSigned reports whether the type is a signed integer type
+func (t RetType) IsSigned() bool {
+	return t&0x40 != 0
+}
+
+// String returns the name of the type, as used in format files
+func (t RetType) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("RetType(0x%x)", int(t))
+}
+
+// ParseType converts a data type token from a format file to a RetType. It also
+// accepts the single-character type codes of early Standards.
+func ParseType(s string) (RetType, error) {
+	for t, name := range typeNames {
+		if s == name {
+			return t, nil
+		}
+	}
+	switch s {
+	case "FLOAT", "f":
+		return FLOAT32, nil
+	case "DOUBLE", "d":
+		return FLOAT64, nil
+	case "COMPLEX":
+		return COMPLEX128, nil
+	case "c":
+		return UINT8, nil
+	case "u":
+		return UINT16, nil
+	case "s":
+		return INT16, nil
+	case "U":
+		return UINT32, nil
+	case "S", "i":
+		return INT32, nil
+	}
+	return UNKNOWN, fmt.Errorf("Unknown data type %q", s)
+}
+
+// EntryType is the type of a field, with the same values as the GetData library uses
+type EntryType int
+
+// The field types
+const (
+	NOENTRY       EntryType = 0x00
+	RAWENTRY      EntryType = 0x01
+	LINCOMENTRY   EntryType = 0x02
+	LINTERPENTRY  EntryType = 0x03
+	BITENTRY      EntryType = 0x04
+	MULTIPLYENTRY EntryType = 0x05
+	PHASEENTRY    EntryType = 0x06
+	INDEXENTRY    EntryType = 0x07
+	POLYNOMENTRY  EntryType = 0x08
+	SBITENTRY     EntryType = 0x09
+	DIVIDEENTRY   EntryType = 0x0a
+	RECIPENTRY    EntryType = 0x0b
+	WINDOWENTRY   EntryType = 0x0c
+	MPLEXENTRY    EntryType = 0x0d
+	INDIRENTRY    EntryType = 0x0e
+	SINDIRENTRY   EntryType = 0x0f
+	CONSTENTRY    EntryType = 0x10
+	CARRAYENTRY   EntryType = 0x11
+	STRINGENTRY   EntryType = 0x12
+	SARRAYENTRY   EntryType = 0x13
+	ALIASENTRY    EntryType = -1
+)
+
+var entryTypeNames = map[EntryType]string{
+	RAWENTRY: "RAW", LINCOMENTRY: "LINCOM", LINTERPENTRY: "LINTERP", BITENTRY: "BIT",
+	MULTIPLYENTRY: "MULTIPLY", PHASEENTRY: "PHASE", INDEXENTRY: "INDEX",
+	POLYNOMENTRY: "POLYNOM", SBITENTRY: "SBIT", DIVIDEENTRY: "DIVIDE", RECIPENTRY: "RECIP",
+	WINDOWENTRY: "WINDOW", MPLEXENTRY: "MPLEX", INDIRENTRY: "INDIR", SINDIRENTRY: "SINDIR",
+	CONSTENTRY: "CONST", CARRAYENTRY: "CARRAY", STRINGENTRY: "STRING", SARRAYENTRY: "SARRAY",
+	ALIASENTRY: "ALIAS",
+}
+
+// String returns the name of the field type, as used in format files
+func (t EntryType) String() string {
+	if name, ok := entryTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("EntryType(0x%x)", int(t))
+}
+
+// IsScalar reports whether fields of this type hold scalar (non-vector) data
+func (t EntryType) IsScalar() bool {
+	return t >= CONSTENTRY
+}
+
+// WindowOps are the operations of a WINDOW field
+type WindowOps int
+
+// The WINDOW operations, with the same values as the GetData library uses
+const (
+	WINDOPUNK WindowOps = iota
+	WINDOPEQ
+	WINDOPNE
+	WINDOPGE
+	WINDOPGT
+	WINDOPLE
+	WINDOPLT
+	WINDOPSET
+	WINDOPCLR
+)
+
+var windopNames = []string{"", "EQ", "NE", "GE", "GT", "LE", "LT", "SET", "CLR"}
+
+// String returns the name of the operation, as used in format files
+func (op WindowOps) String() string {
+	if op > WINDOPUNK && int(op) < len(windopNames) {
+		return windopNames[op]
+	}
+	return fmt.Sprintf("WindowOps(%d)", int(op))
+}
+
+// Flags hold a fragment's encoding, endianness, or protection level
+type Flags int64
+
+// The encodings, endiannesses, and protection levels, with the same values as
+// the GetData library uses. A zero value means none was declared.
+const (
+	AUTOENCODED   Flags = 0x0000000
+	UNENCODED     Flags = 0x1000000
+	TEXTENCODED   Flags = 0x2000000
+	SLIMENCODED   Flags = 0x3000000
+	GZIPENCODED   Flags = 0x4000000
+	BZIP2ENCODED  Flags = 0x5000000
+	LZMAENCODED   Flags = 0x6000000
+	SIEENCODED    Flags = 0x7000000
+	ZZIPENCODED   Flags = 0x8000000
+	ZZSLIMENCODED Flags = 0x9000000
+	FLACENCODED   Flags = 0xA000000
+
+	NATIVEENDIAN Flags = 0x0
+	BIGENDIAN    Flags = 0x4
+	LITTLEENDIAN Flags = 0x8
+	ARMENDIAN    Flags = 0x8000
+
+	PROTECTNONE   Flags = 0
+	PROTECTFORMAT Flags = 1
+	PROTECTDATA   Flags = 2
+	PROTECTALL    Flags = 3
+)
+
+var encodingNames = map[Flags]string{
+	UNENCODED: "none", TEXTENCODED: "text", SLIMENCODED: "slim", GZIPENCODED: "gzip",
+	BZIP2ENCODED: "bzip2", LZMAENCODED: "lzma", SIEENCODED: "sie", ZZIPENCODED: "zzip",
+	ZZSLIMENCODED: "zzslim", FLACENCODED: "flac",
+}
+
+var protectNames = []string{"none", "format", "data", "all"}
+
+// Param is a field parameter, given either as a literal number or by a CONST or
+// CARRAY field.
+type Param struct {
+	Literal string // text of a literal value, or "" if given by a field
+	Field   string // field code of the CONST or CARRAY giving the value
+	Index   int    // element of the CARRAY, or -1 for a CONST
+}
+
+// Literal returns a literal Param of the given integer, floating-point, or
+// complex value
+func Literal(v interface{}) Param {
+	return Param{Literal: formatNumber(v), Index: -1}
+}
+
+// IsLiteral reports whether the parameter is given as a literal number
+func (p Param) IsLiteral() bool {
+	return p.Field == ""
+}
+
+// String returns the parameter as written in a format file
+func (p Param) String() string {
+	if p.IsLiteral() {
+		return p.Literal
+	}
+	if p.Index >= 0 {
+		return fmt.Sprintf("%s<%d>", p.Field, p.Index)
+	}
+	return p.Field
+}
+
+func (p Param) errNotLiteral() error {
+	return fmt.Errorf("Parameter is given by field %s, not a literal", p.String())
+}
+
+// Int returns the literal value of the parameter as an integer
+func (p Param) Int() (int64, error) {
+	if !p.IsLiteral() {
+		return 0, p.errNotLiteral()
+	}
+	if v, err := strconv.ParseInt(p.Literal, 0, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseUint(p.Literal, 0, 64); err == nil {
+		return int64(v), nil
+	}
+	f, err := p.Float()
+	return int64(f), err
+}
+
+// Uint returns the literal value of the parameter as an unsigned integer
+func (p Param) Uint() (uint64, error) {
+	if !p.IsLiteral() {
+		return 0, p.errNotLiteral()
+	}
+	if v, err := strconv.ParseUint(p.Literal, 0, 64); err == nil {
+		return v, nil
+	}
+	v, err := p.Int()
+	return uint64(v), err
+}
+
+// Float returns the literal value of the parameter as a real number (the real
+// part, if it is complex)
+func (p Param) Float() (float64, error) {
+	c, err := p.Complex()
+	return real(c), err
+}
+
+// Complex returns the literal value of the parameter as a complex number
+func (p Param) Complex() (complex128, error) {
+	if !p.IsLiteral() {
+		return 0, p.errNotLiteral()
+	}
+	return ParseNumber(p.Literal)
+}
+
+// ParseNumber parses a number as written in a format file: an integer (decimal,
+// octal, or hex), a floating-point number, or a complex number written as
+// "real;imag".
+func ParseNumber(s string) (complex128, error) {
+	re, im, isComplex := strings.Cut(s, ";")
+	r, err := parseReal(re)
+	if err != nil {
+		return 0, err
+	}
+	if !isComplex {
+		return complex(r, 0), nil
+	}
+	i, err := parseReal(im)
+	if err != nil {
+		return 0, err
+	}
+	return complex(r, i), nil
+}
+
+func parseReal(s string) (float64, error) {
+	if v, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(v), nil
+	}
+	if v, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return float64(v), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid number %q", s)
+	}
+	return v, nil
+}
+
+func isNumber(s string) bool {
+	_, err := ParseNumber(s)
+	return err == nil
+}
+
+func formatNumber(v interface{}) string {
+	switch x := v.(type) {
+	case int:
+		return strconv.FormatInt(int64(x), 10)
+	case int8:
+		return strconv.FormatInt(int64(x), 10)
+	case int16:
+		return strconv.FormatInt(int64(x), 10)
+	case int32:
+		return strconv.FormatInt(int64(x), 10)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case complex64:
+		return formatNumber(complex128(x))
+	case complex128:
+		if imag(x) == 0 {
+			return formatNumber(real(x))
+		}
+		return formatNumber(real(x)) + ";" + formatNumber(imag(x))
+	}
+	return fmt.Sprint(v)
+}
+
+// Entry holds the metadata of one field. Which members are used depends on Type.
+type Entry struct {
+	Name      string // full field code, including namespace and affixes
+	Type      EntryType
+	Fragment  int // index of the defining Fragment
+	Hidden    bool
+	InFields  []string  // input field codes of a derived field
+	DataType  RetType   // RAW data type, or CONST/CARRAY storage type
+	SPF       Param     // RAW samples per frame
+	Scale     []Param   // LINCOM scale factors
+	Offset    []Param   // LINCOM offsets
+	Coeffs    []Param   // POLYNOM coefficients, constant term first
+	Table     string    // LINTERP look-up table path, as written
+	Bitnum    Param     // BIT and SBIT first bit
+	Numbits   Param     // BIT and SBIT number of bits
+	Shift     Param     // PHASE shift
+	Dividend  Param     // RECIP dividend
+	CountVal  Param     // MPLEX count value
+	Period    Param     // MPLEX period
+	WindowOp  WindowOps // WINDOW operation
+	Threshold Param     // WINDOW threshold
+	Values    []string  // CONST and CARRAY literal values, or STRING and SARRAY strings
+	Target    string    // target field code of an ALIAS
+}
+
+// Parent returns the field code of the parent of a metafield, or "" if the entry
+// is not a metafield
+func (e *Entry) Parent() string {
+	if i := strings.LastIndexByte(e.Name, '/'); i >= 0 {
+		return e.Name[:i]
+	}
+	return ""
+}
+
+// Fragment holds the metadata of one format file
+type Fragment struct {
+	Index       int
+	Parent      int    // index of the including fragment, or -1 for the primary format file
+	Filename    string // path relative to the dirfile, using '/' separators
+	Version     int    // Standards Version declared by /VERSION, or -1 if none
+	Encoding    Flags
+	Endianness  Flags
+	FrameOffset int64
+	Protection  Flags
+	Namespace   string // namespace given in the /INCLUDE directive
+	Prefix      string // field name prefix given in the /INCLUDE directive
+	Suffix      string // field name suffix given in the /INCLUDE directive
+	Reference   string // field code given by /REFERENCE in this fragment, if any
+}
+
+// Format holds the parsed metadata of a whole dirfile
+type Format struct {
+	Fragments []*Fragment
+	Entries   []*Entry // in order of definition
+	Reference string   // the reference field, set by the last /REFERENCE directive
+	entries   map[string]*Entry
+}
+
+// New returns an empty Format with a single, empty primary format file
+func New() *Format {
+	f := &Format{entries: make(map[string]*Entry)}
+	f.Fragments = append(f.Fragments, &Fragment{Parent: -1, Filename: "format", Version: -1})
+	return f
+}
+
+// Entry returns the entry with the given field code (or nil), following aliases
+func (f *Format) Entry(name string) *Entry {
+	e := f.entries[name]
+	for i := 0; e != nil && e.Type == ALIASENTRY && i < 64; i++ {
+		e = f.entries[e.Target]
+	}
+	return e
+}
+
+// RawEntry returns the entry with the given field code (or nil), not following aliases
+func (f *Format) RawEntry(name string) *Entry {
+	return f.entries[name]
+}
+
+// Add adds an entry, which must not duplicate an existing field code
+func (f *Format) Add(e *Entry) error {
+	if e.Name == "" {
+		return fmt.Errorf("Entry has no name")
+	}
+	if _, ok := f.entries[e.Name]; ok {
+		return fmt.Errorf("Field code %s already exists", e.Name)
+	}
+	if e.Fragment < 0 || e.Fragment >= len(f.Fragments) {
+		return fmt.Errorf("Entry %s has invalid fragment index %d", e.Name, e.Fragment)
+	}
+	if parent := e.Parent(); parent != "" {
+		if _, ok := f.entries[parent]; !ok {
+			return fmt.Errorf("Metafield %s has no parent field %s", e.Name, parent)
+		}
+	}
+	f.entries[e.Name] = e
+	f.Entries = append(f.Entries, e)
+	return nil
+}
+
+// affixes returns the effective namespace, prefix, and suffix applied to field
+// codes in fragment i, accumulated over the chain of /INCLUDE directives
+func (f *Format) affixes(i int) (ns, prefix, suffix string) {
+	for ; i >= 0; i = f.Fragments[i].Parent {
+		frag := f.Fragments[i]
+		prefix = frag.Prefix + prefix
+		suffix = suffix + frag.Suffix
+		if frag.Namespace != "" {
+			if ns == "" {
+				ns = frag.Namespace
+			} else {
+				ns = frag.Namespace + "." + ns
+			}
+		}
+	}
+	return
+}
+
+// dir returns the directory of fragment i, relative to the dirfile
+func (f *Format) dir(i int) string {
+	return path.Dir(f.Fragments[i].Filename)
+}
@@ -0,0 +1,296 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+func parseString(t *testing.T, files map[string]string) *Format {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for name, text := range files {
+		fsys["df/"+name] = &fstest.MapFile{Data: []byte(text)}
+	}
+	f, err := Parse(fsys, "df")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return f
+}
+
+// roundTrip writes every fragment of f and parses the result
+func roundTrip(t *testing.T, f *Format) *Format {
+	t.Helper()
+	files := make(map[string]string)
+	for _, frag := range f.Fragments {
+		var buf bytes.Buffer
+		if err := f.WriteFragment(&buf, frag.Index); err != nil {
+			t.Fatalf("WriteFragment(%d) failed: %v", frag.Index, err)
+		}
+		files[frag.Filename] = buf.String()
+	}
+	return parseString(t, files)
+}
+
+func compareFormats(t *testing.T, a, b *Format) {
+	t.Helper()
+	if len(a.Entries) != len(b.Entries) {
+		t.Fatalf("Round trip has %d entries, want %d", len(b.Entries), len(a.Entries))
+	}
+	for _, e := range a.Entries {
+		if e2 := b.RawEntry(e.Name); !reflect.DeepEqual(e, e2) {
+			t.Errorf("Round trip entry %s is %+v, want %+v", e.Name, e2, e)
+		}
+	}
+	if !reflect.DeepEqual(a.Fragments, b.Fragments) {
+		t.Errorf("Round trip fragments differ")
+	}
+	if a.Reference != b.Reference {
+		t.Errorf("Round trip reference is %q, want %q", b.Reference, a.Reference)
+	}
+}
+
+func TestParse(t *testing.T) {
+	f := parseString(t, map[string]string{"format": testdirfile.Format})
+	if len(f.Entries) != 24 {
+		t.Errorf("Parsed %d entries, want 24", len(f.Entries))
+	}
+	if f.Fragments[0].Endianness != LITTLEENDIAN {
+		t.Errorf("Endianness is 0x%x, want LITTLEENDIAN", f.Fragments[0].Endianness)
+	}
+
+	data := f.Entry("data")
+	if data == nil || data.Type != RAWENTRY || data.DataType != INT8 {
+		t.Fatalf("Entry data is %+v", data)
+	}
+	if spf, err := data.SPF.Uint(); err != nil || spf != 8 {
+		t.Errorf("data SPF is %d (%v), want 8", spf, err)
+	}
+	if f.Entry("alias") != data {
+		t.Errorf("Alias does not resolve to data")
+	}
+	if a := f.RawEntry("alias"); a.Type != ALIASENTRY || a.Target != "data" {
+		t.Errorf("Raw alias entry is %+v", a)
+	}
+
+	lincom := f.Entry("lincom")
+	if !reflect.DeepEqual(lincom.InFields, []string{"data", "INDEX", "linterp"}) {
+		t.Errorf("lincom inputs are %v", lincom.InFields)
+	}
+	if c, err := lincom.Offset[1].Complex(); err != nil || c != complex(3.3, 4.4) {
+		t.Errorf("lincom offset[1] is %v (%v), want 3.3+4.4i", c, err)
+	}
+	if s := lincom.Scale[2]; s.IsLiteral() || s.Field != "const" || s.Index != -1 {
+		t.Errorf("lincom scale[2] is %+v, want field const", s)
+	}
+
+	if e := f.Entry("data/mconst"); e == nil || e.Parent() != "data" || e.DataType != COMPLEX128 {
+		t.Errorf("Metafield data/mconst is %+v", e)
+	}
+	if e := f.Entry("data/msarray"); e == nil || len(e.Values) != 5 {
+		t.Errorf("Metafield data/msarray is %+v", e)
+	}
+	if e := f.Entry("data/mstr"); e == nil || e.Values[0] != "This is a string constant." {
+		t.Errorf("Metafield data/mstr is %+v", e)
+	}
+	if e := f.Entry("window"); e.WindowOp != WINDOPLT || e.Threshold.Literal != "4.1" {
+		t.Errorf("window is %+v", e)
+	}
+	if e := f.Entry("bit"); e.Bitnum.Literal != "3" || e.Numbits.Literal != "4" {
+		t.Errorf("bit is %+v", e)
+	}
+	if e := f.Entry("polynom"); len(e.Coeffs) != 6 || e.Coeffs[5].Field != "const" {
+		t.Errorf("polynom is %+v", e)
+	}
+
+	compareFormats(t, f, roundTrip(t, f))
+}
+
+func TestInclude(t *testing.T) {
+	f := parseString(t, map[string]string{
+		"format": `/VERSION 10
+/ENCODING none
+/PROTECT data
+/FRAMEOFFSET 3
+base RAW UINT16 4
+/INCLUDE sub/form2 pre_ _suf
+/INCLUDE form3 ns.
+/REFERENCE base
+`,
+		"sub/form2": `/ENDIAN big arm
+x RAW FLOAT64 1
+y LINCOM x 2 0 .base 1 0
+/HIDDEN y
+/INCLUDE form4 "" _two
+`,
+		"sub/form4": "z PHASE x 1\n",
+		"form3": `c CONST UINT8 0x10
+s STRING "tab\there \"quoted\" \x23"
+/NAMESPACE inner
+deep BIT .ns.c c
+`,
+	})
+	if len(f.Fragments) != 4 {
+		t.Fatalf("Parsed %d fragments, want 4", len(f.Fragments))
+	}
+	wantFiles := []string{"format", "sub/form2", "sub/form4", "form3"}
+	for i, frag := range f.Fragments {
+		if frag.Filename != wantFiles[i] {
+			t.Errorf("Fragment %d file is %q, want %q", i, frag.Filename, wantFiles[i])
+		}
+	}
+	root := f.Fragments[0]
+	if root.Version != 10 || root.Encoding != UNENCODED || root.Protection != PROTECTDATA ||
+		root.FrameOffset != 3 {
+		t.Errorf("Root fragment is %+v", root)
+	}
+	if f.Fragments[1].Endianness != BIGENDIAN|ARMENDIAN || f.Fragments[1].Version != 10 {
+		t.Errorf("Fragment 1 is %+v", f.Fragments[1])
+	}
+	if f.Reference != "base" {
+		t.Errorf("Reference is %q, want base", f.Reference)
+	}
+
+	y := f.Entry("pre_y_suf")
+	if y == nil || !y.Hidden || y.Fragment != 1 {
+		t.Fatalf("Entry pre_y_suf is %+v", y)
+	}
+	if !reflect.DeepEqual(y.InFields, []string{"pre_x_suf", "base"}) {
+		t.Errorf("pre_y_suf inputs are %v", y.InFields)
+	}
+	if z := f.Entry("pre_z_two_suf"); z == nil || z.InFields[0] != "pre_x_two_suf" {
+		t.Errorf("Entry pre_z_two_suf is %+v", z)
+	}
+	if c := f.Entry("ns.c"); c == nil || c.Values[0] != "0x10" {
+		t.Errorf("Entry ns.c is %+v", c)
+	} else if v, err := (Param{Literal: c.Values[0]}).Int(); err != nil || v != 16 {
+		t.Errorf("ns.c value is %d (%v), want 16", v, err)
+	}
+	if s := f.Entry("ns.s"); s == nil || s.Values[0] != "tab\there \"quoted\" #" {
+		t.Errorf("Entry ns.s is %+v", s)
+	}
+	deep := f.Entry("ns.inner.deep")
+	if deep == nil || deep.InFields[0] != "ns.c" || deep.Bitnum.Field != "ns.inner.c" {
+		t.Errorf("Entry ns.inner.deep is %+v", deep)
+	}
+
+	compareFormats(t, f, roundTrip(t, f))
+}
+
+func TestOldStandards(t *testing.T) {
+	f := parseString(t, map[string]string{
+		"format": `VERSION 4
+ENDIAN big
+FRAMEOFFSET 2
+a RAW c 1
+b RAW d 2
+sum LINCOM 2 a 1 0 b 2 0
+INCLUDE more
+`,
+		"more": "m RAW U 5 # a comment\n",
+	})
+	if f.Fragments[0].Version != 4 || f.Fragments[0].Endianness != BIGENDIAN ||
+		f.Fragments[0].FrameOffset != 2 {
+		t.Errorf("Root fragment is %+v", f.Fragments[0])
+	}
+	if f.Entry("a").DataType != UINT8 || f.Entry("b").DataType != FLOAT64 ||
+		f.Entry("m").DataType != UINT32 {
+		t.Errorf("Single-character types parsed wrongly")
+	}
+	if sum := f.Entry("sum"); len(sum.InFields) != 2 || sum.Scale[1].Literal != "2" {
+		t.Errorf("Entry sum is %+v", sum)
+	}
+	if f.Fragments[1].Version != 4 {
+		t.Errorf("Included fragment version is %d, want 4", f.Fragments[1].Version)
+	}
+
+	dir := t.TempDir()
+	if err := f.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir failed: %v", err)
+	}
+	f2, err := ParseDir(dir)
+	if err != nil {
+		t.Fatalf("ParseDir failed: %v", err)
+	}
+	compareFormats(t, f, f2)
+
+	// Standards Version 4 has neither slashes nor long type names
+	for _, frag := range f.Fragments {
+		var buf bytes.Buffer
+		if err := f.WriteFragment(&buf, frag.Index); err != nil {
+			t.Fatalf("WriteFragment(%d) failed: %v", frag.Index, err)
+		}
+		if text := buf.String(); strings.Contains(text, "/") || strings.Contains(text, "INT") ||
+			strings.Contains(text, "FLOAT") {
+			t.Errorf("Fragment %d is not in Standards Version 4 syntax:\n%s", frag.Index, text)
+		}
+	}
+	bad := map[string]string{
+		"metafield": "VERSION 5\na RAW UINT8 1\n/META a units STRING V\n",
+		"CONST":     "VERSION 5\nc CONST UINT8 1\n",
+		"INT64":     "VERSION 4\na RAW INT64 1\n",
+		"REFERENCE": "VERSION 5\na RAW UINT8 1\nREFERENCE a\n",
+	}
+	for what, text := range bad {
+		f := parseString(t, map[string]string{"format": text})
+		if err := f.WriteFragment(&bytes.Buffer{}, 0); err == nil {
+			t.Errorf("WriteFragment wrote a %s in Standards Version %d", what, f.Fragments[0].Version)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := []string{
+		"x RAW INT99 1\n",
+		"x FOO 1\n",
+		"/BOGUS x\n",
+		"x RAW UINT8 1\nx RAW UINT8 1\n",
+		"s STRING \"unterminated\n",
+		"w WINDOW a b XX 1\n",
+		"/HIDDEN nothing\n",
+	}
+	for _, text := range bad {
+		fsys := fstest.MapFS{"format": &fstest.MapFile{Data: []byte(text)}}
+		_, err := Parse(fsys, ".")
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("Parse(%q) returned %v, want a *SyntaxError", text, err)
+		}
+	}
+}
+
+func TestIncludeRecursion(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"self":     {"format": "/INCLUDE format\n"},
+		"ancestor": {"format": "/INCLUDE sub/a\n", "sub/a": "/INCLUDE b\n", "sub/b": "/INCLUDE ../format\n"},
+	} {
+		fsys := fstest.MapFS{}
+		for file, text := range files {
+			fsys[file] = &fstest.MapFile{Data: []byte(text)}
+		}
+		_, err := Parse(fsys, ".")
+		if !errors.Is(err, ErrRecurse) {
+			t.Errorf("Parse of a %s-including fragment returned %v, want ErrRecurse", name, err)
+		}
+	}
+	// including the same fragment twice is not recursion
+	f := parseString(t, map[string]string{"format": "/INCLUDE a\n/INCLUDE b\n",
+		"a": "/INCLUDE c\n", "b": "/INCLUDE c x_\n", "c": "v RAW UINT8 1\n"})
+	if len(f.Fragments) != 5 {
+		t.Errorf("Parse found %d fragments, want 5", len(f.Fragments))
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, s := range []string{"plain", "", "two words", `back\slash`, "hash#", "ctl\x01", "quote\""} {
+		tokens, err := tokenize("x " + quote(s))
+		if err != nil || len(tokens) != 2 || tokens[1] != s {
+			t.Errorf("tokenize(quote(%q)) = %q, %v", s, tokens, err)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package format
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrRecurse is the error wrapped by a SyntaxError for an /INCLUDE of a
+// fragment by itself or by a fragment it includes (GetData's GD_E_RECURSE_LEVEL)
+var ErrRecurse = errors.New("Too many levels of recursion")
+
+// SyntaxError reports a problem with one line of a format file
+type SyntaxError struct {
+	File string // format file path, relative to the dirfile
+	Line int    // line number, starting from 1
+	Text string // the offending line
+	Msg  string
+	Err  error // the underlying error, if any
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Unwrap returns the underlying error, if any
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// ParseDir parses the format files of the dirfile in directory dir
+func ParseDir(dir string) (*Format, error) {
+	return Parse(os.DirFS(dir), ".")
+}
+
+// Parse parses the format files of the dirfile at path dir within fsys,
+// starting with dir/format and following /INCLUDE directives.
+func Parse(fsys fs.FS, dir string) (*Format, error) {
+	f := New()
+	p := &parser{f: f, fsys: fsys, root: dir}
+	if err := p.parseFragment(0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type parser struct {
+	f    *Format
+	fsys fs.FS
+	root string
+}
+
+// fragState is the parser state within one fragment
+type fragState struct {
+	index     int
+	version   int
+	ns        string // effective namespace, including any /NAMESPACE
+	prefix    string
+	suffix    string
+	file      string
+	linenum   int
+	line      string
+	directive bool
+}
+
+func (p *parser) parseFragment(index int) error {
+	frag := p.f.Fragments[index]
+	file, err := p.fsys.Open(path.Join(p.root, frag.Filename))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	st := &fragState{index: index, version: -1, file: frag.Filename}
+	if frag.Parent >= 0 {
+		st.version = p.f.Fragments[frag.Parent].Version
+	}
+	st.ns, st.prefix, st.suffix = p.f.affixes(index)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 4096), 1<<20)
+	for scanner.Scan() {
+		st.linenum++
+		st.line = strings.TrimSuffix(scanner.Text(), "\r")
+		tokens, err := tokenize(st.line)
+		if err != nil {
+			return st.errorf("%s", err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		if err := p.parseLine(st, tokens); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (st *fragState) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{File: st.file, Line: st.linenum, Text: st.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// code converts a field code as written in this fragment to a full field code,
+// applying the namespace and affixes
+func (st *fragState) code(c string) string {
+	if c == "INDEX" {
+		return c
+	}
+	if strings.HasPrefix(c, ".") {
+		return c[1:]
+	}
+	parent, meta, isMeta := strings.Cut(c, "/")
+	full := st.prefix + parent + st.suffix
+	if st.ns != "" {
+		full = st.ns + "." + full
+	}
+	if isMeta {
+		full += "/" + meta
+	}
+	return full
+}
+
+// param converts a parameter token to a Param
+func (st *fragState) param(tok string) Param {
+	if isNumber(tok) {
+		return Param{Literal: tok, Index: -1}
+	}
+	p := Param{Index: -1}
+	if i := strings.IndexByte(tok, '<'); i > 0 && strings.HasSuffix(tok, ">") {
+		if n, err := strconv.Atoi(tok[i+1 : len(tok)-1]); err == nil {
+			p.Index = n
+			tok = tok[:i]
+		}
+	}
+	p.Field = st.code(tok)
+	return p
+}
+
+var directives = map[string]bool{
+	"ALIAS": true, "ENCODING": true, "ENDIAN": true, "FRAMEOFFSET": true, "HIDDEN": true,
+	"INCLUDE": true, "META": true, "NAMESPACE": true, "PROTECT": true, "REFERENCE": true,
+	"VERSION": true,
+}
+
+func (p *parser) parseLine(st *fragState, tokens []string) error {
+	first := tokens[0]
+	if strings.HasPrefix(first, "/") && directives[first[1:]] {
+		return p.parseDirective(st, first[1:], tokens[1:])
+	}
+	// Before Standards Version 6, directives had no leading slash
+	if st.version < 6 && directives[first] && first != "ALIAS" && first != "HIDDEN" &&
+		first != "NAMESPACE" {
+		return p.parseDirective(st, first, tokens[1:])
+	}
+	if strings.HasPrefix(first, "/") {
+		return st.errorf("Unknown directive %s", first)
+	}
+	if len(tokens) < 2 {
+		return st.errorf("Too few tokens on line")
+	}
+	return p.parseField(st, st.code(first), tokens[1], tokens[2:])
+}
+
+func (p *parser) parseDirective(st *fragState, name string, args []string) error {
+	frag := p.f.Fragments[st.index]
+	nargs := map[string]int{"ALIAS": 2, "ENCODING": 1, "ENDIAN": 1, "FRAMEOFFSET": 1,
+		"HIDDEN": 1, "INCLUDE": 1, "META": 4, "NAMESPACE": 1, "PROTECT": 1, "REFERENCE": 1,
+		"VERSION": 1}[name]
+	if len(args) < nargs {
+		return st.errorf("Too few tokens for /%s", name)
+	}
+	switch name {
+	case "VERSION":
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 0 {
+			return st.errorf("Invalid version %q", args[0])
+		}
+		st.version = v
+		frag.Version = v
+	case "ENDIAN":
+		switch args[0] {
+		case "big":
+			frag.Endianness = BIGENDIAN
+		case "little":
+			frag.Endianness = LITTLEENDIAN
+		default:
+			return st.errorf("Invalid endianness %q", args[0])
+		}
+		if len(args) > 1 && args[1] == "arm" {
+			frag.Endianness |= ARMENDIAN
+		}
+	case "ENCODING":
+		frag.Encoding = AUTOENCODED
+		for enc, ename := range encodingNames {
+			if args[0] == ename {
+				frag.Encoding = enc
+			}
+		}
+		if frag.Encoding == AUTOENCODED {
+			return st.errorf("Unknown encoding %q", args[0])
+		}
+	case "FRAMEOFFSET":
+		off, err := strconv.ParseInt(args[0], 0, 64)
+		if err != nil || off < 0 {
+			return st.errorf("Invalid frame offset %q", args[0])
+		}
+		frag.FrameOffset = off
+	case "PROTECT":
+		frag.Protection = -1
+		for i, pname := range protectNames {
+			if args[0] == pname {
+				frag.Protection = Flags(i)
+			}
+		}
+		if frag.Protection < 0 {
+			return st.errorf("Invalid protection level %q", args[0])
+		}
+	case "REFERENCE":
+		frag.Reference = st.code(args[0])
+		p.f.Reference = frag.Reference
+	case "HIDDEN":
+		e := p.f.RawEntry(st.code(args[0]))
+		if e == nil {
+			return st.errorf("Cannot hide missing field %s", args[0])
+		}
+		e.Hidden = true
+	case "ALIAS":
+		e := &Entry{Name: st.code(args[0]), Type: ALIASENTRY, Fragment: st.index,
+			Target: st.code(args[1])}
+		if err := p.f.Add(e); err != nil {
+			return st.errorf("%s", err)
+		}
+	case "META":
+		return p.parseField(st, st.code(args[0])+"/"+args[1], args[2], args[3:])
+	case "NAMESPACE":
+		ns, _, _ := p.f.affixes(st.index)
+		switch {
+		case strings.HasPrefix(args[0], "."):
+			st.ns = args[0][1:]
+		case ns == "":
+			st.ns = args[0]
+		default:
+			st.ns = ns + "." + args[0]
+		}
+	case "INCLUDE":
+		return p.parseInclude(st, args)
+	}
+	return nil
+}
+
+func (p *parser) parseInclude(st *fragState, args []string) error {
+	child := &Fragment{
+		Index:    len(p.f.Fragments),
+		Parent:   st.index,
+		Filename: path.Clean(path.Join(p.f.dir(st.index), args[0])),
+		Version:  st.version,
+	}
+	if len(args) > 1 {
+		child.Prefix = args[1]
+		if i := strings.LastIndexByte(args[1], '.'); i >= 0 {
+			child.Namespace, child.Prefix = args[1][:i], args[1][i+1:]
+		}
+	}
+	if len(args) > 2 {
+		child.Suffix = args[2]
+	}
+	// A /NAMESPACE in the parent applies to the included fragment too
+	parentNS, _, _ := p.f.affixes(st.index)
+	if st.ns != parentNS {
+		extra := strings.TrimPrefix(strings.TrimPrefix(st.ns, parentNS), ".")
+		if child.Namespace == "" {
+			child.Namespace = extra
+		} else {
+			child.Namespace = extra + "." + child.Namespace
+		}
+	}
+	// A fragment including itself, directly or not, would recurse forever
+	for i := st.index; i >= 0; i = p.f.Fragments[i].Parent {
+		if p.f.Fragments[i].Filename == child.Filename {
+			return &SyntaxError{File: st.file, Line: st.linenum, Text: st.line,
+				Msg: fmt.Sprintf("%s includes itself", child.Filename), Err: ErrRecurse}
+		}
+	}
+	p.f.Fragments = append(p.f.Fragments, child)
+	if err := p.parseFragment(child.Index); err != nil {
+		return err
+	}
+	// A /REFERENCE in the included fragment does not carry on into its parent's
+	// later lines, but a /VERSION does.
+	st.version = p.f.Fragments[child.Index].Version
+	return nil
+}
+
+func (p *parser) parseField(st *fragState, name, typename string, params []string) error {
+	e := &Entry{Name: name, Fragment: st.index}
+	need := func(n int) error {
+		if len(params) < n {
+			return st.errorf("Too few parameters for %s field %s", typename, name)
+		}
+		return nil
+	}
+	var err error
+	switch typename {
+	case "RAW":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = RAWENTRY
+		if e.DataType, err = ParseType(params[0]); err != nil {
+			return st.errorf("%s", err)
+		}
+		e.SPF = st.param(params[1])
+	case "LINCOM":
+		e.Type = LINCOMENTRY
+		n := len(params) / 3
+		if len(params)%3 == 1 {
+			if n, err = strconv.Atoi(params[0]); err != nil {
+				return st.errorf("Invalid LINCOM field count %q", params[0])
+			}
+			params = params[1:]
+		}
+		if n < 1 || n > 3 || len(params) < 3*n {
+			return st.errorf("Invalid LINCOM parameters for field %s", name)
+		}
+		for i := 0; i < n; i++ {
+			e.InFields = append(e.InFields, st.code(params[3*i]))
+			e.Scale = append(e.Scale, st.param(params[3*i+1]))
+			e.Offset = append(e.Offset, st.param(params[3*i+2]))
+		}
+	case "LINTERP":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = LINTERPENTRY
+		e.InFields = []string{st.code(params[0])}
+		e.Table = params[1]
+	case "BIT", "SBIT":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = BITENTRY
+		if typename == "SBIT" {
+			e.Type = SBITENTRY
+		}
+		e.InFields = []string{st.code(params[0])}
+		e.Bitnum = st.param(params[1])
+		e.Numbits = Literal(1)
+		if len(params) > 2 {
+			e.Numbits = st.param(params[2])
+		}
+	case "MULTIPLY", "DIVIDE", "INDIR", "SINDIR":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = map[string]EntryType{"MULTIPLY": MULTIPLYENTRY, "DIVIDE": DIVIDEENTRY,
+			"INDIR": INDIRENTRY, "SINDIR": SINDIRENTRY}[typename]
+		e.InFields = []string{st.code(params[0]), st.code(params[1])}
+	case "PHASE":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = PHASEENTRY
+		e.InFields = []string{st.code(params[0])}
+		e.Shift = st.param(params[1])
+	case "POLYNOM":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = POLYNOMENTRY
+		e.InFields = []string{st.code(params[0])}
+		for _, a := range params[1:] {
+			e.Coeffs = append(e.Coeffs, st.param(a))
+		}
+	case "RECIP":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = RECIPENTRY
+		e.InFields = []string{st.code(params[0])}
+		e.Dividend = st.param(params[1])
+	case "WINDOW":
+		if err = need(4); err != nil {
+			return err
+		}
+		e.Type = WINDOWENTRY
+		e.InFields = []string{st.code(params[0]), st.code(params[1])}
+		for op, opname := range windopNames {
+			if op > 0 && params[2] == opname {
+				e.WindowOp = WindowOps(op)
+			}
+		}
+		if e.WindowOp == WINDOPUNK {
+			return st.errorf("Invalid WINDOW operation %q", params[2])
+		}
+		e.Threshold = st.param(params[3])
+	case "MPLEX":
+		if err = need(3); err != nil {
+			return err
+		}
+		e.Type = MPLEXENTRY
+		e.InFields = []string{st.code(params[0]), st.code(params[1])}
+		e.CountVal = st.param(params[2])
+		e.Period = Literal(0)
+		if len(params) > 3 {
+			e.Period = st.param(params[3])
+		}
+	case "CONST", "CARRAY":
+		if err = need(2); err != nil {
+			return err
+		}
+		e.Type = CONSTENTRY
+		if typename == "CARRAY" {
+			e.Type = CARRAYENTRY
+		} else {
+			params = params[:2]
+		}
+		if e.DataType, err = ParseType(params[0]); err != nil {
+			return st.errorf("%s", err)
+		}
+		for _, v := range params[1:] {
+			if !isNumber(v) {
+				return st.errorf("Invalid %s value %q", typename, v)
+			}
+		}
+		e.Values = append([]string{}, params[1:]...)
+	case "STRING":
+		if err = need(1); err != nil {
+			return err
+		}
+		e.Type = STRINGENTRY
+		e.Values = []string{params[0]}
+	case "SARRAY":
+		e.Type = SARRAYENTRY
+		e.Values = append([]string{}, params...)
+	default:
+		return st.errorf("Unknown field type %s", typename)
+	}
+	if err := p.f.Add(e); err != nil {
+		return st.errorf("%s", err)
+	}
+	return nil
+}
+
+// tokenize splits a format file line into tokens, handling quoting, escapes
+// and comments
+func tokenize(line string) ([]string, error) {
+	var tokens []string
+	var tok strings.Builder
+	inToken, quoted := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inToken = true
+		case c == '\\':
+			if i+1 >= len(line) {
+				return nil, fmt.Errorf("Unterminated escape sequence")
+			}
+			n, err := unescape(line[i+1:], &tok)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			inToken = true
+		case !quoted && c == '#':
+			i = len(line)
+		case !quoted && (c == ' ' || c == '\t'):
+			if inToken {
+				tokens = append(tokens, tok.String())
+				tok.Reset()
+				inToken = false
+			}
+		default:
+			tok.WriteByte(c)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("Unterminated quoted token")
+	}
+	if inToken {
+		tokens = append(tokens, tok.String())
+	}
+	return tokens, nil
+}
+
+// unescape decodes the escape sequence at the start of s (just after the
+// backslash) into tok, returning the number of bytes consumed
+func unescape(s string, tok *strings.Builder) (int, error) {
+	simple := map[byte]byte{'a': '\a', 'b': '\b', 'e': 0x1b, 'f': '\f', 'n': '\n', 'r': '\r',
+		't': '\t', 'v': '\v'}
+	c := s[0]
+	if r, ok := simple[c]; ok {
+		tok.WriteByte(r)
+		return 1, nil
+	}
+	switch {
+	case c == 'x' || c == 'u':
+		maxlen := 2
+		if c == 'u' {
+			maxlen = 6
+		}
+		n := 1
+		for n <= maxlen && n < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[n]) >= 0 {
+			n++
+		}
+		v, err := strconv.ParseUint(s[1:n], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid escape sequence \\%s", s[:n])
+		}
+		if c == 'u' {
+			tok.WriteRune(rune(v))
+		} else {
+			tok.WriteByte(byte(v))
+		}
+		return n, nil
+	case c >= '0' && c <= '7':
+		n := 1
+		for n < 3 && n < len(s) && s[n] >= '0' && s[n] <= '7' {
+			n++
+		}
+		v, _ := strconv.ParseUint(s[:n], 8, 8)
+		tok.WriteByte(byte(v))
+		return n, nil
+	}
+	// Any other character, including space, '"', '#' and '\', stands for itself
+	r, size := utf8.DecodeRuneInString(s)
+	tok.WriteRune(r)
+	return size, nil
+}
@@ -0,0 +1,313 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteDir writes every format file to its place under directory dir,
+// creating subdirectories as needed
+func (f *Format) WriteDir(dir string) error {
+	for _, frag := range f.Fragments {
+		name := filepath.Join(dir, filepath.FromSlash(frag.Filename))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		file, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		err = f.WriteFragment(file, frag.Index)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeCodes are the single-character data types of Standards Versions before 5
+var typeCodes = map[RetType]string{
+	UINT8: "c", UINT16: "u", INT16: "s", UINT32: "U", INT32: "S", FLOAT32: "f", FLOAT64: "d",
+}
+
+// WriteFragment writes the format file of fragment index to w. Directives are
+// written first, then the fields in order of definition, then /INCLUDE and
+// /REFERENCE directives. A fragment whose Version is below 6 is written in the
+// syntax of that Standards Version: directives without a leading slash and,
+// before Version 5, single-character data types. Anything such a Version cannot
+// express (metafields, aliases, hidden fields, field types and directives of
+// later Versions) is an error.
+func (f *Format) WriteFragment(w io.Writer, index int) error {
+	if index < 0 || index >= len(f.Fragments) {
+		return fmt.Errorf("Fragment index %d out of range", index)
+	}
+	frag := f.Fragments[index]
+	fw := &fragWriter{w: bufio.NewWriter(w), version: frag.Version}
+	fw.ns, fw.prefix, fw.suffix = f.affixes(index)
+
+	if frag.Version >= 0 && (frag.Parent < 0 || f.Fragments[frag.Parent].Version != frag.Version) {
+		fw.line("/VERSION", fmt.Sprint(frag.Version))
+	}
+	if frag.Endianness&(BIGENDIAN|LITTLEENDIAN) != 0 {
+		tokens := []string{"/ENDIAN", "little"}
+		if frag.Endianness&BIGENDIAN != 0 {
+			tokens[1] = "big"
+		}
+		if frag.Endianness&ARMENDIAN != 0 {
+			fw.require("ARM endianness", 8)
+			tokens = append(tokens, "arm")
+		}
+		fw.line(tokens...)
+	}
+	if name, ok := encodingNames[frag.Encoding]; ok {
+		fw.require("/ENCODING", 6)
+		fw.line("/ENCODING", name)
+	}
+	if frag.FrameOffset != 0 {
+		fw.line("/FRAMEOFFSET", fmt.Sprint(frag.FrameOffset))
+	}
+	if frag.Protection > PROTECTNONE && int(frag.Protection) < len(protectNames) {
+		fw.require("/PROTECT", 6)
+		fw.line("/PROTECT", protectNames[frag.Protection])
+	}
+
+	for _, e := range f.Entries {
+		if e.Fragment == index {
+			fw.entry(e)
+		}
+	}
+
+	for _, child := range f.Fragments {
+		if child.Parent != index {
+			continue
+		}
+		rel := child.Filename
+		if dir := f.dir(index); dir != "." {
+			rel = strings.TrimPrefix(rel, dir+"/")
+		}
+		tokens := []string{"/INCLUDE", rel}
+		pfx := child.Prefix
+		if child.Namespace != "" {
+			pfx = child.Namespace + "." + pfx
+		}
+		if pfx != "" || child.Suffix != "" {
+			fw.require("/INCLUDE with affixes", 9)
+			tokens = append(tokens, pfx)
+		}
+		if child.Suffix != "" {
+			tokens = append(tokens, child.Suffix)
+		}
+		fw.line(tokens...)
+	}
+
+	if frag.Reference != "" {
+		fw.require("/REFERENCE", 6)
+		fw.line("/REFERENCE", fw.code(frag.Reference))
+	}
+	if fw.err != nil {
+		return fw.err
+	}
+	return fw.w.Flush()
+}
+
+type fragWriter struct {
+	w       *bufio.Writer
+	version int // the fragment's Standards Version, or -1 if unknown
+	ns      string
+	prefix  string
+	suffix  string
+	err     error
+}
+
+// old reports whether the fragment is written in the syntax of a Standards
+// Version before 6
+func (fw *fragWriter) old() bool {
+	return fw.version >= 0 && fw.version < 6
+}
+
+// require records an error if what, first allowed in Standards Version v,
+// cannot be written in the fragment's Version
+func (fw *fragWriter) require(what string, v int) {
+	if fw.err == nil && fw.version >= 0 && fw.version < v {
+		fw.err = fmt.Errorf("Cannot write %s in Standards Version %d", what, fw.version)
+	}
+}
+
+func (fw *fragWriter) line(tokens ...string) {
+	if fw.err != nil {
+		return
+	}
+	for i, tok := range tokens {
+		if i > 0 {
+			fw.w.WriteByte(' ')
+		}
+		// Directive names and field types never need quoting
+		if i == 0 && strings.HasPrefix(tok, "/") {
+			if fw.old() {
+				tok = tok[1:]
+			}
+			fw.w.WriteString(tok)
+		} else {
+			fw.w.WriteString(quote(tok))
+		}
+	}
+	_, fw.err = fw.w.WriteString("\n")
+}
+
+// code converts a full field code to the form written in this fragment, by
+// removing the namespace and affixes. Codes outside the fragment's namespace,
+// or lacking its affixes, are written with a leading '.'.
+func (fw *fragWriter) code(c string) string {
+	if c == "INDEX" {
+		return c
+	}
+	parent, meta, isMeta := strings.Cut(c, "/")
+	if fw.ns != "" {
+		if !strings.HasPrefix(parent, fw.ns+".") {
+			return "." + c
+		}
+		parent = parent[len(fw.ns)+1:]
+	}
+	if len(parent) < len(fw.prefix)+len(fw.suffix) || !strings.HasPrefix(parent, fw.prefix) ||
+		!strings.HasSuffix(parent, fw.suffix) {
+		return "." + c
+	}
+	parent = parent[len(fw.prefix) : len(parent)-len(fw.suffix)]
+	if isMeta {
+		fw.require("metafield "+c, 6)
+		return parent + "/" + meta
+	}
+	return parent
+}
+
+func (fw *fragWriter) param(p Param) string {
+	if p.IsLiteral() {
+		return p.Literal
+	}
+	if p.Index >= 0 {
+		return fmt.Sprintf("%s<%d>", fw.code(p.Field), p.Index)
+	}
+	return fw.code(p.Field)
+}
+
+func (fw *fragWriter) entry(e *Entry) {
+	name := fw.code(e.Name)
+	if e.Hidden {
+		fw.require("hidden field "+e.Name, 9)
+	}
+	if e.Type == ALIASENTRY {
+		fw.require("/ALIAS "+e.Name, 9)
+		fw.line("/ALIAS", name, fw.code(e.Target))
+		if e.Hidden {
+			fw.line("/HIDDEN", name)
+		}
+		return
+	}
+	if v := entryVersions[e.Type]; v > 0 {
+		fw.require(fmt.Sprintf("%v field %s", e.Type, e.Name), v)
+	}
+	tokens := []string{name, e.Type.String()}
+	in := func(i int) string { return fw.code(e.InFields[i]) }
+	switch e.Type {
+	case RAWENTRY:
+		tokens = append(tokens, fw.dataType(e), fw.param(e.SPF))
+	case LINCOMENTRY:
+		tokens = append(tokens, fmt.Sprint(len(e.InFields)))
+		for i := range e.InFields {
+			tokens = append(tokens, in(i), fw.param(e.Scale[i]), fw.param(e.Offset[i]))
+		}
+	case LINTERPENTRY:
+		tokens = append(tokens, in(0), e.Table)
+	case BITENTRY, SBITENTRY:
+		tokens = append(tokens, in(0), fw.param(e.Bitnum), fw.param(e.Numbits))
+	case MULTIPLYENTRY, DIVIDEENTRY, INDIRENTRY, SINDIRENTRY:
+		tokens = append(tokens, in(0), in(1))
+	case PHASEENTRY:
+		tokens = append(tokens, in(0), fw.param(e.Shift))
+	case POLYNOMENTRY:
+		tokens = append(tokens, in(0))
+		for _, a := range e.Coeffs {
+			tokens = append(tokens, fw.param(a))
+		}
+	case RECIPENTRY:
+		tokens = append(tokens, in(0), fw.param(e.Dividend))
+	case WINDOWENTRY:
+		tokens = append(tokens, in(0), in(1), e.WindowOp.String(), fw.param(e.Threshold))
+	case MPLEXENTRY:
+		tokens = append(tokens, in(0), in(1), fw.param(e.CountVal), fw.param(e.Period))
+	case CONSTENTRY, CARRAYENTRY:
+		tokens = append(tokens, e.DataType.String())
+		tokens = append(tokens, e.Values...)
+	case STRINGENTRY, SARRAYENTRY:
+		tokens = append(tokens, e.Values...)
+	default:
+		fw.err = fmt.Errorf("Cannot write field %s of type %v", e.Name, e.Type)
+		return
+	}
+	fw.line(tokens...)
+	if e.Hidden {
+		fw.line("/HIDDEN", name)
+	}
+}
+
+// entryVersions are the Standards Versions that introduced the later field types
+var entryVersions = map[EntryType]int{
+	CONSTENTRY: 6, STRINGENTRY: 6, SBITENTRY: 7, POLYNOMENTRY: 7, DIVIDEENTRY: 8,
+	RECIPENTRY: 8, CARRAYENTRY: 8, WINDOWENTRY: 9, MPLEXENTRY: 9, INDIRENTRY: 10,
+	SINDIRENTRY: 10, SARRAYENTRY: 10,
+}
+
+// dataType returns the data type of RAW field e as written in the fragment
+func (fw *fragWriter) dataType(e *Entry) string {
+	if fw.version < 0 || fw.version >= 5 {
+		return e.DataType.String()
+	}
+	code, ok := typeCodes[e.DataType]
+	if !ok && fw.err == nil {
+		fw.err = fmt.Errorf("Cannot write %v field %s in Standards Version %d", e.DataType, e.Name, fw.version)
+	}
+	return code
+}
+
+// quote returns tok as a format file token, quoting and escaping it if needed
+func quote(tok string) string {
+	if tok != "" && !strings.ContainsAny(tok, " \t\"#\\") && isPrintable(tok) {
+		return tok
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(tok); i++ {
+		c := tok[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func isPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -1,3 +1,5 @@
+//go:build !purego
+
 package getdata
 
 /*
@@ -53,7 +55,7 @@ func NewFragment(df *Dirfile, index int) (*Fragment, error) {
 	if index > 0 {
 		frag.parent = int(C.gd_parent_fragment(df.d, cidx))
 		if frag.parent < 0 {
-			return nil, df.Error()
+			return nil, df.opError("NewFragment", "")
 		}
 	}
 
@@ -61,7 +63,7 @@ func NewFragment(df *Dirfile, index int) (*Fragment, error) {
 	var pfx, sfx *C.char
 	result := C.gd_fragment_affixes(df.d, cidx, &pfx, &sfx)
 	if result != 0 {
-		return nil, df.Error()
+		return nil, df.opError("NewFragment", "")
 	}
 	frag.prefix = C.GoString(pfx)
 	frag.suffix = C.GoString(sfx)
@@ -75,7 +77,7 @@ func NewFragment(df *Dirfile, index int) (*Fragment, error) {
 func (frag *Fragment) Rewrite() error {
 	result := C.gd_rewrite_fragment(frag.df.d, C.int(frag.index))
 	if result != C.GD_E_OK {
-		return frag.df.Error()
+		return frag.df.opError("Rewrite", "")
 	}
 	return nil
 }
@@ -87,12 +89,38 @@ func (frag *Fragment) SetNamespace(ns string) error {
 	cidx := C.int(frag.index)
 	result := C.gd_fragment_namespace(frag.df.d, cidx, namespace)
 	if result == nullCString {
-		return frag.df.Error()
+		return frag.df.opError("SetNamespace", "")
 	}
 	frag.namespace = ns
 	return nil
 }
 
+// Index returns the index of the fragment in the dirfile
+func (frag Fragment) Index() int {
+	return frag.index
+}
+
+// Name returns the path of the fragment's format file
+func (frag Fragment) Name() string {
+	return frag.name
+}
+
+// Parent returns the index of the fragment which includes this one, or -1 for
+// the primary format file
+func (frag Fragment) Parent() int {
+	return frag.parent
+}
+
+// Namespace returns the fragment's root namespace
+func (frag Fragment) Namespace() string {
+	return frag.namespace
+}
+
+// Protection returns the protection level of the fragment
+func (frag Fragment) Protection() Flags {
+	return frag.protection
+}
+
 // Prefix returns the fragment field name prefix
 func (frag Fragment) Prefix() string {
 	return frag.prefix
@@ -105,7 +133,7 @@ func (frag *Fragment) SetPrefix(prefix string) error {
 	cidx := C.int(frag.index)
 	result := C.gd_alter_affixes(frag.df.d, cidx, cprefix, nullCString)
 	if result < 0 {
-		return frag.df.Error()
+		return frag.df.opError("SetPrefix", "")
 	}
 	frag.prefix = prefix
 	return nil
@@ -123,7 +151,7 @@ func (frag *Fragment) SetSuffix(suffix string) error {
 	cidx := C.int(frag.index)
 	result := C.gd_alter_affixes(frag.df.d, cidx, nullCString, csuffix)
 	if result < 0 {
-		return frag.df.Error()
+		return frag.df.opError("SetSuffix", "")
 	}
 	frag.suffix = suffix
 	return nil
@@ -137,16 +165,23 @@ func (frag Fragment) Encoding() Flags {
 // SetEncoding changes the encoding system of all RAW entries in the fragment to the
 // given encoding scheme. If recode is true, then associated binary files will be re-encoded.
 func (frag *Fragment) SetEncoding(encoding Flags, recode bool) error {
-	cidx := C.int(frag.index)
+	if err := frag.df.alterEncoding(frag.index, encoding, recode); err != nil {
+		return err
+	}
+	frag.encoding = encoding
+	return nil
+}
+
+// alterEncoding changes the encoding of fragment index, without updating any Fragment
+func (df *Dirfile) alterEncoding(index int, encoding Flags, recode bool) error {
 	var rc C.int
 	if recode {
 		rc = 1
 	}
-	result := C.gd_alter_encoding(frag.df.d, C.ulong(encoding), cidx, rc)
+	result := C.gd_alter_encoding(df.d, C.ulong(encoding), C.int(index), rc)
 	if result < 0 {
-		return frag.df.Error()
+		return df.opError("SetEncoding", "")
 	}
-	frag.encoding = encoding
 	return nil
 }
 
@@ -159,16 +194,23 @@ func (frag Fragment) Endianness() Flags {
 // given scheme. The bytesex should be one of BIGENDIAN, LITTLEENDIAN, NATIVEENDIAN,
 // or NONNATIVEENDIAN. If recode is true, then associated binary files will be re-encoded.
 func (frag *Fragment) SetEndianness(bytesex Flags, recode bool) error {
-	cidx := C.int(frag.index)
+	if err := frag.df.alterEndianness(frag.index, bytesex, recode); err != nil {
+		return err
+	}
+	frag.endianness = bytesex
+	return nil
+}
+
+// alterEndianness changes the byte sex of fragment index, without updating any Fragment
+func (df *Dirfile) alterEndianness(index int, bytesex Flags, recode bool) error {
 	var rc C.int
 	if recode {
 		rc = 1
 	}
-	result := C.gd_alter_endianness(frag.df.d, C.ulong(bytesex), cidx, rc)
+	result := C.gd_alter_endianness(df.d, C.ulong(bytesex), C.int(index), rc)
 	if result < 0 {
-		return frag.df.Error()
+		return df.opError("SetEndianness", "")
 	}
-	frag.endianness = bytesex
 	return nil
 }
 
@@ -180,16 +222,23 @@ func (frag Fragment) FrameOffset() uint {
 // SetFrameOffset changes the frame offset of RAW fields in a given fragment to the
 // given offset. If recode is true, then associated binary files will be re-encoded.
 func (frag *Fragment) SetFrameOffset(offset uint, recode bool) error {
-	cidx := C.int(frag.index)
+	if err := frag.df.alterFrameOffset(frag.index, offset, recode); err != nil {
+		return err
+	}
+	frag.frameoff = offset
+	return nil
+}
+
+// alterFrameOffset changes the frame offset of fragment index, without updating any Fragment
+func (df *Dirfile) alterFrameOffset(index int, offset uint, recode bool) error {
 	var rc C.int
 	if recode {
 		rc = 1
 	}
-	result := C.gd_alter_frameoffset(frag.df.d, C.off_t(offset), cidx, rc)
+	result := C.gd_alter_frameoffset(df.d, C.off_t(offset), C.int(index), rc)
 	if result < 0 {
-		return frag.df.Error()
+		return df.opError("SetFrameOffset", "")
 	}
-	frag.frameoff = offset
 	return nil
 }
 
@@ -197,7 +246,7 @@ func (frag *Fragment) SetFrameOffset(offset uint, recode bool) error {
 func (frag *Fragment) SetProtection(level Flags) error {
 	result := C.gd_alter_protection(frag.df.d, C.int(level), C.int(frag.index))
 	if result != C.GD_E_OK {
-		return frag.df.Error()
+		return frag.df.opError("SetProtection", "")
 	}
 	frag.protection = level
 	return nil
@@ -0,0 +1,259 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"reflect"
+)
+
+// Decimation selects how GetFrames reduces a field sampled faster than the table
+type Decimation int
+
+const (
+	// DecimateSubsample takes the first sample of each interval
+	DecimateSubsample Decimation = iota
+	// DecimateMean takes the mean of the samples in each interval
+	DecimateMean
+	// DecimateHold takes the last sample of each interval
+	DecimateHold
+)
+
+// Upsampling selects how GetFrames fills in a field sampled slower than the table
+type Upsampling int
+
+const (
+	// UpsampleRepeat repeats each sample until the next one
+	UpsampleRepeat Upsampling = iota
+	// UpsampleLinear interpolates linearly between samples
+	UpsampleLinear
+)
+
+// FrameOptions control how GetFramesOptions aligns fields. The zero value gives
+// the behaviour of GetFrames.
+type FrameOptions struct {
+	SPF        int // samples per frame of the table, or 0 for the reference field's
+	Decimation Decimation
+	Upsampling Upsampling
+	Type       RetType // if not NULLTYPE, read every column as this type
+}
+
+// Column is one field of a FrameTable
+type Column struct {
+	Field     string
+	Type      RetType     // the type of the values in Data
+	NativeSPF int         // the samples per frame of the field itself
+	Data      interface{} // a slice of Type holding FrameTable.SPF samples per frame
+}
+
+// FrameTable holds a range of frames of several fields, resampled to a common
+// number of samples per frame, as columns
+type FrameTable struct {
+	FirstFrame int
+	NFrames    int
+	SPF        int
+	Columns    []Column
+}
+
+// Column returns the column for the given field, or nil if there is none
+func (t *FrameTable) Column(field string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Field == field {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// ColumnData returns the data of the column for fieldcode as a slice of T
+func ColumnData[T Numeric](t *FrameTable, fieldcode string) ([]T, error) {
+	c := t.Column(fieldcode)
+	if c == nil {
+		return nil, newError(ErrArgument, "ColumnData", fieldcode, "FrameTable has no column %s", fieldcode)
+	}
+	data, ok := c.Data.([]T)
+	if !ok {
+		return nil, newError(ErrBadType, "ColumnData", fieldcode, "Column %s holds %T, not %T", fieldcode, c.Data, data)
+	}
+	return data, nil
+}
+
+// GetFrames reads numFrames frames of each of the given fields, starting at
+// firstFrame, and returns them as columns aligned to the sample rate of the
+// reference field. Faster fields are subsampled and slower ones repeated;
+// each column keeps its field's native type. See GetFramesOptions.
+func (df *Dirfile) GetFrames(fields []string, firstFrame, numFrames int) (*FrameTable, error) {
+	return df.GetFramesOptions(fields, firstFrame, numFrames, FrameOptions{})
+}
+
+// GetFramesOptions reads numFrames frames of each of the given fields, starting
+// at firstFrame, and returns them as columns aligned to opts.SPF samples per
+// frame, or to the reference field's rate if that is 0. Columns keep the native
+// types of their fields unless opts.Type is set, except that columns averaged
+// by DecimateMean or interpolated by UpsampleLinear hold floating-point values:
+// FLOAT64 in place of any integer type. If any field ends before the last frame
+// requested, the table ends with that field's last complete frame.
+func (df *Dirfile) GetFramesOptions(fields []string, firstFrame, numFrames int, opts FrameOptions) (*FrameTable, error) {
+	return ReadFrames(df, fields, firstFrame, numFrames, opts)
+}
+
+// ReadFrames is GetFramesOptions for any ReadOnly dirfile, such as an FSDirfile
+func ReadFrames(df ReadOnly, fields []string, firstFrame, numFrames int, opts FrameOptions) (*FrameTable, error) {
+	if len(fields) == 0 || numFrames <= 0 {
+		return nil, newError(ErrArgument, "GetFrames", "", "GetFrames needs at least one field and a positive number of frames")
+	}
+	spf := opts.SPF
+	if spf <= 0 {
+		ref, err := df.GetReference()
+		if err != nil {
+			return nil, err
+		}
+		if spf = df.SPF(ref.Name()); spf <= 0 {
+			return nil, readError(df, "GetFrames", ref.Name())
+		}
+	}
+	table := &FrameTable{FirstFrame: firstFrame, NFrames: numFrames, SPF: spf,
+		Columns: make([]Column, len(fields))}
+	for i, field := range fields {
+		c := &table.Columns[i]
+		c.Field = field
+		if c.NativeSPF = df.SPF(field); c.NativeSPF <= 0 {
+			return nil, readError(df, "GetFrames", field)
+		}
+		c.Type = opts.Type
+		if c.Type == NULLTYPE {
+			if c.Type = df.NativeType(field); c.Type == UNKNOWN {
+				return nil, readError(df, "GetFrames", field)
+			}
+		}
+		arithmetic := (c.NativeSPF > spf && opts.Decimation == DecimateMean) ||
+			(c.NativeSPF < spf && opts.Upsampling == UpsampleLinear)
+		if arithmetic {
+			c.Type = arithmeticType(c.Type)
+		}
+		nframes, err := readColumn(df, c, firstFrame, numFrames, spf, opts)
+		if err != nil {
+			return nil, err
+		}
+		table.NFrames = min(table.NFrames, nframes)
+	}
+	for i := range table.Columns {
+		c := &table.Columns[i]
+		c.Data = reflect.ValueOf(c.Data).Slice(0, table.NFrames*spf).Interface()
+	}
+	return table, nil
+}
+
+// arithmeticType returns the type in which averages or interpolations of values
+// of type t are returned
+func arithmeticType(t RetType) RetType {
+	switch t {
+	case FLOAT32, COMPLEX64, COMPLEX128:
+		return t
+	}
+	return FLOAT64
+}
+
+// readColumn reads the column c, resampled to spf samples per frame, and
+// returns the number of complete frames read
+func readColumn(df ReadOnly, c *Column, firstFrame, numFrames, spf int, opts FrameOptions) (int, error) {
+	switch c.Type {
+	case UINT8:
+		return readColumnAs[uint8](df, c, firstFrame, numFrames, spf, opts)
+	case INT8:
+		return readColumnAs[int8](df, c, firstFrame, numFrames, spf, opts)
+	case UINT16:
+		return readColumnAs[uint16](df, c, firstFrame, numFrames, spf, opts)
+	case INT16:
+		return readColumnAs[int16](df, c, firstFrame, numFrames, spf, opts)
+	case UINT32:
+		return readColumnAs[uint32](df, c, firstFrame, numFrames, spf, opts)
+	case INT32:
+		return readColumnAs[int32](df, c, firstFrame, numFrames, spf, opts)
+	case UINT64:
+		return readColumnAs[uint64](df, c, firstFrame, numFrames, spf, opts)
+	case INT64:
+		return readColumnAs[int64](df, c, firstFrame, numFrames, spf, opts)
+	case FLOAT32:
+		return readColumnAs[float32](df, c, firstFrame, numFrames, spf, opts)
+	case FLOAT64:
+		return readColumnAs[float64](df, c, firstFrame, numFrames, spf, opts)
+	case COMPLEX64:
+		return readColumnAs[complex64](df, c, firstFrame, numFrames, spf, opts)
+	case COMPLEX128:
+		return readColumnAs[complex128](df, c, firstFrame, numFrames, spf, opts)
+	}
+	return 0, newError(ErrBadType, "GetFrames", c.Field, "GetFrames cannot read field %s as type 0x%x", c.Field, c.Type)
+}
+
+// readColumnAs reads the column c as type T; see readColumn
+func readColumnAs[T Numeric](df ReadOnly, c *Column, firstFrame, numFrames, spf int, opts FrameOptions) (int, error) {
+	extra := 0
+	if c.NativeSPF < spf && opts.Upsampling == UpsampleLinear {
+		extra = 1 // the first sample of the next frame, to interpolate towards
+	}
+	var in []T
+	if _, err := df.GetDataAlloc(c.Field, firstFrame, 0, numFrames, extra, &in); err != nil {
+		return 0, err
+	}
+	nframes := min(len(in)/c.NativeSPF, numFrames)
+	out := make([]T, nframes*spf)
+	switch {
+	case c.NativeSPF == spf:
+		copy(out, in)
+	case c.NativeSPF > spf:
+		decimate(out, in, c.NativeSPF, spf, opts.Decimation)
+	default:
+		upsample(out, in, c.NativeSPF, spf, opts.Upsampling)
+	}
+	c.Data = out
+	return nframes, nil
+}
+
+// decimate fills out, at outSPF samples per frame, from the faster samples in
+func decimate[T Numeric](out, in []T, inSPF, outSPF int, method Decimation) {
+	for j := range out {
+		start, end := j*inSPF/outSPF, (j+1)*inSPF/outSPF
+		switch method {
+		case DecimateMean:
+			var sum, count T
+			for _, v := range in[start:end] {
+				sum += v
+				count++
+			}
+			out[j] = sum / count
+		case DecimateHold:
+			out[j] = in[end-1]
+		default:
+			out[j] = in[start]
+		}
+	}
+}
+
+// upsample fills out, at outSPF samples per frame, from the slower samples in
+func upsample[T Numeric](out, in []T, inSPF, outSPF int, method Upsampling) {
+	for j := range out {
+		i, rem := j*inSPF/outSPF, j*inSPF%outSPF
+		if method == UpsampleLinear && rem != 0 && i+1 < len(in) {
+			out[j] = in[i] + (in[i+1]-in[i])*fraction[T](rem, outSPF)
+		} else {
+			out[j] = in[i]
+		}
+	}
+}
+
+// fraction returns num/den as type T, which must be a floating-point or complex
+// type (it is zero for integer types)
+func fraction[T Numeric](num, den int) T {
+	var v T
+	switch p := any(&v).(type) {
+	case *float32:
+		*p = float32(num) / float32(den)
+	case *float64:
+		*p = float64(num) / float64(den)
+	case *complex64:
+		*p = complex(float32(num)/float32(den), 0)
+	case *complex128:
+		*p = complex(float64(num)/float64(den), 0)
+	}
+	return v
+}
@@ -0,0 +1,165 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestResample(t *testing.T) {
+	in := []float64{1, 2, 3, 4, 5, 6}
+	out := make([]float64, 4)
+	for method, want := range map[Decimation][]float64{
+		DecimateSubsample: {1, 2, 4, 5},
+		DecimateMean:      {1, 2.5, 4, 5.5},
+		DecimateHold:      {1, 3, 4, 6},
+	} {
+		decimate(out, in, 3, 2, method)
+		if !slices.Equal(out, want) {
+			t.Errorf("decimate(%d) = %v, want %v", method, out, want)
+		}
+	}
+
+	in = []float64{0, 10, 20}
+	out = make([]float64, 6)
+	upsample(out, in, 1, 3, UpsampleRepeat)
+	if want := []float64{0, 0, 0, 10, 10, 10}; !slices.Equal(out, want) {
+		t.Errorf("upsample(repeat) = %v, want %v", out, want)
+	}
+	in = []float64{0, 30, 60, 90}
+	upsample(out, in, 2, 3, UpsampleLinear)
+	if want := []float64{0, 20, 40, 60, 80, 90}; !slices.EqualFunc(out, want, func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-12
+	}) {
+		t.Errorf("upsample(linear) = %v, want %v", out, want)
+	}
+	c := make([]complex64, 2)
+	upsample(c, []complex64{2i, 4i}, 1, 2, UpsampleLinear)
+	if want := []complex64{2i, 3i}; !slices.Equal(c, want) {
+		t.Errorf("upsample(linear) of complex = %v, want %v", c, want)
+	}
+}
+
+func TestGetFrames(t *testing.T) {
+	dir := "dirfile_frames"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	// "data" (8 samples per frame) is the reference field
+	table, err := d.GetFrames([]string{"data", "INDEX"}, 8, 5)
+	if err != nil {
+		t.Fatal("GetFrames failed:", err)
+	}
+	if table.SPF != 8 || table.NFrames != 2 || len(table.Columns) != 2 {
+		t.Errorf("GetFrames returned SPF %d, %d frames, %d columns, want 8, 2, 2",
+			table.SPF, table.NFrames, len(table.Columns))
+	}
+	if c := table.Column("data"); c == nil || c.Type != INT8 || c.NativeSPF != 8 {
+		t.Errorf("Column(data) = %+v", c)
+	}
+	if data, err := ColumnData[int8](table, "data"); err != nil || len(data) != 16 || data[0] != 65 {
+		t.Errorf("ColumnData(data) = %v (%v)", data, err)
+	}
+	index, err := ColumnData[uint64](table, "INDEX")
+	if want := []uint64{8, 8, 8, 8, 8, 8, 8, 8, 9, 9, 9, 9, 9, 9, 9, 9}; err != nil || !slices.Equal(index, want) {
+		t.Errorf("ColumnData(INDEX) = %v (%v), want %v", index, err, want)
+	}
+	if _, err = ColumnData[float64](table, "data"); !errors.Is(err, ErrBadType) {
+		t.Errorf("ColumnData of the wrong type returned %v, want ErrBadType", err)
+	}
+	if _, err = ColumnData[int8](table, "missing"); !errors.Is(err, ErrArgument) {
+		t.Errorf("ColumnData of a missing column returned %v, want ErrArgument", err)
+	}
+	if _, err = d.GetFrames(nil, 0, 1); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetFrames of no fields returned %v, want ErrArgument", err)
+	}
+
+	table, err = d.GetFramesOptions([]string{"data", "INDEX"}, 0, 1,
+		FrameOptions{SPF: 2, Decimation: DecimateMean, Upsampling: UpsampleLinear})
+	if err != nil {
+		t.Fatal("GetFramesOptions failed:", err)
+	}
+	if data, err := ColumnData[float64](table, "data"); err != nil || !slices.Equal(data, []float64{2.5, 6.5}) {
+		t.Errorf("Mean of data = %v (%v), want [2.5 6.5]", data, err)
+	}
+	if index, err := ColumnData[float64](table, "INDEX"); err != nil || !slices.Equal(index, []float64{0, 0.5}) {
+		t.Errorf("Interpolated INDEX = %v (%v), want [0 0.5]", index, err)
+	}
+}
+
+func TestReadOnlyFS(t *testing.T) {
+	d, err := OpenDirfileFS(zipDirfile(t), "flight/dirfile")
+	if err != nil {
+		t.Fatal("OpenDirfileFS failed:", err)
+	}
+	defer d.Close()
+
+	e, err := d.Entry("double")
+	if err != nil {
+		t.Fatal("Entry(double) failed:", err)
+	}
+	if in, _ := e.InFields(); e.Type() != LINCOMENTRY || !slices.Equal(in, []string{"data"}) {
+		t.Errorf("Entry(double) is type 0x%x with inputs %v, want a LINCOM of data", e.Type(), in)
+	}
+	if m, err := e.Scale(0); err != nil || m != 2 {
+		t.Errorf("Entry(double).Scale(0) = %v (%v), want 2", m, err)
+	}
+	if c, err := d.Entry("const"); err != nil || c.Type() != CONSTENTRY {
+		t.Errorf("Entry(const) = %v (%v)", c, err)
+	} else if ct, _ := c.ConstType(); ct != FLOAT64 || c.value != 5.5 {
+		t.Errorf("Entry(const) holds %v of type 0x%x, want FLOAT64 5.5", c.value, ct)
+	}
+	if et := d.EntryType("name"); et != STRINGENTRY {
+		t.Errorf("EntryType(name) = 0x%x, want STRINGENTRY", et)
+	}
+	if ref, err := d.GetReference(); err != nil || ref.Name() != "data" {
+		t.Errorf("GetReference = %s (%v), want data", ref.Name(), err)
+	}
+	if m, err := d.MatchEntries("^d", ALLFRAGMENTS, VECTORENTRIES, 0); err != nil ||
+		!slices.Equal(m, []string{"data", "double"}) {
+		t.Errorf("MatchEntries(^d) = %v (%v), want [data double]", m, err)
+	}
+	if m, _ := d.MatchEntries("", ALLFRAGMENTS, CONSTENTRY, Flags(HIDDENENTRIES)); !slices.Equal(m, []string{"const", "secret"}) {
+		t.Errorf("MatchEntries of CONST fields = %v, want [const secret]", m)
+	}
+
+	var ro ReadOnly = &d
+	table, err := ReadFrames(ro, []string{"data", "double"}, 1, 2, FrameOptions{SPF: 2})
+	if err != nil {
+		t.Fatal("ReadFrames failed:", err)
+	}
+	if data, _ := ColumnData[float64](table, "double"); !slices.Equal(data, []float64{18, 26, 34, 42}) {
+		t.Errorf("ReadFrames column double = %v", data)
+	}
+
+	r, err := NewFieldReader[int64](ro, "data", 30)
+	if err != nil {
+		t.Fatal("NewFieldReader failed:", err)
+	}
+	var sizes []int
+	for block := range r.Blocks() {
+		sizes = append(sizes, len(block))
+	}
+	if r.Err() != nil || !slices.Equal(sizes, []int{30, 30, 20}) {
+		t.Errorf("FieldReader read blocks of %v (%v), want [30 30 20]", sizes, r.Err())
+	}
+
+	// data holds 1, 2, ... 80: use it as times
+	ti, err := NewTimeIndex(ro, "data")
+	if err != nil {
+		t.Fatal("NewTimeIndex failed:", err)
+	}
+	if f, err := ti.FrameAt(time.Unix(41, 0)); err != nil || f != 5 {
+		t.Errorf("FrameAt(41) = %v (%v), want 5", f, err)
+	}
+}
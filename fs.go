@@ -0,0 +1,321 @@
+package getdata
+
+import (
+	"errors"
+	"io/fs"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/joefowler/gogetdata/format"
+	"github.com/joefowler/gogetdata/pure"
+)
+
+// FSDirfile is a read-only dirfile read from an fs.FS (e.g., a zip.Reader,
+// embed.FS or os.DirFS) by package pure, without the GetData C library. It
+// offers the read API of Dirfile for RAW fields stored unencoded or
+// gzip-encoded, the derived fields computed from them, and scalar fields.
+// Errors are *Error values, with the code of the matching C API error, so they
+// can be tested as for a Dirfile, e.g. errors.Is(err, ErrBadCode).
+type FSDirfile struct {
+	name string
+	p    *pure.Dirfile
+	err  error
+}
+
+// OpenDirfileFS opens the dirfile in directory path of fsys read-only. path
+// uses '/' separators, as for fs.FS; use "." for a dirfile at the root of fsys.
+func OpenDirfileFS(fsys fs.FS, path string) (FSDirfile, error) {
+	p, err := pure.OpenFS(fsys, path)
+	if err != nil {
+		return FSDirfile{}, pureError("OpenDirfile", "", err)
+	}
+	return FSDirfile{name: path, p: p}, nil
+}
+
+// pureCodes are the error codes matching the sentinel errors of packages pure
+// and format
+var pureCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{pure.ErrBadCode, ErrBadCode},
+	{pure.ErrBadFieldType, ErrBadFieldType},
+	{pure.ErrUnsupported, ErrUnsupported},
+	{pure.ErrBadType, ErrBadType},
+	{pure.ErrRange, ErrRange},
+	{pure.ErrBadScalar, ErrBadScalar},
+	{pure.ErrLUT, ErrLUT},
+	{pure.ErrRecurse, ErrRecurseLevel},
+	{format.ErrRecurse, ErrRecurseLevel},
+}
+
+// pureError returns err, from package pure, as an *Error with the matching
+// error code (or nil if err is nil)
+func pureError(op, fieldcode string, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{Op: op, Field: fieldcode, Msg: err.Error()}
+	for _, c := range pureCodes {
+		if errors.Is(err, c.err) {
+			e.Code = c.code
+			return e
+		}
+	}
+	var syntax *format.SyntaxError
+	var pathErr *fs.PathError
+	if errors.As(err, &syntax) {
+		e.Code = ErrFormat
+	} else if errors.As(err, &pathErr) {
+		e.Code = ErrIO
+	}
+	return e
+}
+
+// Error returns the error raised by the latest method that reported failure by
+// its return value alone (e.g., NFrames returning 0)
+func (df *FSDirfile) Error() error {
+	return df.err
+}
+
+// Close releases the dirfile. Closing it again does nothing.
+func (df *FSDirfile) Close() error {
+	if df.p == nil {
+		return nil
+	}
+	err := df.p.Close()
+	df.p = nil
+	return err
+}
+
+// Dirfilename returns the path to the dirfile
+func (df *FSDirfile) Dirfilename() string {
+	return df.name
+}
+
+// MplexLookback changes how many MPLEX cycles GetData searches backwards for
+// the initial value of a MPLEX field (pure.LOOKBACKALL to search to the start)
+func (df *FSDirfile) MplexLookback(lookback int) {
+	df.p.MplexLookback(lookback)
+}
+
+// NFrames returns the number of frames in the dirfile (or on error, 0)
+func (df *FSDirfile) NFrames() int {
+	n, err := df.p.NFrames()
+	df.err = pureError("NFrames", "", err)
+	return n
+}
+
+// SPF returns the number of samples per frame for a given field (or on error, 0)
+func (df *FSDirfile) SPF(fieldcode string) int {
+	spf, err := df.p.SPF(fieldcode)
+	df.err = pureError("SPF", fieldcode, err)
+	return spf
+}
+
+// EoF returns the end-of-field position, in samples (or on error, -1)
+func (df *FSDirfile) EoF(fieldcode string) int {
+	eof, err := df.p.EoF(fieldcode)
+	if df.err = pureError("EoF", fieldcode, err); df.err != nil {
+		return -1
+	}
+	return eof
+}
+
+// NativeType returns the native data type of a field (or on error, UNKNOWN)
+func (df *FSDirfile) NativeType(fieldcode string) RetType {
+	t, err := df.p.NativeType(fieldcode)
+	df.err = pureError("NativeType", fieldcode, err)
+	if err != nil {
+		return UNKNOWN
+	}
+	return RetType(t)
+}
+
+// FieldList returns a slice of strings listing all fields (no metafields).
+func (df *FSDirfile) FieldList() []string {
+	return df.p.FieldList()
+}
+
+// VectorList returns a slice of strings listing all vector fields (no metafields).
+func (df *FSDirfile) VectorList() []string {
+	return df.p.VectorList()
+}
+
+// NFragments returns the number of fragments in the dirfile
+func (df *FSDirfile) NFragments() int {
+	return len(df.p.Format().Fragments)
+}
+
+// rawEntry returns the entry for fieldcode, not following aliases
+func (df *FSDirfile) rawEntry(op, fieldcode string) (*format.Entry, error) {
+	e := df.p.Format().RawEntry(fieldcode)
+	if e == nil {
+		return nil, newError(ErrBadCode, op, fieldcode, "Field not found: %s", fieldcode)
+	}
+	return e, nil
+}
+
+// FragmentIndex returns the index of the fragment which defines a given field or alias
+func (df *FSDirfile) FragmentIndex(fieldcode string) (int, error) {
+	if fieldcode == "INDEX" {
+		return 0, nil
+	}
+	e, err := df.rawEntry("FragmentIndex", fieldcode)
+	if err != nil {
+		return 0, err
+	}
+	return e.Fragment, nil
+}
+
+// Hidden returns whether a given field code is hidden or not
+func (df *FSDirfile) Hidden(fieldcode string) (bool, error) {
+	if fieldcode == "INDEX" {
+		return false, nil
+	}
+	e, err := df.rawEntry("Hidden", fieldcode)
+	if err != nil {
+		return false, err
+	}
+	return e.Hidden, nil
+}
+
+// Validate checks whether a given field code is valid, returning error if it
+// isn't: the field must exist and, if it is a vector field, so must its inputs.
+func (df *FSDirfile) Validate(fieldcode string) error {
+	e, err := df.p.Entry(fieldcode)
+	if err == nil && !e.Type.IsScalar() {
+		_, err = df.p.NativeType(fieldcode)
+	}
+	return pureError("Validate", fieldcode, err)
+}
+
+// MFieldList returns a slice of strings listing the names of all metafields
+// for a particular parent (no hidden metafields).
+func (df *FSDirfile) MFieldList(parent string) []string {
+	var result []string
+	for _, e := range df.p.Format().Entries {
+		if name, ok := strings.CutPrefix(e.Name, parent+"/"); ok && !e.Hidden {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// ArrayLen returns the number of elements in a scalar field (CARRAY, CONST,
+// SARRAY, or STRING), or on error, 0
+func (df *FSDirfile) ArrayLen(fieldcode string) int {
+	e, err := df.p.Entry(fieldcode)
+	if df.err = pureError("ArrayLen", fieldcode, err); err != nil {
+		return 0
+	}
+	if !e.Type.IsScalar() {
+		df.err = newError(ErrBadFieldType, "ArrayLen", fieldcode, "Field %s is not a scalar field", fieldcode)
+		return 0
+	}
+	return len(e.Values)
+}
+
+// BoF returns the beginning-of-field position, in samples (or on error, -1)
+func (df *FSDirfile) BoF(fieldcode string) int {
+	bof, err := df.p.BoF(fieldcode)
+	if df.err = pureError("BoF", fieldcode, err); df.err != nil {
+		return -1
+	}
+	return bof
+}
+
+// Framenum performs reverse look-up on a field, returning the (fractional) frame
+// at which it reaches value (or on error, NaN). Assumes the entire field is
+// monotonic.
+func (df *FSDirfile) Framenum(fieldcode string, value float64) float64 {
+	frame, err := df.p.Framenum(fieldcode, value)
+	if df.err = pureError("Framenum", fieldcode, err); df.err != nil {
+		return math.NaN()
+	}
+	return frame
+}
+
+// GetData fetches data from a vector field in the dirfile.
+// out should be a *pointer to* a slice of numeric data of adequate size, e.g.
+// d := make([]int32, 20)
+// df.GetData("field", 5, 0, 2, 0, &d)
+// Returns (n, err) where n is the number of samples read.
+func (df *FSDirfile) GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	n, err := df.p.GetData(fieldcode, firstFrame, firstSample, numFrames, numSamples, out)
+	df.err = pureError("GetData", fieldcode, err)
+	return n, df.err
+}
+
+// GetDataAlloc fetches data like GetData, sizing the output slice itself. out
+// should be a *pointer to* a slice of numeric data of any length (even nil); on
+// return it holds the n samples actually read.
+func (df *FSDirfile) GetDataAlloc(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	spf := df.SPF(fieldcode)
+	if spf == 0 {
+		return 0, df.err
+	}
+	requested := numFrames*spf + numSamples
+	if requested <= 0 {
+		return 0, errZeroLength("GetDataAlloc", fieldcode)
+	}
+	// INDEX has no end, so read all that was requested
+	if fieldcode != "INDEX" {
+		eof := df.EoF(fieldcode)
+		if eof < 0 {
+			return 0, df.err
+		}
+		requested = max(min(requested, eof-(firstFrame*spf+firstSample)), 0)
+	}
+	if !resizeOut(out, requested) {
+		return 0, newError(ErrArgument, "GetDataAlloc", fieldcode, "GetDataAlloc out variable was not a pointer to a slice")
+	}
+	if requested == 0 {
+		df.err = nil
+		return 0, nil
+	}
+	n, err := df.GetData(fieldcode, firstFrame, firstSample, 0, requested, out)
+	resizeOut(out, n)
+	return n, err
+}
+
+// resizeOut sets the slice pointed to by out to length n, reusing its
+// underlying array if the capacity is sufficient. It returns false if out is
+// not a pointer to a slice.
+func resizeOut(out interface{}, n int) bool {
+	p := reflect.ValueOf(out)
+	if p.Kind() != reflect.Pointer || p.IsNil() || p.Elem().Kind() != reflect.Slice {
+		return false
+	}
+	if s := p.Elem(); s.Cap() < n {
+		s.Set(reflect.MakeSlice(s.Type(), n, n))
+	} else {
+		s.SetLen(n)
+	}
+	return true
+}
+
+// GetConstant fills the numeric type pointed to by inptr with the value of a
+// CONST field (including metafields)
+func (df *FSDirfile) GetConstant(fieldcode string, inptr interface{}) error {
+	return pureError("GetConstant", fieldcode, df.p.GetConstant(fieldcode, inptr))
+}
+
+// GetCarray fills the numeric slice pointed to by out with the values of all
+// elements in a CARRAY field (including metafields)
+func (df *FSDirfile) GetCarray(fieldcode string, out interface{}) error {
+	return pureError("GetCarray", fieldcode, df.p.GetCarray(fieldcode, out))
+}
+
+// GetString returns the value of a STRING field (including metafields)
+func (df *FSDirfile) GetString(fieldcode string) (string, error) {
+	s, err := df.p.GetString(fieldcode)
+	return s, pureError("GetString", fieldcode, err)
+}
+
+// GetSarray returns the values of all elements in an SARRAY field (including metafields)
+func (df *FSDirfile) GetSarray(fieldcode string) ([]string, error) {
+	values, err := df.p.GetSarray(fieldcode)
+	return values, pureError("GetSarray", fieldcode, err)
+}
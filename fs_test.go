@@ -0,0 +1,175 @@
+package getdata
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+// zipDirfile returns a zip archive holding a small dirfile in directory flight/dirfile
+func zipDirfile(t *testing.T) *zip.Reader {
+	t.Helper()
+	files := map[string][]byte{
+		"flight/dirfile/format": []byte("/ENDIAN little\ndata RAW INT8 8\ndouble LINCOM data 2 0\n" +
+			"const CONST FLOAT64 5.5\nname STRING \"Zaphod Beeblebrox\"\n" +
+			"data/units STRING V\nsecret CONST UINT8 3\n/HIDDEN secret\n"),
+		"flight/dirfile/data": testdirfile.Data(),
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestOpenDirfileFS(t *testing.T) {
+	zr := zipDirfile(t)
+	if _, err := OpenDirfileFS(zr, "flight/missing"); err == nil {
+		t.Error("OpenDirfileFS on a missing directory did not fail")
+	}
+	d, err := OpenDirfileFS(zr, "flight/dirfile")
+	if err != nil {
+		t.Fatal("OpenDirfileFS failed:", err)
+	}
+	defer d.Close()
+
+	if name := d.Dirfilename(); name != "flight/dirfile" {
+		t.Errorf("Dirfilename = %q", name)
+	}
+	if nf := d.NFrames(); nf != 10 {
+		t.Errorf("NFrames = %d, want 10", nf)
+	}
+	if fl := d.FieldList(); !slices.Equal(fl, []string{"INDEX", "data", "double", "const", "name"}) {
+		t.Errorf("FieldList = %v", fl)
+	}
+	if vl := d.VectorList(); !slices.Equal(vl, []string{"INDEX", "data", "double"}) {
+		t.Errorf("VectorList = %v", vl)
+	}
+
+	out := make([]int32, 8)
+	if n, err := d.GetData("double", 2, 0, 1, 0, &out); err != nil || n != 8 ||
+		!slices.Equal(out, []int32{34, 36, 38, 40, 42, 44, 46, 48}) {
+		t.Errorf("GetData(double) = %v, %d (%v)", out, n, err)
+	}
+	var alloc []uint8
+	if n, err := d.GetDataAlloc("data", 9, 4, 2, 0, &alloc); err != nil || n != 4 ||
+		!slices.Equal(alloc, []uint8{77, 78, 79, 80}) {
+		t.Errorf("GetDataAlloc(data) = %v, %d (%v)", alloc, n, err)
+	}
+	var index []float64
+	if n, err := d.GetDataAlloc("INDEX", 20, 0, 0, 2, &index); err != nil || n != 2 ||
+		!slices.Equal(index, []float64{20, 21}) {
+		t.Errorf("GetDataAlloc(INDEX) = %v, %d (%v)", index, n, err)
+	}
+
+	var c float32
+	if err := d.GetConstant("const", &c); err != nil || c != 5.5 {
+		t.Errorf("GetConstant(const) = %v (%v), want 5.5", c, err)
+	}
+	if s, err := d.GetString("name"); err != nil || s != "Zaphod Beeblebrox" {
+		t.Errorf("GetString(name) = %q (%v)", s, err)
+	}
+}
+
+func TestFSDirfileErrors(t *testing.T) {
+	d, err := OpenDirfileFS(zipDirfile(t), "flight/dirfile")
+	if err != nil {
+		t.Fatal("OpenDirfileFS failed:", err)
+	}
+	defer d.Close()
+
+	if eof := d.EoF("missing"); eof != -1 || !errors.Is(d.Error(), ErrBadCode) {
+		t.Errorf("EoF(missing) = %d with error %v, want -1 and ErrBadCode", eof, d.Error())
+	}
+	var e *Error
+	if !errors.As(d.Error(), &e) || e.Op != "EoF" || e.Field != "missing" {
+		t.Errorf("EoF(missing) error = %#v, want an *Error for EoF of missing", d.Error())
+	}
+	var out []float64
+	if _, err := d.GetDataAlloc("missing", 0, 0, 1, 0, &out); !errors.Is(err, ErrBadCode) {
+		t.Errorf("GetDataAlloc(missing) returned %v, want ErrBadCode", err)
+	}
+	if _, err := d.GetDataAlloc("data", 0, 0, 0, 0, &out); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataAlloc of no samples returned %v, want ErrArgument", err)
+	}
+	if _, err := d.GetDataAlloc("data", 0, 0, 1, 0, out); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataAlloc into a non-pointer returned %v, want ErrArgument", err)
+	}
+	if n, err := d.GetDataAlloc("data", 20, 0, 1, 0, &out); err != nil || n != 0 {
+		t.Errorf("GetDataAlloc past the end = %d (%v), want 0 samples and no error", n, err)
+	}
+	var c float64
+	if err := d.GetConstant("data", &c); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("GetConstant(data) returned %v, want ErrBadFieldType", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Error("Close failed:", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Error("Second Close failed:", err)
+	}
+}
+
+func TestFSDirfileMetadata(t *testing.T) {
+	d, err := OpenDirfileFS(zipDirfile(t), "flight/dirfile")
+	if err != nil {
+		t.Fatal("OpenDirfileFS failed:", err)
+	}
+	defer d.Close()
+
+	if n := d.NFragments(); n != 1 {
+		t.Errorf("NFragments = %d, want 1", n)
+	}
+	if i, err := d.FragmentIndex("double"); err != nil || i != 0 {
+		t.Errorf("FragmentIndex(double) = %d (%v), want 0", i, err)
+	}
+	if h, err := d.Hidden("secret"); err != nil || !h {
+		t.Errorf("Hidden(secret) = %v (%v), want true", h, err)
+	}
+	if _, err := d.Hidden("missing"); !errors.Is(err, ErrBadCode) {
+		t.Errorf("Hidden(missing) returned %v, want ErrBadCode", err)
+	}
+	if err := d.Validate("double"); err != nil {
+		t.Errorf("Validate(double) returned %v", err)
+	}
+	if err := d.Validate("missing"); !errors.Is(err, ErrBadCode) {
+		t.Errorf("Validate(missing) returned %v, want ErrBadCode", err)
+	}
+	if ml := d.MFieldList("data"); !slices.Equal(ml, []string{"units"}) {
+		t.Errorf("MFieldList(data) = %v, want [units]", ml)
+	}
+	if n := d.ArrayLen("const"); n != 1 {
+		t.Errorf("ArrayLen(const) = %d, want 1", n)
+	}
+	if n := d.ArrayLen("data"); n != 0 || !errors.Is(d.Error(), ErrBadFieldType) {
+		t.Errorf("ArrayLen(data) = %d with error %v, want 0 and ErrBadFieldType", n, d.Error())
+	}
+	if bof := d.BoF("double"); bof != 0 {
+		t.Errorf("BoF(double) = %d, want 0", bof)
+	}
+	// data holds 1, 2, ... 80 at 8 samples per frame
+	if f := d.Framenum("data", 40.5); f != 39.5/8 {
+		t.Errorf("Framenum(data, 40.5) = %v, want %v", f, 39.5/8)
+	}
+	if f := d.Framenum("double", 0); f != -1.0/8 {
+		t.Errorf("Framenum(double, 0) = %v, want %v (extrapolated)", f, -1.0/8)
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"regexp"
+
+	"github.com/joefowler/gogetdata/format"
+)
+
+// The FSDirfile methods here return the Entry and EntryType of the cgo binding,
+// so that an FSDirfile satisfies ReadOnly alongside a Dirfile.
+
+// Entry returns the dirfile entry with the given name, following aliases. Its
+// parameters given by CONST or CARRAY fields are read when it is returned.
+func (df *FSDirfile) Entry(fieldcode string) (Entry, error) {
+	fe, err := df.p.Entry(fieldcode)
+	if err != nil {
+		return Entry{}, pureError("Entry", fieldcode, err)
+	}
+	return entryFromFormat(fe, func(p format.Param) (complex128, error) {
+		v, err := df.p.Scalar(p)
+		return v, pureError("Entry", fieldcode, err)
+	})
+}
+
+// EntryType returns the EntryType for the named field (or on error, NOENTRY)
+func (df *FSDirfile) EntryType(fieldcode string) EntryType {
+	e, err := df.p.Entry(fieldcode)
+	if df.err = pureError("EntryType", fieldcode, err); err != nil {
+		return NOENTRY
+	}
+	return EntryType(e.Type)
+}
+
+// GetReference returns the reference entry: the field named by /REFERENCE, or
+// else the first RAW field
+func (df *FSDirfile) GetReference() (Entry, error) {
+	ref := df.p.ReferenceField()
+	if ref == "" {
+		return Entry{}, newError(ErrBadCode, "GetReference", "", "Dirfile has no RAW fields")
+	}
+	return df.Entry(ref)
+}
+
+// MatchEntries returns a list of the top-level fields in the dirfile whose names
+// match the regular expression regex (in the syntax of package regexp), as for
+// Dirfile.MatchEntries. fragment may be ALLFRAGMENTS; et may be a field type or
+// one of ALLENTRIES, VECTORENTRIES, SCALARENTRIES, or ALIASENTRIES; and flags
+// may include HIDDENENTRIES, NOALIASENTRIES, and REGEXCASELESS.
+func (df *FSDirfile) MatchEntries(regex string, fragment int, et EntryType, flags Flags) ([]string, error) {
+	if EntryType(flags)&REGEXCASELESS != 0 {
+		regex = "(?i)" + regex
+	}
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, newError(ErrArgument, "MatchEntries", "", "Bad regular expression %q: %v", regex, err)
+	}
+	f := df.p.Format()
+	entries := append([]*format.Entry{{Name: "INDEX", Type: format.INDEXENTRY}}, f.Entries...)
+	var result []string
+	for _, e := range entries {
+		alias := e.Type == format.ALIASENTRY
+		target := e
+		if alias {
+			if target = f.Entry(e.Name); target == nil {
+				continue
+			}
+		}
+		switch {
+		case e.Parent() != "" || !re.MatchString(e.Name):
+			continue
+		case fragment != ALLFRAGMENTS && e.Fragment != fragment:
+			continue
+		case e.Hidden && EntryType(flags)&HIDDENENTRIES == 0:
+			continue
+		case alias && EntryType(flags)&NOALIASENTRIES != 0:
+			continue
+		}
+		switch et {
+		case ALLENTRIES:
+		case VECTORENTRIES:
+			if target.Type.IsScalar() {
+				continue
+			}
+		case SCALARENTRIES:
+			if !target.Type.IsScalar() {
+				continue
+			}
+		case ALIASENTRIES:
+			if !alias {
+				continue
+			}
+		default:
+			if EntryType(target.Type) != et {
+				continue
+			}
+		}
+		result = append(result, e.Name)
+	}
+	return result, nil
+}
+
+// GetFrames reads frames of several fields as aligned columns; see
+// Dirfile.GetFrames
+func (df *FSDirfile) GetFrames(fields []string, firstFrame, numFrames int) (*FrameTable, error) {
+	return ReadFrames(df, fields, firstFrame, numFrames, FrameOptions{})
+}
+
+// GetFramesOptions reads frames of several fields as aligned columns; see
+// Dirfile.GetFramesOptions
+func (df *FSDirfile) GetFramesOptions(fields []string, firstFrame, numFrames int, opts FrameOptions) (*FrameTable, error) {
+	return ReadFrames(df, fields, firstFrame, numFrames, opts)
+}
@@ -0,0 +1,149 @@
+//go:build !purego
+
+package getdata
+
+/*
+#include <getdata.h>
+#include <stdlib.h>
+#include <string.h>
+*/
+import "C"
+
+import "unsafe"
+
+// Numeric is the set of Go types that correspond to the numeric GetData data types.
+type Numeric interface {
+	uint8 | int8 | uint16 | int16 | uint32 | int32 | uint64 | int64 |
+		float32 | float64 | complex64 | complex128
+}
+
+// typeOf returns the GetData type matching the Go type T
+func typeOf[T Numeric]() RetType {
+	var zero T
+	t, _ := value2type(zero)
+	return t
+}
+
+// GetData fetches data of type T from a vector field in the dirfile (incl.
+// metafields). It returns a slice holding the samples actually read, which may be
+// fewer than the requested numFrames*SPF+numSamples near the end of the field.
+// See Dirfile.GetDataAlloc.
+func GetData[T Numeric](df *Dirfile, fieldcode string, firstFrame, firstSample, numFrames, numSamples int) ([]T, error) {
+	var out []T
+	_, err := df.GetDataAlloc(fieldcode, firstFrame, firstSample, numFrames, numSamples, &out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PutData stores data to a vector field in the dirfile (incl. metafields),
+// returning the number of samples written.
+func PutData[T Numeric](df *Dirfile, fieldcode string, firstFrame, firstSample int, data []T) (int, error) {
+	return df.PutData(fieldcode, firstFrame, firstSample, data)
+}
+
+// GetConstant returns the value of a CONST field (incl. metafields) as type T
+func GetConstant[T Numeric](df *Dirfile, fieldcode string) (T, error) {
+	var value T
+	err := df.GetConstant(fieldcode, &value)
+	return value, err
+}
+
+// PutConstant stores the value of a CONST field (incl. metafields)
+func PutConstant[T Numeric](df *Dirfile, fieldcode string, value T) error {
+	return df.PutConstant(fieldcode, value)
+}
+
+// GetCarray returns all elements of a CARRAY field (incl. metafields) as type T
+func GetCarray[T Numeric](df *Dirfile, fieldcode string) ([]T, error) {
+	n := df.ArrayLen(fieldcode)
+	if n <= 0 {
+		return nil, df.opError("GetCarray", fieldcode)
+	}
+	out := make([]T, n)
+	if err := df.GetCarray(fieldcode, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCarraySlice returns n elements of a CARRAY field (incl. metafields) as type T,
+// starting with element start
+func GetCarraySlice[T Numeric](df *Dirfile, fieldcode string, start, n uint) ([]T, error) {
+	if n == 0 {
+		return nil, errZeroLength("GetCarraySlice", fieldcode)
+	}
+	out := make([]T, n)
+	if err := df.GetCarraySlice(fieldcode, start, n, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PutCarray stores an entire CARRAY field (incl. metafields)
+func PutCarray[T Numeric](df *Dirfile, fieldcode string, values []T) error {
+	if len(values) == 0 {
+		return errZeroLength("PutCarray", fieldcode)
+	}
+	return df.PutCarray(fieldcode, values)
+}
+
+// Constants returns the values of all CONST fields in the dirfile as type T
+func Constants[T Numeric](df *Dirfile) ([]T, error) {
+	out := make([]T, df.NFieldsByType(CONSTENTRY))
+	if len(out) == 0 {
+		return out, nil
+	}
+	if err := df.Constants(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MConstants returns the values of all CONST metafields of parent as type T
+func MConstants[T Numeric](df *Dirfile, parent string) ([]T, error) {
+	out := make([]T, df.NMFieldsByType(parent, CONSTENTRY))
+	if len(out) == 0 {
+		return out, nil
+	}
+	if err := df.MConstants(parent, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Carrays returns the values of all CARRAY fields in the dirfile as type T
+func Carrays[T Numeric](df *Dirfile) ([][]T, error) {
+	ptr := C.gd_carrays(df.d, C.gd_type_t(typeOf[T]()))
+	if ptr == (*C.gd_carray_t)(C.NULL) {
+		return nil, df.opError("Carrays", "")
+	}
+	return carraysFromC[T](ptr), nil
+}
+
+// MCarrays returns the values of all CARRAY metafields of parent as type T
+func MCarrays[T Numeric](df *Dirfile, parent string) ([][]T, error) {
+	cparent := C.CString(parent)
+	defer C.free(unsafe.Pointer(cparent))
+
+	ptr := C.gd_mcarrays(df.d, cparent, C.gd_type_t(typeOf[T]()))
+	if ptr == (*C.gd_carray_t)(C.NULL) {
+		return nil, df.opError("MCarrays", parent)
+	}
+	return carraysFromC[T](ptr), nil
+}
+
+// carraysFromC copies a list of gd_carray_t, terminated by one of zero length,
+// into Go slices
+func carraysFromC[T Numeric](ptr *C.gd_carray_t) [][]T {
+	size := C.size_t(sizeof(typeOf[T]()))
+	carrays := make([][]T, 0)
+	for (*ptr).n > 0 {
+		a := make([]T, (*ptr).n)
+		C.memcpy(unsafe.Pointer(&a[0]), (*ptr).d, (*ptr).n*size)
+		carrays = append(carrays, a)
+		ptr = (*C.gd_carray_t)(unsafe.Pointer(uintptr(unsafe.Pointer(ptr)) + unsafe.Sizeof(*ptr)))
+	}
+	return carrays
+}
@@ -0,0 +1,82 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"testing"
+)
+
+func TestGenerics(t *testing.T) {
+	dir := "dirfile_generic"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Discard()
+
+	data, err := GetData[float32](&d, "data", 5, 0, 1, 0)
+	if err != nil {
+		t.Error("GetData[float32] failed:", err)
+	}
+	if len(data) != 8 {
+		t.Errorf("GetData[float32] returned %d samples, want 8", len(data))
+	}
+	for i, v := range data {
+		if v != float32(41+i) {
+			t.Errorf("GetData[float32] returned d[%d]=%f, want %d", i, v, 41+i)
+		}
+	}
+
+	if n, err := PutData(&d, "data", 0, 0, []int16{-1, -2, -3}); err != nil || n != 3 {
+		t.Errorf("PutData[int16] returned %d, %v, want 3, nil", n, err)
+	}
+	if cdata, err := GetData[complex128](&d, "data", 0, 0, 0, 3); err != nil || len(cdata) != 3 || cdata[2] != -3 {
+		t.Errorf("GetData[complex128] returned %v, %v, want [-1 -2 -3], nil", cdata, err)
+	}
+
+	if c, err := GetConstant[float64](&d, "const"); err != nil || c != 5.5 {
+		t.Errorf("GetConstant[float64] returned %f, %v, want 5.5, nil", c, err)
+	}
+	if c, err := GetConstant[uint8](&d, "const"); err != nil || c != 5 {
+		t.Errorf("GetConstant[uint8] returned %d, %v, want 5, nil", c, err)
+	}
+	if err := PutConstant(&d, "const", int32(7)); err != nil {
+		t.Error("PutConstant[int32] failed:", err)
+	}
+	if consts, err := Constants[int64](&d); err != nil || uint(len(consts)) != d.NFieldsByType(CONSTENTRY) {
+		t.Errorf("Constants[int64] returned %v, %v", consts, err)
+	}
+
+	ca, err := GetCarray[int16](&d, "carray")
+	expected := []int16{1, 2, 3, 4, 5, 6}
+	if err != nil || len(ca) != len(expected) {
+		t.Errorf("GetCarray[int16] returned %v, %v, want %v, nil", ca, err, expected)
+	} else {
+		for i, v := range expected {
+			if ca[i] != v {
+				t.Errorf("GetCarray[int16] returned a[%d]=%d, want %d", i, ca[i], v)
+			}
+		}
+	}
+	if cs, err := GetCarraySlice[float32](&d, "carray", 2, 2); err != nil || len(cs) != 2 || cs[0] != float32(3.3) {
+		t.Errorf("GetCarraySlice[float32] returned %v, %v, want [3.3 4.4], nil", cs, err)
+	}
+	if _, err := GetCarraySlice[float32](&d, "carray", 2, 0); err == nil {
+		t.Error("GetCarraySlice of zero elements did not return error")
+	}
+
+	all, err := Carrays[int32](&d)
+	if err != nil || len(all) != 1 || len(all[0]) != 6 || all[0][5] != 6 {
+		t.Errorf("Carrays[int32] returned %v, %v, want [[1 2 3 4 5 6]], nil", all, err)
+	}
+	meta, err := MCarrays[float64](&d, "data")
+	if err != nil || len(meta) != 1 || len(meta[0]) != 5 || meta[0][0] != 1.9 {
+		t.Errorf("MCarrays[float64] returned %v, %v, want [[1.9 2.8 3.7 4.6 5.5]], nil", meta, err)
+	}
+	if err := PutCarray(&d, "carray", []uint8{9, 8, 7, 6, 5, 4}); err != nil {
+		t.Error("PutCarray[uint8] failed:", err)
+	}
+}
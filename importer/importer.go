@@ -0,0 +1,319 @@
+//go:build !purego
+
+// Package importer creates dirfile fields from delimited text (CSV or TSV) or
+// from binary columnar data: one RAW field per column, written in chunks with
+// PutData, with optional units metafields and LINCOM calibrations read from a
+// JSON description. YAML descriptions are not supported. Integers in text are
+// decimal, so zero-padded values like 010 are ten, not octal.
+package importer
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joefowler/gogetdata"
+	"github.com/joefowler/gogetdata/format"
+)
+
+// DefaultChunkRows is the number of rows buffered before they are written,
+// when Options.ChunkRows is 0
+const DefaultChunkRows = 4096
+
+// DefaultDetectRows is the number of rows examined to detect the type of a
+// column, when Options.DetectRows is 0
+const DefaultDetectRows = 1000
+
+// Calibration describes a LINCOM field, Scale*column + Offset
+type Calibration struct {
+	Field  string  `json:"field"` // the name of the LINCOM, or "" for the column's field + "_cal"
+	Scale  float64 `json:"scale"`
+	Offset float64 `json:"offset"`
+	Units  string  `json:"units"`
+}
+
+// Column describes the field created for one column of input
+type Column struct {
+	Field       string       `json:"field"` // the field name, or "" for the column name
+	Type        string       `json:"type"`  // the RAW data type, e.g. "INT16", or "" to detect it
+	SPF         uint         `json:"spf"`   // samples per frame, or 0 for Options.SPF
+	Units       string       `json:"units"` // if not "", the value of a "units" STRING metafield
+	Calibration *Calibration `json:"calibration"`
+}
+
+// Description is the JSON sidecar file describing the columns, keyed by
+// column name, e.g.
+//
+//	{"columns": {"temp": {"type": "FLOAT32", "spf": 4, "units": "ADU",
+//	    "calibration": {"scale": 0.01, "offset": -273.15, "units": "degC"}}}}
+type Description struct {
+	Columns map[string]Column `json:"columns"`
+}
+
+// ReadDescription reads a Description from the JSON file name
+func ReadDescription(name string) (*Description, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	var desc Description
+	if err := dec.Decode(&desc); err != nil {
+		return nil, fmt.Errorf("Could not read description %s: %v", name, err)
+	}
+	return &desc, nil
+}
+
+// Options control how ImportCSV and ImportBinary create fields
+type Options struct {
+	Comma       rune         // the field delimiter, or 0 for ','
+	Names       []string     // the column names, or nil to read them from the first row
+	Description *Description // per-column settings, or nil
+	SPF         uint         // samples per frame of columns without their own, or 0 for 1
+	Encoding    getdata.Flags
+	Fragment    int // the fragment to add fields to
+	ChunkRows   int // rows buffered before writing, or 0 for DefaultChunkRows
+	DetectRows  int // rows examined to detect column types, or 0 for DefaultDetectRows
+}
+
+// ImportCSV reads delimited text from r and writes each column to a new RAW
+// field of df, returning the names of the fields. Empty cells are skipped, so
+// a column sampled more slowly than the others may leave cells empty. A column
+// without a type is INT64 if its first opts.DetectRows values are decimal
+// integers, FLOAT64 if they are real numbers, or else COMPLEX128. If
+// opts.Encoding is set, the encoding of opts.Fragment is changed to it first.
+func ImportCSV(df *getdata.Dirfile, r io.Reader, opts Options) ([]string, error) {
+	in := csv.NewReader(r)
+	if opts.Comma != 0 {
+		in.Comma = opts.Comma
+	}
+	in.TrimLeadingSpace = true
+	names := opts.Names
+	if names == nil {
+		header, err := in.Read()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read header: %v", err)
+		}
+		names = header
+	}
+	in.FieldsPerRecord = len(names)
+
+	detect := opts.DetectRows
+	if detect <= 0 {
+		detect = DefaultDetectRows
+	}
+	var rows [][]string
+	for len(rows) < detect {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+
+	if err := opts.setEncoding(df); err != nil {
+		return nil, err
+	}
+	sinks := make([]sink, len(names))
+	fields := make([]string, len(names))
+	for i, name := range names {
+		col := opts.column(name)
+		t, err := columnType(col.Type, rows, i)
+		if err != nil {
+			return nil, fmt.Errorf("Column %s: %v", name, err)
+		}
+		fields[i] = col.Field
+		if sinks[i], err = addField(df, col, t, opts.Fragment); err != nil {
+			return nil, err
+		}
+	}
+
+	chunk := opts.ChunkRows
+	if chunk <= 0 {
+		chunk = DefaultChunkRows
+	}
+	flush := func() error {
+		for _, s := range sinks {
+			if err := s.flush(df); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for row := 0; ; row++ {
+		var record []string
+		if row < len(rows) {
+			record = rows[row]
+		} else {
+			var err error
+			if record, err = in.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		for i, cell := range record {
+			if cell = strings.TrimSpace(cell); cell == "" {
+				continue
+			}
+			if err := sinks[i].add(cell); err != nil {
+				return nil, fmt.Errorf("Row %d, column %s: %v", row+1, names[i], err)
+			}
+		}
+		if (row+1)%chunk == 0 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return fields, df.FlushAll()
+}
+
+// ImportBinary reads samples of type t, in the given byte order, from r until
+// it ends, and writes them to a new RAW field whose name and other settings are
+// given by opts (as for a column named field) a chunk at a time. If
+// opts.Encoding is set, the encoding of opts.Fragment is changed to it first.
+func ImportBinary(df *getdata.Dirfile, r io.Reader, field string, t getdata.RetType,
+	order binary.ByteOrder, opts Options) error {
+	if err := opts.setEncoding(df); err != nil {
+		return err
+	}
+	col := opts.column(field)
+	s, err := addField(df, col, t, opts.Fragment)
+	if err != nil {
+		return err
+	}
+	size := format.RetType(t).Size()
+	chunk := opts.ChunkRows
+	if chunk <= 0 {
+		chunk = DefaultChunkRows
+	}
+	buf := make([]byte, chunk*size)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if n%size != 0 {
+			return fmt.Errorf("Input for field %s ends with a partial sample", col.Field)
+		}
+		if err := s.decode(buf[:n], order); err != nil {
+			return err
+		}
+		if err := s.flush(df); err != nil {
+			return err
+		}
+		if err != nil { // the end of the input
+			break
+		}
+	}
+	return df.Flush(col.Field)
+}
+
+// setEncoding changes the encoding of opts.Fragment to opts.Encoding, if set
+func (opts Options) setEncoding(df *getdata.Dirfile) error {
+	if opts.Encoding == 0 {
+		return nil
+	}
+	frag, err := df.Fragment(opts.Fragment)
+	if err != nil {
+		return err
+	}
+	return frag.SetEncoding(opts.Encoding, false)
+}
+
+// column returns the settings for the named column, with defaults filled in
+func (opts Options) column(name string) Column {
+	var col Column
+	if opts.Description != nil {
+		col = opts.Description.Columns[name]
+	}
+	if col.Field == "" {
+		col.Field = name
+	}
+	if col.SPF == 0 {
+		col.SPF = max(opts.SPF, 1)
+	}
+	if col.Calibration != nil && col.Calibration.Field == "" {
+		c := *col.Calibration
+		c.Field = col.Field + "_cal"
+		col.Calibration = &c
+	}
+	return col
+}
+
+// columnType returns the type named by name, or if that is "", the type
+// detected from the values in column i of rows
+func columnType(name string, rows [][]string, i int) (getdata.RetType, error) {
+	if name != "" {
+		t, err := format.ParseType(name)
+		if err != nil {
+			return getdata.UNKNOWN, err
+		}
+		return getdata.RetType(t), nil
+	}
+	t := getdata.INT64
+	for _, row := range rows {
+		cell := strings.TrimSpace(row[i])
+		if cell == "" {
+			continue
+		}
+		if t == getdata.INT64 {
+			if _, err := strconv.ParseInt(cell, 10, 64); err == nil {
+				continue
+			}
+			t = getdata.FLOAT64
+		}
+		if t == getdata.FLOAT64 {
+			if _, err := strconv.ParseFloat(cell, 64); err == nil {
+				continue
+			}
+			t = getdata.COMPLEX128
+		}
+		if _, err := strconv.ParseComplex(cell, 128); err != nil {
+			return getdata.UNKNOWN, fmt.Errorf("%q is not a number", cell)
+		}
+	}
+	return t, nil
+}
+
+// addField adds the RAW field for col, of type t, with its units and
+// calibration, and returns a sink writing to it
+func addField(df *getdata.Dirfile, col Column, t getdata.RetType, fragment int) (sink, error) {
+	s := newSink(col.Field, t)
+	if s == nil {
+		return nil, fmt.Errorf("Cannot import field %s of type 0x%x", col.Field, t)
+	}
+	if err := df.AddRaw(col.Field, t, col.SPF, fragment); err != nil {
+		return nil, err
+	}
+	if col.Units != "" {
+		if err := df.MAddString(col.Field, "units", col.Units); err != nil {
+			return nil, err
+		}
+	}
+	if c := col.Calibration; c != nil {
+		if err := df.AddLincom(c.Field, []string{col.Field}, []float64{c.Scale}, []float64{c.Offset}, fragment); err != nil {
+			return nil, err
+		}
+		if c.Units != "" {
+			if err := df.MAddString(c.Field, "units", c.Units); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
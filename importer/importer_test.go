@@ -0,0 +1,162 @@
+//go:build !purego
+
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/joefowler/gogetdata"
+)
+
+func TestColumnType(t *testing.T) {
+	rows := [][]string{
+		{"1", "1", "1", "1", "", "x"},
+		{"-2", "08", "2.5", "2.5", "", "1"},
+		{"", "3", "1e3", "1+2i", "", "2"},
+	}
+	for i, want := range []getdata.RetType{getdata.INT64, getdata.INT64, getdata.FLOAT64,
+		getdata.COMPLEX128, getdata.INT64} {
+		if got, err := columnType("", rows, i); err != nil || got != want {
+			t.Errorf("columnType of column %d = 0x%x (%v), want 0x%x", i, got, err, want)
+		}
+	}
+	if _, err := columnType("", rows, 5); err == nil {
+		t.Error("columnType of a column of non-numbers did not return error")
+	}
+	if got, err := columnType("UINT16", rows, 5); err != nil || got != getdata.UINT16 {
+		t.Errorf("columnType(UINT16) = 0x%x (%v)", got, err)
+	}
+	if _, err := columnType("INT12", rows, 0); err == nil {
+		t.Error("columnType(INT12) did not return error")
+	}
+}
+
+func TestSink(t *testing.T) {
+	b := newSink("f", getdata.INT8).(*buffer[int8])
+	for _, cell := range []string{"5", "-128", "0127", "-010"} {
+		if err := b.add(cell); err != nil {
+			t.Errorf("add(%q) failed: %v", cell, err)
+		}
+	}
+	if err := b.add("128"); err == nil {
+		t.Error("add(128) to an INT8 field did not return error")
+	}
+	if err := b.add("0x7f"); err == nil {
+		t.Error("add(0x7f) to an INT8 field did not return error")
+	}
+	if want := []int8{5, -128, 127, -10}; !slices.Equal(b.data, want) {
+		t.Errorf("INT8 sink holds %v, want %v", b.data, want)
+	}
+
+	c := newSink("f", getdata.COMPLEX64).(*buffer[complex64])
+	if err := c.add("1.5-2i"); err != nil || c.data[0] != 1.5-2i {
+		t.Errorf("add(1.5-2i) gave %v (%v)", c.data, err)
+	}
+	u := newSink("f", getdata.UINT16).(*buffer[uint16])
+	if err := u.decode([]byte{1, 2, 3, 4}, binary.BigEndian); err != nil || !slices.Equal(u.data, []uint16{0x102, 0x304}) {
+		t.Errorf("decode gave %v (%v), want [258 772]", u.data, err)
+	}
+	if newSink("f", getdata.STRING) != nil {
+		t.Error("newSink(STRING) is not nil")
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "imported")
+	d, err := getdata.OpenDirfile(dir, getdata.RDWR|getdata.CREAT|getdata.EXCL)
+	if err != nil {
+		t.Fatal("Could not create dirfile:", err)
+	}
+	defer d.Close()
+
+	descFile := filepath.Join(t.TempDir(), "desc.json")
+	desc := `{"columns": {"temp": {"type": "FLOAT32", "spf": 2, "units": "ADU",
+		"calibration": {"scale": 2, "offset": -1, "units": "K"}},
+		"count": {"field": "counter"}}}`
+	if err = os.WriteFile(descFile, []byte(desc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	description, err := ReadDescription(descFile)
+	if err != nil {
+		t.Fatal("ReadDescription failed:", err)
+	}
+
+	csv := "count,temp\n1,0.5\n,1.5\n2,2.5\n,3.5\n"
+	fields, err := ImportCSV(&d, strings.NewReader(csv), Options{Description: description, ChunkRows: 3})
+	if err != nil {
+		t.Fatal("ImportCSV failed:", err)
+	}
+	if want := []string{"counter", "temp"}; !slices.Equal(fields, want) {
+		t.Errorf("ImportCSV returned fields %v, want %v", fields, want)
+	}
+	if nt := d.NativeType("counter"); nt != getdata.INT64 {
+		t.Errorf("counter has type 0x%x, want INT64", nt)
+	}
+	if spf := d.SPF("temp"); spf != 2 {
+		t.Errorf("temp has SPF %d, want 2", spf)
+	}
+	counter, err := getdata.GetData[int64](&d, "counter", 0, 0, 2, 0)
+	if err != nil || !slices.Equal(counter, []int64{1, 2}) {
+		t.Errorf("counter holds %v (%v), want [1 2]", counter, err)
+	}
+	cal, err := getdata.GetData[float64](&d, "temp_cal", 0, 0, 2, 0)
+	if err != nil || !slices.Equal(cal, []float64{0, 2, 4, 6}) {
+		t.Errorf("temp_cal holds %v (%v), want [0 2 4 6]", cal, err)
+	}
+	for field, want := range map[string]string{"temp/units": "ADU", "temp_cal/units": "K"} {
+		if units, err := d.GetString(field); err != nil || units != want {
+			t.Errorf("%s = %q (%v), want %q", field, units, err, want)
+		}
+	}
+
+	if _, err = ImportCSV(&d, strings.NewReader("bad\n1\nx\n"), Options{DetectRows: 1}); err == nil {
+		t.Error("ImportCSV of a non-number did not return error")
+	}
+
+	var raw bytes.Buffer
+	binary.Write(&raw, binary.LittleEndian, []int16{-1, 2, -3})
+	if err = ImportBinary(&d, &raw, "bin", getdata.INT16, binary.LittleEndian, Options{ChunkRows: 2}); err != nil {
+		t.Fatal("ImportBinary failed:", err)
+	}
+	if bin, err := getdata.GetData[int16](&d, "bin", 0, 0, 3, 0); err != nil || !slices.Equal(bin, []int16{-1, 2, -3}) {
+		t.Errorf("bin holds %v (%v), want [-1 2 -3]", bin, err)
+	}
+
+	// a read error is reported as such, even after a partial sample
+	errRead := errors.New("read failed")
+	r := io.MultiReader(bytes.NewReader([]byte{1, 2, 3}), iotest.ErrReader(errRead))
+	if err = ImportBinary(&d, r, "bin2", getdata.INT16, binary.LittleEndian, Options{}); !errors.Is(err, errRead) {
+		t.Errorf("ImportBinary of a failing reader returned %v, want %v", err, errRead)
+	}
+}
+
+func TestImportBinaryEncoding(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "binary")
+	d, err := getdata.OpenDirfile(dir, getdata.RDWR|getdata.CREAT|getdata.EXCL)
+	if err != nil {
+		t.Fatal("Could not create dirfile:", err)
+	}
+	defer d.Close()
+
+	var raw bytes.Buffer
+	binary.Write(&raw, binary.BigEndian, []float32{1.5, -2.5})
+	opts := Options{Encoding: getdata.TEXTENCODED}
+	if err = ImportBinary(&d, &raw, "bin", getdata.FLOAT32, binary.BigEndian, opts); err != nil {
+		t.Fatal("ImportBinary failed:", err)
+	}
+	if frag, err := d.Fragment(0); err != nil || frag.Encoding() != getdata.TEXTENCODED {
+		t.Errorf("After ImportBinary, fragment 0 is not TEXTENCODED (%v)", err)
+	}
+	if bin, err := getdata.GetData[float32](&d, "bin", 0, 0, 2, 0); err != nil || !slices.Equal(bin, []float32{1.5, -2.5}) {
+		t.Errorf("bin holds %v (%v), want [1.5 -2.5]", bin, err)
+	}
+}
@@ -0,0 +1,123 @@
+//go:build !purego
+
+package importer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/joefowler/gogetdata"
+)
+
+// sink buffers the samples of one field, and writes them with PutData
+type sink interface {
+	add(cell string) error                           // parse and buffer one sample
+	decode(raw []byte, order binary.ByteOrder) error // buffer the binary samples in raw
+	flush(df *getdata.Dirfile) error                 // write the buffered samples
+}
+
+// buffer is the sink for a field of type T
+type buffer[T getdata.Numeric] struct {
+	field string
+	parse func(string) (T, error)
+	data  []T
+	pos   int // the sample number where data will be written
+}
+
+// newSink returns a sink for field of type t, or nil if t is not numeric
+func newSink(field string, t getdata.RetType) sink {
+	switch t {
+	case getdata.UINT8:
+		return newBuffer(field, parseUint[uint8])
+	case getdata.INT8:
+		return newBuffer(field, parseInt[int8])
+	case getdata.UINT16:
+		return newBuffer(field, parseUint[uint16])
+	case getdata.INT16:
+		return newBuffer(field, parseInt[int16])
+	case getdata.UINT32:
+		return newBuffer(field, parseUint[uint32])
+	case getdata.INT32:
+		return newBuffer(field, parseInt[int32])
+	case getdata.UINT64:
+		return newBuffer(field, parseUint[uint64])
+	case getdata.INT64:
+		return newBuffer(field, parseInt[int64])
+	case getdata.FLOAT32:
+		return newBuffer(field, parseFloat[float32])
+	case getdata.FLOAT64:
+		return newBuffer(field, parseFloat[float64])
+	case getdata.COMPLEX64:
+		return newBuffer(field, parseComplex[complex64])
+	case getdata.COMPLEX128:
+		return newBuffer(field, parseComplex[complex128])
+	}
+	return nil
+}
+
+func newBuffer[T getdata.Numeric](field string, parse func(string) (T, error)) *buffer[T] {
+	return &buffer[T]{field: field, parse: parse}
+}
+
+func (b *buffer[T]) add(cell string) error {
+	v, err := b.parse(cell)
+	if err != nil {
+		return err
+	}
+	b.data = append(b.data, v)
+	return nil
+}
+
+func (b *buffer[T]) decode(raw []byte, order binary.ByteOrder) error {
+	var v T
+	start := len(b.data)
+	b.data = append(b.data, make([]T, len(raw)/binary.Size(v))...)
+	_, err := binary.Decode(raw, order, b.data[start:])
+	return err
+}
+
+func (b *buffer[T]) flush(df *getdata.Dirfile) error {
+	if len(b.data) == 0 {
+		return nil
+	}
+	n, err := df.PutData(b.field, 0, b.pos, b.data)
+	if err != nil {
+		return err
+	}
+	if n != len(b.data) {
+		return fmt.Errorf("Wrote %d of %d samples to field %s", n, len(b.data), b.field)
+	}
+	b.pos += n
+	b.data = b.data[:0]
+	return nil
+}
+
+// parseUint parses a decimal unsigned integer of type T; leading zeros do not
+// make it octal
+func parseUint[T uint8 | uint16 | uint32 | uint64](s string) (T, error) {
+	var v T
+	u, err := strconv.ParseUint(s, 10, binary.Size(v)*8)
+	return T(u), err
+}
+
+// parseInt parses a decimal signed integer of type T
+func parseInt[T int8 | int16 | int32 | int64](s string) (T, error) {
+	var v T
+	i, err := strconv.ParseInt(s, 10, binary.Size(v)*8)
+	return T(i), err
+}
+
+// parseFloat parses a floating-point number of type T
+func parseFloat[T float32 | float64](s string) (T, error) {
+	var v T
+	f, err := strconv.ParseFloat(s, binary.Size(v)*8)
+	return T(f), err
+}
+
+// parseComplex parses a complex number of type T, e.g. "1.5+2i"
+func parseComplex[T complex64 | complex128](s string) (T, error) {
+	var v T
+	c, err := strconv.ParseComplex(s, binary.Size(v)*8)
+	return T(c), err
+}
@@ -0,0 +1,109 @@
+//go:build !purego
+
+package getdata
+
+import "errors"
+
+// Interface is the set of Dirfile methods used to read, write, and describe
+// fields. It is implemented by *Dirfile, by *SyncDirfile, and by the in-memory
+// *MemDirfile, so that code written against Interface can be tested without
+// touching the filesystem.
+type Interface interface {
+	Close() error
+	Dirfilename() string
+	NFrames() int
+	NFragments() int
+	FragmentIndex(fieldcode string) (int, error)
+	Include(fragname string, flags Flags) (int, error)
+
+	Entry(fieldcode string) (Entry, error)
+	EntryType(fieldcode string) EntryType
+	FieldList() []string
+	VectorList() []string
+	FieldListByType(et EntryType) []string
+	MFieldList(parent string) []string
+	Hidden(fieldcode string) (bool, error)
+	Validate(fieldcode string) error
+	SPF(fieldcode string) int
+	EoF(fieldcode string) int
+	NativeType(fieldcode string) RetType
+	ArrayLen(fieldcode string) int
+
+	AddEntry(e *Entry) error
+	MAddEntry(e *Entry, parent string) error
+	Delete(fieldname string, flags DeleteFlags) error
+
+	GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error)
+	GetDataAlloc(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error)
+	PutData(fieldcode string, firstFrame, firstSample int, data interface{}) (int, error)
+
+	GetConstant(fieldcode string, inptr interface{}) error
+	PutConstant(fieldcode string, data interface{}) error
+	GetCarray(fieldcode string, out interface{}) error
+	PutCarray(fieldcode string, array interface{}) error
+	GetString(fieldcode string) (string, error)
+	PutString(fieldcode, value string) error
+	GetSarray(fieldcode string) ([]string, error)
+	PutSarray(fieldcode string, sarray []string) error
+}
+
+// ReadOnly is the set of Dirfile methods used to read and describe fields
+// without changing the dirfile. It is implemented by *Dirfile, *SyncDirfile,
+// and the read-only *FSDirfile, and accepted by ReadFrames, NewTimeIndex,
+// NewFieldReader, and package export. Methods that report failure by their
+// return value alone (e.g. SPF returning 0) leave the error in Error.
+type ReadOnly interface {
+	Error() error
+	Close() error
+	Dirfilename() string
+	NFrames() int
+	NFragments() int
+	FragmentIndex(fieldcode string) (int, error)
+
+	Entry(fieldcode string) (Entry, error)
+	EntryType(fieldcode string) EntryType
+	GetReference() (Entry, error)
+	FieldList() []string
+	VectorList() []string
+	MFieldList(parent string) []string
+	MatchEntries(regex string, fragment int, et EntryType, flags Flags) ([]string, error)
+	Hidden(fieldcode string) (bool, error)
+	Validate(fieldcode string) error
+	SPF(fieldcode string) int
+	BoF(fieldcode string) int
+	EoF(fieldcode string) int
+	NativeType(fieldcode string) RetType
+	ArrayLen(fieldcode string) int
+	Framenum(fieldcode string, value float64) float64
+
+	GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error)
+	GetDataAlloc(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error)
+	GetConstant(fieldcode string, inptr interface{}) error
+	GetCarray(fieldcode string, out interface{}) error
+	GetString(fieldcode string) (string, error)
+	GetSarray(fieldcode string) ([]string, error)
+}
+
+var (
+	_ Interface = (*Dirfile)(nil)
+	_ Interface = (*MemDirfile)(nil)
+	_ ReadOnly  = (*Dirfile)(nil)
+	_ ReadOnly  = (*FSDirfile)(nil)
+	_ Interface = (*SyncDirfile)(nil)
+	_ ReadOnly  = (*SyncDirfile)(nil)
+)
+
+// readError returns the latest error of df, for a method that reported failure
+// by its return value alone, recording the operation and field code involved
+func readError(df ReadOnly, op, fieldcode string) error {
+	if d, ok := df.(*Dirfile); ok {
+		return d.opError(op, fieldcode)
+	}
+	var e *Error
+	if !errors.As(df.Error(), &e) {
+		return newError(ErrInternal, op, fieldcode, "%s failed on field %s without an error", op, fieldcode)
+	}
+	copied := *e
+	copied.Op, copied.Field = op, fieldcode
+	return &copied
+}
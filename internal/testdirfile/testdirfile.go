@@ -0,0 +1,68 @@
+// Package testdirfile writes the dirfile shared by the tests of this module: a
+// RAW field data holding the bytes 1, 2, ... 80 at 8 samples per frame, and the
+// derived, scalar and meta fields of Format that read it.
+package testdirfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Format is the format file of the test dirfile
+const Format = `/ENDIAN little
+data RAW INT8 8
+lincom LINCOM data 1.1 2.2 INDEX 2.2 3.3;4.4 linterp const const
+/META data mstr STRING "This is a string constant."
+/META data mconst CONST COMPLEX128 3.3;4.4
+/META data mcarray CARRAY FLOAT64 1.9 2.8 3.7 4.6 5.5
+/META data mlut LINTERP DATA ./lut
+const CONST FLOAT64 5.5
+carray CARRAY FLOAT64 1.1 2.2 3.3 4.4 5.5 6.6
+linterp LINTERP data ./lut
+polynom POLYNOM data 1.1 2.2 2.2 3.3;4.4 const const
+bit BIT data 3 4
+sbit SBIT data 5 6
+mplex MPLEX data sbit 1 10
+mult MULTIPLY data sbit
+div DIVIDE mult bit
+recip RECIP div 6.5;4.3
+phase PHASE data 11
+window WINDOW linterp mult LT 4.1
+/ALIAS alias data
+string STRING "Zaphod Beeblebrox"
+sarray SARRAY one two three four five six seven
+data/msarray SARRAY eight nine ten eleven twelve
+indir INDIR data carray
+sindir SINDIR data sarray
+`
+
+// Files are the other files of the test dirfile: the look-up table of its
+// LINTERP fields, and a fragment, form2, for tests to include
+var Files = map[string]string{
+	"lut":   "0 0\n100 200\n",
+	"form2": "const2 CONST INT8 -19\n",
+}
+
+// Data returns the contents of the RAW field data: the bytes 1, 2, ... 80
+func Data() []byte {
+	data := make([]byte, 80)
+	for i := range data {
+		data[i] = byte(i + 1)
+	}
+	return data
+}
+
+// Write writes the test dirfile into the existing directory dir, with the
+// format file text format (Format, or another using the same files)
+func Write(dir, format string) error {
+	files := map[string][]byte{"format": []byte(format), "data": Data()}
+	for name, text := range Files {
+		files[name] = []byte(text)
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
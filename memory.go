@@ -0,0 +1,733 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MemDirfile is a dirfile held entirely in memory, for fast hermetic tests of
+// code written against Interface. It supports adding, deleting and listing
+// fields and metafields in any number of fragments, reading and writing RAW
+// data, and reading and writing CONST, CARRAY, STRING and SARRAY values. Aliases
+// are followed, and the implicit INDEX field can be read. Derived fields can be
+// added and described, but not read: GetData on them returns ErrBadFieldType.
+// A MemDirfile is not safe for concurrent use.
+type MemDirfile struct {
+	name      string
+	fragments []string
+	entries   map[string]*Entry
+	order     []string                 // field codes in the order they were added
+	data      map[string]reflect.Value // samples of each RAW field, as a slice of its data type
+}
+
+// NewMemDirfile returns an empty in-memory dirfile with the given name, holding
+// only the primary format fragment
+func NewMemDirfile(name string) *MemDirfile {
+	return &MemDirfile{
+		name:      name,
+		fragments: []string{"format"},
+		entries:   make(map[string]*Entry),
+		data:      make(map[string]reflect.Value),
+	}
+}
+
+// memTypes are the Go types that store each numeric data type
+var memTypes = map[RetType]reflect.Type{
+	UINT8:      reflect.TypeFor[uint8](),
+	INT8:       reflect.TypeFor[int8](),
+	UINT16:     reflect.TypeFor[uint16](),
+	INT16:      reflect.TypeFor[int16](),
+	UINT32:     reflect.TypeFor[uint32](),
+	INT32:      reflect.TypeFor[int32](),
+	UINT64:     reflect.TypeFor[uint64](),
+	INT64:      reflect.TypeFor[int64](),
+	FLOAT32:    reflect.TypeFor[float32](),
+	FLOAT64:    reflect.TypeFor[float64](),
+	COMPLEX64:  reflect.TypeFor[complex64](),
+	COMPLEX128: reflect.TypeFor[complex128](),
+}
+
+// errBadCode is the error for a field code not found in the dirfile
+func errBadCode(op, fieldcode string) error {
+	return newError(ErrBadCode, op, fieldcode, "Field not found: %s", fieldcode)
+}
+
+// errFieldType is the error for an operation not supported on a field's type
+func errFieldType(op, fieldcode string) error {
+	return newError(ErrBadFieldType, op, fieldcode, "Bad field type for %s on field %s", op, fieldcode)
+}
+
+// setNumeric sets dst to the numeric value src, converted as a C cast would.
+// Complex values converted to a real type keep only their real part.
+func setNumeric(dst, src reflect.Value) {
+	var i int64
+	var u uint64
+	var c complex128
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = src.Int()
+		u, c = uint64(i), complex(float64(i), 0)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u = src.Uint()
+		i, c = int64(u), complex(float64(u), 0)
+	case reflect.Float32, reflect.Float64:
+		f := src.Float()
+		i, u, c = int64(f), uint64(f), complex(f, 0)
+	case reflect.Complex64, reflect.Complex128:
+		c = src.Complex()
+		i, u = int64(real(c)), uint64(real(c))
+	}
+	switch dst.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		dst.SetInt(i)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		dst.SetFloat(real(c))
+	case reflect.Complex64, reflect.Complex128:
+		dst.SetComplex(c)
+	}
+}
+
+// copyNumeric copies the numeric slice src into dst, converting each element,
+// and returns the number copied
+func copyNumeric(dst, src reflect.Value) int {
+	n := min(dst.Len(), src.Len())
+	for i := 0; i < n; i++ {
+		setNumeric(dst.Index(i), src.Index(i))
+	}
+	return n
+}
+
+// convertValue returns the numeric value v converted to data type t
+func convertValue(v interface{}, t RetType) interface{} {
+	result := reflect.New(memTypes[t]).Elem()
+	setNumeric(result, reflect.ValueOf(v))
+	return result.Interface()
+}
+
+// convertSlice returns a copy of the numeric slice v converted to data type t
+func convertSlice(v interface{}, t RetType) interface{} {
+	src := reflect.ValueOf(v)
+	result := reflect.MakeSlice(reflect.SliceOf(memTypes[t]), src.Len(), src.Len())
+	copyNumeric(result, src)
+	return result.Interface()
+}
+
+// isNumeric returns whether t is one of the numeric data types
+func isNumeric(t RetType) bool {
+	_, ok := memTypes[t]
+	return ok
+}
+
+// isScalar returns whether fields of type et hold scalar values rather than vectors
+func isScalar(et EntryType) bool {
+	switch et {
+	case CONSTENTRY, CARRAYENTRY, STRINGENTRY, SARRAYENTRY:
+		return true
+	}
+	return false
+}
+
+// lookup returns the entry for fieldcode, following aliases
+func (m *MemDirfile) lookup(op, fieldcode string) (*Entry, error) {
+	code := fieldcode
+	for depth := 0; depth <= len(m.entries); depth++ {
+		e, ok := m.entries[code]
+		if !ok {
+			return nil, errBadCode(op, fieldcode)
+		}
+		if e.fieldType != ALIASENTRY {
+			return e, nil
+		}
+		code = e.inFields[0]
+	}
+	return nil, newError(ErrRecurseLevel, op, fieldcode, "Recursion too deep resolving field %s", fieldcode)
+}
+
+// vector returns the entry for fieldcode, which must be a vector field
+func (m *MemDirfile) vector(op, fieldcode string) (*Entry, error) {
+	if fieldcode == "INDEX" {
+		return &Entry{name: "INDEX", fieldType: INDEXENTRY}, nil
+	}
+	e, err := m.lookup(op, fieldcode)
+	if err != nil {
+		return nil, err
+	}
+	if isScalar(e.fieldType) {
+		return nil, newError(ErrDimension, op, fieldcode, "Scalar field %s found where vector field expected", fieldcode)
+	}
+	return e, nil
+}
+
+// scalar returns the entry for fieldcode, which must be of type et
+func (m *MemDirfile) scalar(op, fieldcode string, et EntryType) (*Entry, error) {
+	e, err := m.lookup(op, fieldcode)
+	if err != nil {
+		return nil, err
+	}
+	if e.fieldType != et {
+		return nil, errFieldType(op, fieldcode)
+	}
+	return e, nil
+}
+
+// Close releases the dirfile. Its contents are kept, so it may still be used.
+func (m *MemDirfile) Close() error {
+	return nil
+}
+
+// Dirfilename returns the name given to NewMemDirfile
+func (m *MemDirfile) Dirfilename() string {
+	return m.name
+}
+
+// NFrames returns the number of frames in the dirfile: the length of the first
+// RAW field added
+func (m *MemDirfile) NFrames() int {
+	for _, name := range m.order {
+		if e := m.entries[name]; e.fieldType == RAWENTRY {
+			return m.data[name].Len() / int(e.spf)
+		}
+	}
+	return 0
+}
+
+// NFragments returns the number of fragments in the dirfile
+func (m *MemDirfile) NFragments() int {
+	return len(m.fragments)
+}
+
+// FragmentIndex returns the index of the fragment which defines a given field or alias
+func (m *MemDirfile) FragmentIndex(fieldcode string) (int, error) {
+	if fieldcode == "INDEX" {
+		return 0, nil
+	}
+	e, ok := m.entries[fieldcode]
+	if !ok {
+		return 0, errBadCode("FragmentIndex", fieldcode)
+	}
+	return e.fragment, nil
+}
+
+// Include adds a new, empty fragment named fragname and returns its index.
+// flags are ignored.
+func (m *MemDirfile) Include(fragname string, flags Flags) (int, error) {
+	if slices.Contains(m.fragments, fragname) {
+		return -1, newError(ErrDuplicate, "Include", "", "Fragment %s already included", fragname)
+	}
+	m.fragments = append(m.fragments, fragname)
+	return len(m.fragments) - 1, nil
+}
+
+// Entry returns the dirfile entry with the given name, following aliases
+func (m *MemDirfile) Entry(fieldcode string) (Entry, error) {
+	if fieldcode == "INDEX" {
+		return Entry{name: "INDEX", fieldType: INDEXENTRY}, nil
+	}
+	e, err := m.lookup("Entry", fieldcode)
+	if err != nil {
+		return Entry{}, err
+	}
+	return *e, nil
+}
+
+// EntryType returns the EntryType for the named field (or on error, NOENTRY)
+func (m *MemDirfile) EntryType(fieldcode string) EntryType {
+	if fieldcode == "INDEX" {
+		return INDEXENTRY
+	}
+	e, err := m.lookup("EntryType", fieldcode)
+	if err != nil {
+		return NOENTRY
+	}
+	return e.fieldType
+}
+
+// fieldList returns the non-hidden top-level fields for which keep returns true
+func (m *MemDirfile) fieldList(keep func(code string, e *Entry) bool) []string {
+	var result []string
+	if keep("INDEX", &Entry{name: "INDEX", fieldType: INDEXENTRY}) {
+		result = append(result, "INDEX")
+	}
+	for _, code := range m.order {
+		e := m.entries[code]
+		if !strings.Contains(code, "/") && !e.Hidden() && keep(code, e) {
+			result = append(result, code)
+		}
+	}
+	return result
+}
+
+// FieldList returns a slice of strings listing all fields (no metafields).
+func (m *MemDirfile) FieldList() []string {
+	return m.fieldList(func(string, *Entry) bool { return true })
+}
+
+// VectorList returns a slice of strings listing all vector fields (no metafields).
+func (m *MemDirfile) VectorList() []string {
+	return m.fieldList(func(code string, _ *Entry) bool {
+		_, err := m.vector("VectorList", code)
+		return err == nil
+	})
+}
+
+// FieldListByType returns a slice of strings listing all fields of a given type (no metafields).
+func (m *MemDirfile) FieldListByType(et EntryType) []string {
+	return m.fieldList(func(_ string, e *Entry) bool { return e.fieldType == et })
+}
+
+// MFieldList returns a slice of strings listing the names of all metafields for a particular parent.
+func (m *MemDirfile) MFieldList(parent string) []string {
+	var result []string
+	for _, code := range m.order {
+		if name, ok := strings.CutPrefix(code, parent+"/"); ok && !m.entries[code].Hidden() {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// Hidden returns whether a given field code is hidden or not
+func (m *MemDirfile) Hidden(fieldcode string) (bool, error) {
+	if fieldcode == "INDEX" {
+		return false, nil
+	}
+	e, ok := m.entries[fieldcode]
+	if !ok {
+		return false, errBadCode("Hidden", fieldcode)
+	}
+	return e.Hidden(), nil
+}
+
+// Validate checks whether a given field code is valid, returning error if it isn't.
+func (m *MemDirfile) Validate(fieldcode string) error {
+	if fieldcode == "INDEX" {
+		return nil
+	}
+	_, err := m.lookup("Validate", fieldcode)
+	return err
+}
+
+// SPF returns the number of samples per frame for a given field (or on error,
+// 0). Derived fields take the rate of their first input.
+func (m *MemDirfile) SPF(fieldcode string) int {
+	for depth := 0; depth <= len(m.entries); depth++ {
+		e, err := m.vector("SPF", fieldcode)
+		if err != nil {
+			return 0
+		}
+		switch e.fieldType {
+		case INDEXENTRY:
+			return 1
+		case RAWENTRY:
+			return int(e.spf)
+		}
+		fieldcode = e.inFields[0]
+	}
+	return 0
+}
+
+// EoF returns the end-of-field position, in samples, of a RAW field or an alias
+// to one (or on error, -1)
+func (m *MemDirfile) EoF(fieldcode string) int {
+	e, err := m.vector("EoF", fieldcode)
+	if err != nil || e.fieldType != RAWENTRY {
+		return -1
+	}
+	return m.data[e.name].Len()
+}
+
+// NativeType returns the native data type of a field or alias: the data type of
+// a RAW field, the storage type of a CONST or CARRAY field, UINT64 for INDEX,
+// and STRING for STRING and SARRAY fields. It returns UNKNOWN for derived
+// fields and on error.
+func (m *MemDirfile) NativeType(fieldcode string) RetType {
+	if fieldcode == "INDEX" {
+		return UINT64
+	}
+	e, err := m.lookup("NativeType", fieldcode)
+	if err != nil {
+		return UNKNOWN
+	}
+	switch e.fieldType {
+	case RAWENTRY:
+		return e.dataType
+	case CONSTENTRY, CARRAYENTRY:
+		return e.constType
+	case STRINGENTRY, SARRAYENTRY:
+		return STRING
+	}
+	return UNKNOWN
+}
+
+// ArrayLen returns the number of elements in a scalar field (CARRAY, CONST,
+// STRING, or SARRAY), or on error, 0
+func (m *MemDirfile) ArrayLen(fieldcode string) int {
+	e, err := m.lookup("ArrayLen", fieldcode)
+	if err != nil {
+		return 0
+	}
+	switch e.fieldType {
+	case CONSTENTRY, STRINGENTRY:
+		return 1
+	case CARRAYENTRY, SARRAYENTRY:
+		return e.arrayLen
+	}
+	return 0
+}
+
+// AddEntry adds a copy of the entry to the dirfile. Input fields of derived
+// entries need not exist yet. A RAW field starts out empty.
+func (m *MemDirfile) AddEntry(e *Entry) error {
+	if strings.Contains(e.name, "/") {
+		return newError(ErrBadCode, "AddEntry", e.name, "Field code %s names a metafield; use MAddEntry", e.name)
+	}
+	if e.fragment < 0 || e.fragment >= len(m.fragments) {
+		return newError(ErrBadIndex, "AddEntry", e.name, "Bad fragment index %d", e.fragment)
+	}
+	return m.add("AddEntry", e, e.name)
+}
+
+// MAddEntry adds a copy of the entry as a metafield of the field parent, in the
+// parent's fragment. The entry's name may be either the bare metafield name or
+// the full "parent/name" field code.
+func (m *MemDirfile) MAddEntry(e *Entry, parent string) error {
+	p, ok := m.entries[parent]
+	if !ok || strings.Contains(parent, "/") || p.fieldType == ALIASENTRY {
+		return errBadCode("MAddEntry", parent)
+	}
+	name := strings.TrimPrefix(e.name, parent+"/")
+	if name == "" || strings.Contains(name, "/") {
+		return newError(ErrBadCode, "MAddEntry", e.name, "Bad metafield name %s", e.name)
+	}
+	if e.fieldType == RAWENTRY {
+		return newError(ErrBadFieldType, "MAddEntry", e.name, "RAW fields cannot be metafields")
+	}
+	added := *e
+	added.fragment = p.fragment
+	return m.add("MAddEntry", &added, parent+"/"+name)
+}
+
+// add validates a copy of e and stores it under the field code code
+func (m *MemDirfile) add(op string, e *Entry, code string) error {
+	if code == "" || code == "INDEX" {
+		return newError(ErrBadCode, op, code, "Bad field code %q", code)
+	}
+	if _, ok := m.entries[code]; ok {
+		return newError(ErrDuplicate, op, code, "Field code already present: %s", code)
+	}
+	if isScalar(e.fieldType) {
+		if err := e.loadValue(op); err != nil {
+			return err
+		}
+	}
+	added := *e
+	added.df = nil
+	added.name = code
+
+	switch e.fieldType {
+	case RAWENTRY:
+		if !isNumeric(e.dataType) {
+			return newError(ErrBadType, op, code, "Bad data type 0x%x for field %s", e.dataType, code)
+		}
+		if e.spf == 0 {
+			return newError(ErrBadEntry, op, code, "Zero samples per frame for field %s", code)
+		}
+		m.data[code] = reflect.MakeSlice(reflect.SliceOf(memTypes[e.dataType]), 0, 0)
+	case CONSTENTRY:
+		if t, _ := value2type(e.value); !isNumeric(t) || !isNumeric(e.constType) {
+			return newError(ErrBadType, op, code, "Bad CONST type 0x%x or value %v for field %s", e.constType, e.value, code)
+		}
+		added.value = convertValue(e.value, e.constType)
+	case CARRAYENTRY:
+		if t, _, _ := array2type(e.value); !isNumeric(t) || !isNumeric(e.constType) {
+			return newError(ErrBadType, op, code, "Bad CARRAY type 0x%x or values %v for field %s", e.constType, e.value, code)
+		}
+		added.value = convertSlice(e.value, e.constType)
+		added.arrayLen = reflect.ValueOf(e.value).Len()
+	case STRINGENTRY:
+		if _, ok := e.value.(string); !ok {
+			return newError(ErrBadEntry, op, code, "Entry %s has a STRING value of type %T, want string", code, e.value)
+		}
+	case SARRAYENTRY:
+		values, ok := e.value.([]string)
+		if !ok {
+			return newError(ErrBadEntry, op, code, "Entry %s has a SARRAY value of type %T, want []string", code, e.value)
+		}
+		added.value = slices.Clone(values)
+		added.arrayLen = len(values)
+	case INDEXENTRY, NOENTRY:
+		return newError(ErrBadEntry, op, code, "Cannot add field %s of type 0x%x", code, e.fieldType)
+	}
+	m.entries[code] = &added
+	m.order = append(m.order, code)
+	return nil
+}
+
+// Delete deletes an entry from the dirfile. Without DELETEMETA, a field with
+// metafields cannot be deleted, and without DELETEFORCE, neither can a field
+// used as an input to another field. DELETEDATA and DELETEDEREF are ignored.
+func (m *MemDirfile) Delete(fieldname string, flags DeleteFlags) error {
+	if _, ok := m.entries[fieldname]; !ok {
+		return errBadCode("Delete", fieldname)
+	}
+	meta := fieldname + "/"
+	if flags&DELETEMETA == 0 && slices.ContainsFunc(m.order, func(code string) bool {
+		return strings.HasPrefix(code, meta)
+	}) {
+		return newError(ErrDelete, "Delete", fieldname, "Field %s has metafields", fieldname)
+	}
+	if flags&DELETEFORCE == 0 {
+		for code, e := range m.entries {
+			n := e.nInputs()
+			if e.fieldType == ALIASENTRY {
+				n = 1
+			}
+			if !strings.HasPrefix(code, meta) && slices.Contains(e.inFields[:n], fieldname) {
+				return newError(ErrDelete, "Delete", fieldname, "Field %s is used as an input by %s", fieldname, code)
+			}
+		}
+	}
+	m.order = slices.DeleteFunc(m.order, func(code string) bool {
+		if code == fieldname || strings.HasPrefix(code, meta) {
+			delete(m.entries, code)
+			delete(m.data, code)
+			return true
+		}
+		return false
+	})
+	return nil
+}
+
+// outSlice returns the slice pointed to by out, which must be a pointer to a
+// numeric slice
+func outSlice(op, fieldcode string, out interface{}) (reflect.Value, error) {
+	if t, _ := parray2type(out); !isNumeric(t) {
+		return reflect.Value{}, newError(ErrArgument, op, fieldcode, "%s out variable was not a pointer to numeric slice", op)
+	}
+	return reflect.ValueOf(out).Elem(), nil
+}
+
+// read copies the samples of a RAW or INDEX field from sample start into dst,
+// and returns the number copied
+func (m *MemDirfile) read(op, fieldcode string, start int, dst reflect.Value) (int, error) {
+	e, err := m.vector(op, fieldcode)
+	if err != nil {
+		return 0, err
+	}
+	switch e.fieldType {
+	case INDEXENTRY:
+		for i := 0; i < dst.Len(); i++ {
+			setNumeric(dst.Index(i), reflect.ValueOf(uint64(start+i)))
+		}
+		return dst.Len(), nil
+	case RAWENTRY:
+		samples := m.data[e.name]
+		if start >= samples.Len() {
+			return 0, nil
+		}
+		return copyNumeric(dst, samples.Slice(start, samples.Len())), nil
+	}
+	return 0, errFieldType(op, fieldcode)
+}
+
+// start returns the first sample to read, and checks the field has samples
+func (m *MemDirfile) start(op, fieldcode string, firstFrame, firstSample int) (int, int, error) {
+	if firstFrame == FRAMEHERE {
+		return 0, 0, newError(ErrArgument, op, fieldcode, "MemDirfile does not support reading at FRAMEHERE")
+	}
+	spf := m.SPF(fieldcode)
+	if spf == 0 {
+		_, err := m.vector(op, fieldcode)
+		return 0, 0, err
+	}
+	start := firstFrame*spf + firstSample
+	if start < 0 {
+		return 0, 0, newError(ErrRange, op, fieldcode, "Request out of range for field %s", fieldcode)
+	}
+	return start, spf, nil
+}
+
+// GetData fetches data from a RAW field, an alias to one, or INDEX. out should
+// be a *pointer to* a slice of numeric data of adequate size, as for
+// Dirfile.GetData. Returns (n, err) where n is the number of samples read, which
+// is short (even 0) when reading past the end of the field.
+func (m *MemDirfile) GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	dst, err := outSlice("GetData", fieldcode, out)
+	if err != nil {
+		return 0, err
+	}
+	start, spf, err := m.start("GetData", fieldcode, firstFrame, firstSample)
+	if err != nil {
+		return 0, err
+	}
+	n := numFrames*spf + numSamples
+	if n <= 0 {
+		return 0, errZeroLength("GetData", fieldcode)
+	}
+	if dst.Len() < n {
+		return 0, newError(ErrArgument, "GetData", fieldcode,
+			"GetData out slice holds %d samples, need %d", dst.Len(), n)
+	}
+	return m.read("GetData", fieldcode, start, dst.Slice(0, n))
+}
+
+// GetDataAlloc fetches data like GetData, sizing the output slice itself, as
+// for Dirfile.GetDataAlloc
+func (m *MemDirfile) GetDataAlloc(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	if _, err := outSlice("GetDataAlloc", fieldcode, out); err != nil {
+		return 0, err
+	}
+	start, spf, err := m.start("GetDataAlloc", fieldcode, firstFrame, firstSample)
+	if err != nil {
+		return 0, err
+	}
+	requested := numFrames*spf + numSamples
+	if requested <= 0 {
+		return 0, errZeroLength("GetDataAlloc", fieldcode)
+	}
+	if eof := m.EoF(fieldcode); eof >= 0 {
+		requested = max(0, min(requested, eof-start))
+	}
+	presize(out, requested)
+	n, err := m.read("GetDataAlloc", fieldcode, start, reflect.ValueOf(out).Elem())
+	presize(out, n)
+	return n, err
+}
+
+// PutData stores data to a RAW field (or an alias to one), extending it with
+// zeros if writing starts beyond its end. data should be a slice of numeric data.
+// Returns the number of samples written.
+func (m *MemDirfile) PutData(fieldcode string, firstFrame, firstSample int, data interface{}) (int, error) {
+	if t, _, _ := array2type(data); !isNumeric(t) {
+		return 0, newError(ErrArgument, "PutData", fieldcode, "PutData data variable was not a numeric slice")
+	}
+	start, _, err := m.start("PutData", fieldcode, firstFrame, firstSample)
+	if err != nil {
+		return 0, err
+	}
+	e, _ := m.vector("PutData", fieldcode)
+	if e.fieldType != RAWENTRY {
+		return 0, errFieldType("PutData", fieldcode)
+	}
+	src := reflect.ValueOf(data)
+	samples := m.data[e.name]
+	if end := start + src.Len(); end > samples.Len() {
+		samples = reflect.AppendSlice(samples, reflect.MakeSlice(samples.Type(), end-samples.Len(), end-samples.Len()))
+		m.data[e.name] = samples
+	}
+	return copyNumeric(samples.Slice(start, samples.Len()), src), nil
+}
+
+// GetConstant fills the numeric type pointed to by inptr with the value of a
+// CONST field (including metafields)
+func (m *MemDirfile) GetConstant(fieldcode string, inptr interface{}) error {
+	if t, _ := pointer2type(inptr); !isNumeric(t) {
+		return newError(ErrArgument, "GetConstant", fieldcode, "GetConstant called with ptr not a pointer to numeric type")
+	}
+	e, err := m.scalar("GetConstant", fieldcode, CONSTENTRY)
+	if err != nil {
+		return err
+	}
+	setNumeric(reflect.ValueOf(inptr).Elem(), reflect.ValueOf(e.value))
+	return nil
+}
+
+// PutConstant stores the value of a CONST field (including metafields),
+// converted to the field's storage type. data should be a value of some numeric type.
+func (m *MemDirfile) PutConstant(fieldcode string, data interface{}) error {
+	if t, _ := value2type(data); !isNumeric(t) {
+		return newError(ErrArgument, "PutConstant", fieldcode, "PutConstant data variable was not a numeric value")
+	}
+	e, err := m.scalar("PutConstant", fieldcode, CONSTENTRY)
+	if err != nil {
+		return err
+	}
+	e.value = convertValue(data, e.constType)
+	return nil
+}
+
+// GetCarray fills the numeric slice pointed to by out, which must hold at least
+// ArrayLen elements, with the values of a CARRAY field (including metafields)
+func (m *MemDirfile) GetCarray(fieldcode string, out interface{}) error {
+	dst, err := outSlice("GetCarray", fieldcode, out)
+	if err != nil {
+		return err
+	}
+	e, err := m.scalar("GetCarray", fieldcode, CARRAYENTRY)
+	if err != nil {
+		return err
+	}
+	if dst.Len() < e.arrayLen {
+		return newError(ErrBounds, "GetCarray", fieldcode,
+			"GetCarray out slice holds %d elements, need %d", dst.Len(), e.arrayLen)
+	}
+	copyNumeric(dst, reflect.ValueOf(e.value))
+	return nil
+}
+
+// PutCarray stores an entire CARRAY field (including metafields). array should
+// be a numeric slice of the field's length.
+func (m *MemDirfile) PutCarray(fieldcode string, array interface{}) error {
+	t, _, n := array2type(array)
+	if !isNumeric(t) {
+		return newError(ErrArgument, "PutCarray", fieldcode, "PutCarray array variable was not a numeric slice")
+	}
+	e, err := m.scalar("PutCarray", fieldcode, CARRAYENTRY)
+	if err != nil {
+		return err
+	}
+	if n != e.arrayLen {
+		return newError(ErrBounds, "PutCarray", fieldcode,
+			"Field %s length %d doesn't match length %d of array argument", fieldcode, e.arrayLen, n)
+	}
+	e.value = convertSlice(array, e.constType)
+	return nil
+}
+
+// GetString returns the value of a STRING field (including metafields)
+func (m *MemDirfile) GetString(fieldcode string) (string, error) {
+	e, err := m.scalar("GetString", fieldcode, STRINGENTRY)
+	if err != nil {
+		return "", err
+	}
+	return e.value.(string), nil
+}
+
+// PutString stores the value of a STRING field (including metafields)
+func (m *MemDirfile) PutString(fieldcode, value string) error {
+	e, err := m.scalar("PutString", fieldcode, STRINGENTRY)
+	if err != nil {
+		return err
+	}
+	e.value = value
+	return nil
+}
+
+// GetSarray fetches a list of the value of all elements in an SARRAY field.
+func (m *MemDirfile) GetSarray(fieldcode string) ([]string, error) {
+	e, err := m.scalar("GetSarray", fieldcode, SARRAYENTRY)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(e.value.([]string)), nil
+}
+
+// PutSarray stores an entire SARRAY field (including metafields). sarray must
+// have the field's length.
+func (m *MemDirfile) PutSarray(fieldcode string, sarray []string) error {
+	e, err := m.scalar("PutSarray", fieldcode, SARRAYENTRY)
+	if err != nil {
+		return err
+	}
+	if len(sarray) != e.arrayLen {
+		return newError(ErrArgument, "PutSarray", fieldcode, "Field %s length %d doesn't match length %d of sarray argument",
+			fieldcode, e.arrayLen, len(sarray))
+	}
+	e.value = slices.Clone(sarray)
+	return nil
+}
@@ -0,0 +1,233 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// createMemDirfile returns a MemDirfile holding a few fields of each kind
+func createMemDirfile(t *testing.T) *MemDirfile {
+	t.Helper()
+	m := NewMemDirfile("memtest")
+	if _, err := m.Include("sub", CREAT); err != nil {
+		t.Fatal("Include failed:", err)
+	}
+	entries := []Entry{
+		RawEntry("data", 0, 8, INT16),
+		RawEntry("sub_data", 1, 2, FLOAT64),
+		ConstEntry("const", FLOAT32, 5.5, 0),
+		CarrayEntry("carray", INT32, []float64{1.1, 2.2, 3.9}, 1),
+		StringEntry("string", "Zaphod Beeblebrox", 0),
+		SarrayEntry("sarray", []string{"one", "two"}, 0),
+		LincomEntry("lincom", []string{"data"}, []float64{2}, []float64{1}, 0),
+		AliasEntry("alias", "data", 0),
+	}
+	for i := range entries {
+		if err := m.AddEntry(&entries[i]); err != nil {
+			t.Fatalf("AddEntry(%s) failed: %v", entries[i].Name(), err)
+		}
+	}
+	meta := StringEntry("mstr", "a metafield", 0)
+	if err := m.MAddEntry(&meta, "data"); err != nil {
+		t.Fatal("MAddEntry failed:", err)
+	}
+	return m
+}
+
+func TestMemDirfileMetadata(t *testing.T) {
+	m := createMemDirfile(t)
+	var df Interface = m
+
+	if n := df.NFragments(); n != 2 {
+		t.Errorf("NFragments = %d, want 2", n)
+	}
+	if i, err := df.FragmentIndex("carray"); err != nil || i != 1 {
+		t.Errorf("FragmentIndex(carray) = %d (%v), want 1", i, err)
+	}
+	want := []string{"INDEX", "data", "sub_data", "const", "carray", "string", "sarray", "lincom", "alias"}
+	if fl := df.FieldList(); !slices.Equal(fl, want) {
+		t.Errorf("FieldList = %v, want %v", fl, want)
+	}
+	want = []string{"INDEX", "data", "sub_data", "lincom", "alias"}
+	if vl := df.VectorList(); !slices.Equal(vl, want) {
+		t.Errorf("VectorList = %v, want %v", vl, want)
+	}
+	if fl := df.FieldListByType(RAWENTRY); !slices.Equal(fl, []string{"data", "sub_data"}) {
+		t.Errorf("FieldListByType(RAW) = %v", fl)
+	}
+	if ml := df.MFieldList("data"); !slices.Equal(ml, []string{"mstr"}) {
+		t.Errorf("MFieldList(data) = %v, want [mstr]", ml)
+	}
+	for field, want := range map[string]EntryType{"alias": RAWENTRY, "INDEX": INDEXENTRY,
+		"data/mstr": STRINGENTRY, "nonexistent": NOENTRY} {
+		if et := df.EntryType(field); et != want {
+			t.Errorf("EntryType(%s) = %v, want %v", field, et, want)
+		}
+	}
+	for field, want := range map[string]int{"data": 8, "alias": 8, "lincom": 8, "INDEX": 1, "const": 0} {
+		if spf := df.SPF(field); spf != want {
+			t.Errorf("SPF(%s) = %d, want %d", field, spf, want)
+		}
+	}
+	for field, want := range map[string]RetType{"data": INT16, "const": FLOAT32, "carray": INT32,
+		"sarray": STRING, "lincom": UNKNOWN} {
+		if nt := df.NativeType(field); nt != want {
+			t.Errorf("NativeType(%s) = %v, want %v", field, nt, want)
+		}
+	}
+	if n := df.ArrayLen("carray"); n != 3 {
+		t.Errorf("ArrayLen(carray) = %d, want 3", n)
+	}
+	e, err := df.Entry("lincom")
+	if err != nil {
+		t.Fatal("Entry(lincom) failed:", err)
+	}
+	if in, err := e.InFields(); err != nil || !slices.Equal(in, []string{"data"}) {
+		t.Errorf("Entry(lincom).InFields = %v (%v)", in, err)
+	}
+	// A MemDirfile's entries cannot be changed through the C library
+	if err := e.Rename("lincom2", 0); !errors.Is(err, ErrArgument) {
+		t.Errorf("Entry(lincom).Rename = %v, want ErrArgument", err)
+	}
+	if err := e.Move(1, 0); !errors.Is(err, ErrArgument) {
+		t.Errorf("Entry(lincom).Move = %v, want ErrArgument", err)
+	}
+	if _, err := e.Filename(); !errors.Is(err, ErrArgument) {
+		t.Errorf("Entry(lincom).Filename = %v, want ErrArgument", err)
+	}
+	if err := df.Validate("nonexistent"); !errors.Is(err, ErrBadCode) {
+		t.Errorf("Validate(nonexistent) = %v, want ErrBadCode", err)
+	}
+
+	dup := RawEntry("data", 0, 1, UINT8)
+	if err := df.AddEntry(&dup); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("AddEntry of duplicate = %v, want ErrDuplicate", err)
+	}
+	bad := RawEntry("bad", 5, 1, UINT8)
+	if err := df.AddEntry(&bad); !errors.Is(err, ErrBadIndex) {
+		t.Errorf("AddEntry in fragment 5 = %v, want ErrBadIndex", err)
+	}
+
+	if err := df.Delete("data", 0); !errors.Is(err, ErrDelete) {
+		t.Errorf("Delete(data) with metafields = %v, want ErrDelete", err)
+	}
+	if err := df.Delete("data", DELETEMETA); !errors.Is(err, ErrDelete) {
+		t.Errorf("Delete(data) used by lincom = %v, want ErrDelete", err)
+	}
+	if err := df.Delete("data", DELETEMETA|DELETEFORCE); err != nil {
+		t.Errorf("Delete(data) failed: %v", err)
+	}
+	if et := df.EntryType("data/mstr"); et != NOENTRY {
+		t.Errorf("After Delete, EntryType(data/mstr) = %v, want NOENTRY", et)
+	}
+}
+
+func TestMemDirfileData(t *testing.T) {
+	m := createMemDirfile(t)
+	var df Interface = m
+
+	if n := df.NFrames(); n != 0 {
+		t.Errorf("NFrames of empty dirfile = %d, want 0", n)
+	}
+	in := make([]int32, 20)
+	for i := range in {
+		in[i] = int32(i + 1)
+	}
+	if n, err := df.PutData("data", 1, 0, in); err != nil || n != 20 {
+		t.Fatalf("PutData = %d (%v), want 20", n, err)
+	}
+	if eof := df.EoF("alias"); eof != 28 {
+		t.Errorf("EoF(alias) = %d, want 28", eof)
+	}
+	if n := df.NFrames(); n != 3 {
+		t.Errorf("NFrames = %d, want 3", n)
+	}
+
+	out := make([]float64, 16)
+	n, err := df.GetData("alias", 0, 4, 2, 0, &out)
+	if err != nil || n != 16 {
+		t.Fatalf("GetData(alias) = %d (%v), want 16", n, err)
+	}
+	for i, v := range out {
+		want := 0.0
+		if i >= 4 {
+			want = float64(i - 3)
+		}
+		if v != want {
+			t.Errorf("data[%d] = %v, want %v", i+4, v, want)
+		}
+	}
+
+	var alloc []uint8
+	if n, err := df.GetDataAlloc("data", 3, 0, 1, 0, &alloc); err != nil || n != 4 ||
+		!slices.Equal(alloc, []uint8{17, 18, 19, 20}) {
+		t.Errorf("GetDataAlloc(data) = %v, %d (%v)", alloc, n, err)
+	}
+	if n, err := df.GetData("data", 10, 0, 1, 0, &out); err != nil || n != 0 {
+		t.Errorf("GetData past EOF = %d (%v), want 0", n, err)
+	}
+	index := make([]int64, 3)
+	if n, err := df.GetData("INDEX", 0, 7, 0, 3, &index); err != nil || n != 3 ||
+		!slices.Equal(index, []int64{7, 8, 9}) {
+		t.Errorf("GetData(INDEX) = %v, %d (%v)", index, n, err)
+	}
+	if _, err := df.GetData("lincom", 0, 0, 1, 0, &out); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("GetData(lincom) = %v, want ErrBadFieldType", err)
+	}
+	if _, err := df.GetData("const", 0, 0, 1, 0, &out); !errors.Is(err, ErrDimension) {
+		t.Errorf("GetData(const) = %v, want ErrDimension", err)
+	}
+	if _, err := df.PutData("lincom", 0, 0, in); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("PutData(lincom) = %v, want ErrBadFieldType", err)
+	}
+}
+
+func TestMemDirfileScalars(t *testing.T) {
+	m := createMemDirfile(t)
+	var df Interface = m
+
+	var c complex128
+	if err := df.GetConstant("const", &c); err != nil || c != 5.5 {
+		t.Errorf("GetConstant(const) = %v (%v), want 5.5", c, err)
+	}
+	if err := df.PutConstant("const", int8(-3)); err != nil {
+		t.Error("PutConstant failed:", err)
+	}
+	var f float64
+	if err := df.GetConstant("const", &f); err != nil || f != -3 {
+		t.Errorf("After PutConstant, const = %v (%v), want -3", f, err)
+	}
+
+	carray := make([]float64, 3)
+	if err := df.GetCarray("carray", &carray); err != nil || !slices.Equal(carray, []float64{1, 2, 3}) {
+		t.Errorf("GetCarray(carray) = %v (%v), want [1 2 3] stored as INT32", carray, err)
+	}
+	if err := df.PutCarray("carray", []uint8{4, 5}); !errors.Is(err, ErrBounds) {
+		t.Errorf("PutCarray of wrong length = %v, want ErrBounds", err)
+	}
+	if err := df.PutCarray("carray", []uint8{4, 5, 6}); err != nil {
+		t.Error("PutCarray failed:", err)
+	}
+	if err := df.GetCarray("carray", &carray); err != nil || !slices.Equal(carray, []float64{4, 5, 6}) {
+		t.Errorf("After PutCarray, carray = %v (%v)", carray, err)
+	}
+
+	if err := df.PutString("data/mstr", "changed"); err != nil {
+		t.Error("PutString failed:", err)
+	}
+	if s, err := df.GetString("data/mstr"); err != nil || s != "changed" {
+		t.Errorf("GetString(data/mstr) = %q (%v)", s, err)
+	}
+	if err := df.PutSarray("sarray", []string{"three", "four"}); err != nil {
+		t.Error("PutSarray failed:", err)
+	}
+	if s, err := df.GetSarray("sarray"); err != nil || !slices.Equal(s, []string{"three", "four"}) {
+		t.Errorf("GetSarray(sarray) = %v (%v)", s, err)
+	}
+	if _, err := df.GetString("const"); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("GetString(const) = %v, want ErrBadFieldType", err)
+	}
+}
@@ -0,0 +1,326 @@
+package pure
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/joefowler/gogetdata/format"
+)
+
+type realNumber interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+type complexNumber interface {
+	~complex64 | ~complex128
+}
+
+// decode decodes buf as samples of type t, preceded by pad fill values: zero
+// for integer types and NaN for floating-point types, as the GetData library does.
+func decode(buf []byte, t format.RetType, order binary.ByteOrder, pad int) (interface{}, error) {
+	nan := math.NaN()
+	switch t {
+	case format.UINT8:
+		return decodeAs[uint8](buf, order, pad, 0)
+	case format.INT8:
+		return decodeAs[int8](buf, order, pad, 0)
+	case format.UINT16:
+		return decodeAs[uint16](buf, order, pad, 0)
+	case format.INT16:
+		return decodeAs[int16](buf, order, pad, 0)
+	case format.UINT32:
+		return decodeAs[uint32](buf, order, pad, 0)
+	case format.INT32:
+		return decodeAs[int32](buf, order, pad, 0)
+	case format.UINT64:
+		return decodeAs[uint64](buf, order, pad, 0)
+	case format.INT64:
+		return decodeAs[int64](buf, order, pad, 0)
+	case format.FLOAT32:
+		return decodeAs(buf, order, pad, float32(nan))
+	case format.FLOAT64:
+		return decodeAs(buf, order, pad, nan)
+	case format.COMPLEX64:
+		return decodeAs(buf, order, pad, complex(float32(nan), float32(nan)))
+	case format.COMPLEX128:
+		return decodeAs(buf, order, pad, complex(nan, nan))
+	}
+	return nil, fmt.Errorf("%w: %v", ErrBadType, t)
+}
+
+func decodeAs[T any](buf []byte, order binary.ByteOrder, pad int, fill T) ([]T, error) {
+	var zero T
+	s := make([]T, pad+len(buf)/binary.Size(zero))
+	for i := 0; i < pad; i++ {
+		s[i] = fill
+	}
+	if _, err := binary.Decode(buf, order, s[pad:]); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// outSlice checks that out is a pointer to a numeric slice of length at least n,
+// and returns the slice
+func outSlice(out interface{}, n int) (interface{}, error) {
+	var length int
+	var s interface{}
+	switch v := out.(type) {
+	case *[]uint8:
+		s, length = *v, len(*v)
+	case *[]int8:
+		s, length = *v, len(*v)
+	case *[]uint16:
+		s, length = *v, len(*v)
+	case *[]int16:
+		s, length = *v, len(*v)
+	case *[]uint32:
+		s, length = *v, len(*v)
+	case *[]int32:
+		s, length = *v, len(*v)
+	case *[]uint64:
+		s, length = *v, len(*v)
+	case *[]int64:
+		s, length = *v, len(*v)
+	case *[]float32:
+		s, length = *v, len(*v)
+	case *[]float64:
+		s, length = *v, len(*v)
+	case *[]complex64:
+		s, length = *v, len(*v)
+	case *[]complex128:
+		s, length = *v, len(*v)
+	case *[]string:
+		s, length = *v, len(*v)
+	default:
+		return nil, fmt.Errorf("%w: out was not a pointer to numeric slice", ErrBadType)
+	}
+	if length < n {
+		return nil, fmt.Errorf("%w: out holds %d samples, need %d", ErrRange, length, n)
+	}
+	return s, nil
+}
+
+// convert copies the samples of src into dst (both numeric slices), converting
+// them to dst's type, and returns the number copied. Complex values converted
+// to a real type keep only their real part.
+func convert(dst, src interface{}) (int, error) {
+	switch s := src.(type) {
+	case []uint8:
+		return convertReal(dst, s)
+	case []int8:
+		return convertReal(dst, s)
+	case []uint16:
+		return convertReal(dst, s)
+	case []int16:
+		return convertReal(dst, s)
+	case []uint32:
+		return convertReal(dst, s)
+	case []int32:
+		return convertReal(dst, s)
+	case []uint64:
+		return convertReal(dst, s)
+	case []int64:
+		return convertReal(dst, s)
+	case []float32:
+		return convertReal(dst, s)
+	case []float64:
+		return convertReal(dst, s)
+	case []complex64:
+		return convertComplex(dst, s)
+	case []complex128:
+		return convertComplex(dst, s)
+	case []string:
+		if d, ok := dst.([]string); ok {
+			return copy(d, s), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: cannot convert from %T to %T", ErrBadType, src, dst)
+}
+
+// as returns the numeric slice v converted to a new slice of type T
+func as[T any](v interface{}) []T {
+	s := make([]T, length(v))
+	convert(s, v)
+	return s
+}
+
+// length returns the length of a slice of any type
+func length(v interface{}) int {
+	return reflect.ValueOf(v).Len()
+}
+
+// truncate returns the first n elements of a slice of any type
+func truncate(v interface{}, n int) interface{} {
+	return reflect.ValueOf(v).Slice(0, n).Interface()
+}
+
+// typeOf returns the RetType of the elements of a numeric slice
+func typeOf(v interface{}) format.RetType {
+	switch v.(type) {
+	case []uint8:
+		return format.UINT8
+	case []int8:
+		return format.INT8
+	case []uint16:
+		return format.UINT16
+	case []int16:
+		return format.INT16
+	case []uint32:
+		return format.UINT32
+	case []int32:
+		return format.INT32
+	case []uint64:
+		return format.UINT64
+	case []int64:
+		return format.INT64
+	case []float32:
+		return format.FLOAT32
+	case []float64:
+		return format.FLOAT64
+	case []complex64:
+		return format.COMPLEX64
+	case []complex128:
+		return format.COMPLEX128
+	case []string:
+		return format.STRING
+	}
+	return format.UNKNOWN
+}
+
+// gather returns a new slice whose element i is v[idx[i]], or the fill value
+// (zero, NaN or "", as for padding in decode) where idx[i] is negative
+func gather(v interface{}, idx []int) interface{} {
+	nan := math.NaN()
+	switch s := v.(type) {
+	case []uint8:
+		return gatherAs(s, idx, 0)
+	case []int8:
+		return gatherAs(s, idx, 0)
+	case []uint16:
+		return gatherAs(s, idx, 0)
+	case []int16:
+		return gatherAs(s, idx, 0)
+	case []uint32:
+		return gatherAs(s, idx, 0)
+	case []int32:
+		return gatherAs(s, idx, 0)
+	case []uint64:
+		return gatherAs(s, idx, 0)
+	case []int64:
+		return gatherAs(s, idx, 0)
+	case []float32:
+		return gatherAs(s, idx, float32(nan))
+	case []float64:
+		return gatherAs(s, idx, nan)
+	case []complex64:
+		return gatherAs(s, idx, complex(float32(nan), float32(nan)))
+	case []complex128:
+		return gatherAs(s, idx, complex(nan, nan))
+	case []string:
+		return gatherAs(s, idx, "")
+	}
+	return v
+}
+
+func gatherAs[T any](s []T, idx []int, fill T) []T {
+	out := make([]T, len(idx))
+	for i, j := range idx {
+		if j < 0 {
+			out[i] = fill
+		} else {
+			out[i] = s[j]
+		}
+	}
+	return out
+}
+
+func convertReal[S realNumber](dst interface{}, src []S) (int, error) {
+	switch d := dst.(type) {
+	case []uint8:
+		return castReal(d, src), nil
+	case []int8:
+		return castReal(d, src), nil
+	case []uint16:
+		return castReal(d, src), nil
+	case []int16:
+		return castReal(d, src), nil
+	case []uint32:
+		return castReal(d, src), nil
+	case []int32:
+		return castReal(d, src), nil
+	case []uint64:
+		return castReal(d, src), nil
+	case []int64:
+		return castReal(d, src), nil
+	case []float32:
+		return castReal(d, src), nil
+	case []float64:
+		return castReal(d, src), nil
+	case []complex64:
+		return realToComplex(d, src), nil
+	case []complex128:
+		return realToComplex(d, src), nil
+	}
+	return 0, fmt.Errorf("%w: cannot convert to %T", ErrBadType, dst)
+}
+
+func convertComplex[S complexNumber](dst interface{}, src []S) (int, error) {
+	switch d := dst.(type) {
+	case []uint8:
+		return complexToReal(d, src), nil
+	case []int8:
+		return complexToReal(d, src), nil
+	case []uint16:
+		return complexToReal(d, src), nil
+	case []int16:
+		return complexToReal(d, src), nil
+	case []uint32:
+		return complexToReal(d, src), nil
+	case []int32:
+		return complexToReal(d, src), nil
+	case []uint64:
+		return complexToReal(d, src), nil
+	case []int64:
+		return complexToReal(d, src), nil
+	case []float32:
+		return complexToReal(d, src), nil
+	case []float64:
+		return complexToReal(d, src), nil
+	case []complex64:
+		return castComplex(d, src), nil
+	case []complex128:
+		return castComplex(d, src), nil
+	}
+	return 0, fmt.Errorf("%w: cannot convert to %T", ErrBadType, dst)
+}
+
+func castReal[D, S realNumber](dst []D, src []S) int {
+	for i, v := range src {
+		dst[i] = D(v)
+	}
+	return len(src)
+}
+
+func realToComplex[D complexNumber, S realNumber](dst []D, src []S) int {
+	for i, v := range src {
+		dst[i] = D(complex(float64(v), 0))
+	}
+	return len(src)
+}
+
+func complexToReal[D realNumber, S complexNumber](dst []D, src []S) int {
+	for i, v := range src {
+		dst[i] = D(real(complex128(v)))
+	}
+	return len(src)
+}
+
+func castComplex[D, S complexNumber](dst []D, src []S) int {
+	for i, v := range src {
+		dst[i] = D(v)
+	}
+	return len(src)
+}
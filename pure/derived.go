@@ -0,0 +1,880 @@
+package pure
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joefowler/gogetdata/format"
+)
+
+// The arithmetic below follows the expressions of the GetData library term by
+// term, so that results match it bit for bit. Products are wrapped in explicit
+// conversions wherever they are summed, which forbids the compiler from fusing
+// them into multiply-adds that the C library does not perform.
+
+// scalarText returns the text and type of a parameter given by a CONST or CARRAY field
+func (d *Dirfile) scalarText(p format.Param) (string, format.RetType, error) {
+	e := d.format.Entry(p.Field)
+	idx := max(p.Index, 0)
+	if e == nil || (e.Type != format.CONSTENTRY && e.Type != format.CARRAYENTRY) || idx >= len(e.Values) {
+		return "", format.UNKNOWN, fmt.Errorf("%w: %s", ErrBadScalar, p.String())
+	}
+	return e.Values[idx], e.DataType, nil
+}
+
+// intParam returns the value of an integer parameter, which may be given by a
+// CONST or CARRAY field
+func (d *Dirfile) intParam(p format.Param) (int64, error) {
+	if p.IsLiteral() {
+		return p.Int()
+	}
+	text, _, err := d.scalarText(p)
+	if err != nil {
+		return 0, err
+	}
+	return format.Param{Literal: text}.Int()
+}
+
+// scalar returns the value of a parameter, which may be given by a CONST or
+// CARRAY field, and whether it is complex-valued. A value taken from a field is
+// first rounded to the field's storage type.
+func (d *Dirfile) scalar(p format.Param) (complex128, bool, error) {
+	if p.IsLiteral() {
+		v, err := p.Complex()
+		return v, strings.Contains(p.Literal, ";"), err
+	}
+	text, t, err := d.scalarText(p)
+	if err != nil {
+		return 0, false, err
+	}
+	src, err := parseValues([]string{text}, t)
+	if err != nil {
+		return 0, false, err
+	}
+	dst := makeSlice(t, 1)
+	convert(dst, src)
+	v := as[complex128](dst)[0]
+	return v, t.IsComplex(), nil
+}
+
+// scalars evaluates a list of parameters, also reporting whether any is complex-valued
+func (d *Dirfile) scalars(params []format.Param) ([]complex128, bool, error) {
+	values := make([]complex128, len(params))
+	anyComplex := false
+	for i, p := range params {
+		v, isComplex, err := d.scalar(p)
+		if err != nil {
+			return nil, false, err
+		}
+		values[i] = v
+		anyComplex = anyComplex || isComplex
+	}
+	return values, anyComplex, nil
+}
+
+// parseValues parses CONST or CARRAY values to a slice of int64, uint64,
+// float64 or complex128, according to the storage type t
+func parseValues(values []string, t format.RetType) (interface{}, error) {
+	var err error
+	switch {
+	case t.IsComplex():
+		s := make([]complex128, len(values))
+		for i, v := range values {
+			if s[i], err = format.ParseNumber(v); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	case t.IsFloat():
+		s := make([]float64, len(values))
+		for i, v := range values {
+			if s[i], err = (format.Param{Literal: v}).Float(); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	case t.IsSigned():
+		s := make([]int64, len(values))
+		for i, v := range values {
+			if s[i], err = (format.Param{Literal: v}).Int(); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+	s := make([]uint64, len(values))
+	for i, v := range values {
+		if s[i], err = (format.Param{Literal: v}).Uint(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// vectorInputs returns the input field codes of e that are vector fields
+func vectorInputs(e *format.Entry) []string {
+	switch e.Type {
+	case format.LINCOMENTRY, format.MULTIPLYENTRY, format.DIVIDEENTRY, format.WINDOWENTRY,
+		format.MPLEXENTRY:
+		return e.InFields
+	case format.RAWENTRY, format.INDEXENTRY:
+		return nil
+	}
+	return e.InFields[:1]
+}
+
+// nativeType returns the native data type of a vector field
+func (d *Dirfile) nativeType(e *format.Entry, depth int) (format.RetType, error) {
+	if depth > maxDepth {
+		return format.UNKNOWN, fmt.Errorf("%w: %s", ErrRecurse, e.Name)
+	}
+	var params []format.Param
+	switch e.Type {
+	case format.RAWENTRY:
+		return e.DataType, nil
+	case format.INDEXENTRY, format.BITENTRY:
+		return format.UINT64, nil
+	case format.SBITENTRY:
+		return format.INT64, nil
+	case format.SINDIRENTRY:
+		return format.STRING, nil
+	case format.INDIRENTRY:
+		ce := d.format.Entry(e.InFields[1])
+		if ce == nil || ce.Type != format.CARRAYENTRY {
+			return format.UNKNOWN, fmt.Errorf("%w: %s is not a CARRAY", ErrBadFieldType, e.InFields[1])
+		}
+		return ce.DataType, nil
+	case format.PHASEENTRY, format.WINDOWENTRY, format.MPLEXENTRY:
+		in, err := d.vectorEntry(e.InFields[0], depth+1)
+		if err != nil {
+			return format.UNKNOWN, err
+		}
+		return d.nativeType(in, depth+1)
+	case format.LINTERPENTRY:
+		table, err := d.table(e)
+		if err != nil {
+			return format.UNKNOWN, err
+		}
+		if table.complex {
+			return format.COMPLEX128, nil
+		}
+		return format.FLOAT64, nil
+	case format.LINCOMENTRY:
+		params = append(append(params, e.Scale...), e.Offset...)
+	case format.POLYNOMENTRY:
+		params = e.Coeffs
+	case format.RECIPENTRY:
+		params = []format.Param{e.Dividend}
+	}
+
+	// The remaining types are complex if any input or parameter is
+	_, isComplex, err := d.scalars(params)
+	if err != nil {
+		return format.UNKNOWN, err
+	}
+	for _, code := range vectorInputs(e) {
+		in, err := d.vectorEntry(code, depth+1)
+		if err != nil {
+			return format.UNKNOWN, err
+		}
+		t, err := d.nativeType(in, depth+1)
+		if err != nil {
+			return format.UNKNOWN, err
+		}
+		isComplex = isComplex || t.IsComplex()
+	}
+	if isComplex {
+		return format.COMPLEX128, nil
+	}
+	return format.FLOAT64, nil
+}
+
+// eof returns the end of a vector field, in samples. A derived field ends with
+// the first of its inputs to end; the INDEX field has no end.
+func (d *Dirfile) eof(e *format.Entry, depth int) (int, error) {
+	switch e.Type {
+	case format.INDEXENTRY:
+		return 0, fmt.Errorf("%w: INDEX has no end", ErrBadFieldType)
+	case format.RAWENTRY:
+		spf, err := d.spf(e, depth)
+		if err != nil {
+			return 0, err
+		}
+		nbytes, err := d.rawSize(e)
+		if err != nil {
+			return 0, err
+		}
+		frag := d.format.Fragments[e.Fragment]
+		return int(frag.FrameOffset)*spf + int(nbytes)/e.DataType.Size(), nil
+	}
+
+	spf, err := d.spf(e, depth)
+	if err != nil {
+		return 0, err
+	}
+	eof := -1
+	for _, code := range vectorInputs(e) {
+		in, err := d.vectorEntry(code, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		if in.Type == format.INDEXENTRY {
+			continue
+		}
+		inEoF, err := d.eof(in, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		inSPF, err := d.spf(in, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		// Inputs at other rates are resampled, so that output sample i uses
+		// input sample i*inSPF/spf
+		inEoF = (inEoF*spf + inSPF - 1) / inSPF
+		if eof < 0 || inEoF < eof {
+			eof = inEoF
+		}
+	}
+	if eof < 0 {
+		return 0, fmt.Errorf("%w: %s depends only on INDEX", ErrBadFieldType, e.Name)
+	}
+	if e.Type == format.PHASEENTRY {
+		shift, err := d.intParam(e.Shift)
+		if err != nil {
+			return 0, err
+		}
+		eof = max(eof-int(shift), 0)
+	}
+	return eof, nil
+}
+
+// bof returns the beginning of a vector field, in samples. A derived field
+// begins with the last of its inputs to begin; the INDEX field begins at 0.
+func (d *Dirfile) bof(e *format.Entry, depth int) (int, error) {
+	switch e.Type {
+	case format.INDEXENTRY:
+		return 0, nil
+	case format.RAWENTRY:
+		spf, err := d.spf(e, depth)
+		if err != nil {
+			return 0, err
+		}
+		return int(d.format.Fragments[e.Fragment].FrameOffset) * spf, nil
+	}
+
+	spf, err := d.spf(e, depth)
+	if err != nil {
+		return 0, err
+	}
+	bof := 0
+	for _, code := range vectorInputs(e) {
+		in, err := d.vectorEntry(code, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		inBoF, err := d.bof(in, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		inSPF, err := d.spf(in, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		bof = max(bof, (inBoF*spf+inSPF-1)/inSPF)
+	}
+	if e.Type == format.PHASEENTRY {
+		shift, err := d.intParam(e.Shift)
+		if err != nil {
+			return 0, err
+		}
+		bof = max(bof-int(shift), 0)
+	}
+	return bof, nil
+}
+
+// read returns samples [start, start+n) of a vector field as a slice of its
+// native type. It is shorter than n if the field ends first.
+func (d *Dirfile) read(e *format.Entry, start, n, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: %s", ErrRecurse, e.Name)
+	}
+	if n <= 0 {
+		t, err := d.nativeType(e, depth)
+		if err != nil {
+			return nil, err
+		}
+		return makeSlice(t, 0), nil
+	}
+	switch e.Type {
+	case format.RAWENTRY:
+		spf, err := d.spf(e, depth)
+		if err != nil {
+			return nil, err
+		}
+		return d.readRaw(e, spf, start, n)
+	case format.INDEXENTRY:
+		s := make([]uint64, n)
+		for i := range s {
+			s[i] = uint64(start + i)
+		}
+		return s, nil
+	case format.LINCOMENTRY:
+		return d.readLincom(e, start, n, depth)
+	case format.LINTERPENTRY:
+		return d.readLinterp(e, start, n, depth)
+	case format.BITENTRY, format.SBITENTRY:
+		return d.readBit(e, start, n, depth)
+	case format.MULTIPLYENTRY, format.DIVIDEENTRY:
+		return d.readArithmetic(e, start, n, depth)
+	case format.RECIPENTRY:
+		return d.readRecip(e, start, n, depth)
+	case format.PHASEENTRY:
+		return d.readPhase(e, start, n, depth)
+	case format.POLYNOMENTRY:
+		return d.readPolynom(e, start, n, depth)
+	case format.WINDOWENTRY:
+		return d.readWindow(e, start, n, depth)
+	case format.MPLEXENTRY:
+		return d.readMplex(e, start, n, depth)
+	case format.INDIRENTRY, format.SINDIRENTRY:
+		return d.readIndir(e, start, n, depth)
+	}
+	return nil, fmt.Errorf("%w: %s is %v", ErrBadFieldType, e.Name, e.Type)
+}
+
+// inputs reads the vector inputs of e (the first count of them) for output
+// samples [start, start+n). Inputs at a different sample rate are resampled to
+// e's rate as the GetData library does: output sample i takes input sample
+// i*inSPF/spf, counting from input sample start*inSPF/spf. All are truncated
+// to the length of the shortest.
+func (d *Dirfile) inputs(e *format.Entry, count, start, n, depth int) ([]interface{}, int, error) {
+	spf, err := d.spf(e, depth)
+	if err != nil {
+		return nil, 0, err
+	}
+	vals := make([]interface{}, count)
+	for k, code := range e.InFields[:count] {
+		in, err := d.vectorEntry(code, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		inSPF, err := d.spf(in, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if inSPF == spf {
+			vals[k], err = d.read(in, start, n, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+		} else {
+			v, err := d.read(in, start*inSPF/spf, (n-1)*inSPF/spf+1, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m := min(n, (length(v)*spf+inSPF-1)/inSPF)
+			idx := make([]int, m)
+			for i := range idx {
+				idx[i] = i * inSPF / spf
+			}
+			vals[k] = gather(v, idx)
+		}
+		n = min(n, length(vals[k]))
+	}
+	for k := range vals {
+		vals[k] = truncate(vals[k], n)
+	}
+	return vals, n, nil
+}
+
+func (d *Dirfile) readLincom(e *format.Entry, start, n, depth int) (interface{}, error) {
+	nf := len(e.InFields)
+	vals, n, err := d.inputs(e, nf, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	m, mComplex, err := d.scalars(e.Scale)
+	if err != nil {
+		return nil, err
+	}
+	b, bComplex, err := d.scalars(e.Offset)
+	if err != nil {
+		return nil, err
+	}
+	isComplex := mComplex || bComplex
+	for _, v := range vals {
+		isComplex = isComplex || typeOf(v).IsComplex()
+	}
+
+	// y = x0*m0 + b0 + x1*m1 + b1 + x2*m2 + b2, summed left to right
+	if isComplex {
+		y := make([]complex128, n)
+		for k, v := range vals {
+			x := as[complex128](v)
+			for i := range y {
+				if k == 0 {
+					y[i] = complex128(x[i]*m[k]) + b[k]
+				} else {
+					y[i] = y[i] + complex128(x[i]*m[k]) + b[k]
+				}
+			}
+		}
+		return y, nil
+	}
+	y := make([]float64, n)
+	for k, v := range vals {
+		x := as[float64](v)
+		mk, bk := real(m[k]), real(b[k])
+		for i := range y {
+			if k == 0 {
+				y[i] = float64(x[i]*mk) + bk
+			} else {
+				y[i] = y[i] + float64(x[i]*mk) + bk
+			}
+		}
+	}
+	return y, nil
+}
+
+// lut is a LINTERP look-up table, sorted by x
+type lut struct {
+	x       []float64
+	y       []complex128
+	complex bool
+}
+
+// table loads (or returns the cached) look-up table of a LINTERP field. Its
+// path is relative to the directory of the defining format file.
+func (d *Dirfile) table(e *format.Entry) (*lut, error) {
+	name := e.Table
+	var data []byte
+	var err error
+	if path.IsAbs(name) {
+		data, err = os.ReadFile(name)
+	} else {
+		frag := d.format.Fragments[e.Fragment]
+		name = path.Join(d.root, path.Dir(frag.Filename), name)
+		if t, ok := d.tables[name]; ok {
+			return t, nil
+		}
+		data, err = fs.ReadFile(d.fsys, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrLUT, e.Table, err)
+	}
+
+	t := &lut{}
+	type point struct {
+		x float64
+		y complex128
+	}
+	var points []point
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		tokens := strings.Fields(scanner.Text())
+		if len(tokens) < 2 || strings.HasPrefix(tokens[0], "#") {
+			continue
+		}
+		x, err := strconv.ParseFloat(tokens[0], 64)
+		if err != nil {
+			continue
+		}
+		y, err := format.ParseNumber(tokens[1])
+		if err != nil {
+			continue
+		}
+		t.complex = t.complex || strings.Contains(tokens[1], ";")
+		points = append(points, point{x, y})
+	}
+	if len(points) < 2 {
+		return nil, fmt.Errorf("%w: %s has fewer than 2 points", ErrLUT, e.Table)
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].x < points[j].x })
+	for _, p := range points {
+		t.x = append(t.x, p.x)
+		t.y = append(t.y, p.y)
+	}
+	d.tables[name] = t
+	return t, nil
+}
+
+// index returns the table segment used for interpolating at x: the last point
+// not above x, limited so that values beyond the table are extrapolated from
+// its end segments.
+func (t *lut) index(x float64) int {
+	i := sort.Search(len(t.x), func(i int) bool { return t.x[i] > x }) - 1
+	return min(max(i, 0), len(t.x)-2)
+}
+
+func (d *Dirfile) readLinterp(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 1, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	t, err := d.table(e)
+	if err != nil {
+		return nil, err
+	}
+	x := as[float64](vals[0])
+
+	// y = (y[i+1]-y[i]) / (x[i+1]-x[i]) * (x-x[i]) + y[i]
+	if t.complex {
+		y := make([]complex128, n)
+		for i, v := range x {
+			j := t.index(v)
+			dx := t.x[j+1] - t.x[j]
+			re := float64((real(t.y[j+1])-real(t.y[j]))/dx*(v-t.x[j])) + real(t.y[j])
+			im := float64((imag(t.y[j+1])-imag(t.y[j]))/dx*(v-t.x[j])) + imag(t.y[j])
+			y[i] = complex(re, im)
+		}
+		return y, nil
+	}
+	y := make([]float64, n)
+	for i, v := range x {
+		j := t.index(v)
+		y[i] = float64((real(t.y[j+1])-real(t.y[j]))/(t.x[j+1]-t.x[j])*(v-t.x[j])) + real(t.y[j])
+	}
+	return y, nil
+}
+
+func (d *Dirfile) readBit(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 1, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	bitnum, err := d.intParam(e.Bitnum)
+	if err != nil {
+		return nil, err
+	}
+	numbits, err := d.intParam(e.Numbits)
+	if err != nil {
+		return nil, err
+	}
+	if bitnum < 0 || numbits < 1 || bitnum+numbits > 64 {
+		return nil, fmt.Errorf("%w: %s bits %d to %d", ErrRange, e.Name, bitnum, bitnum+numbits-1)
+	}
+
+	if e.Type == format.SBITENTRY {
+		x := as[int64](vals[0])
+		y := make([]int64, n)
+		for i, v := range x {
+			y[i] = int64(uint64(v)<<(64-bitnum-numbits)) >> (64 - numbits)
+		}
+		return y, nil
+	}
+	mask := ^uint64(0) >> (64 - numbits)
+	x := as[uint64](vals[0])
+	y := make([]uint64, n)
+	for i, v := range x {
+		y[i] = (v >> bitnum) & mask
+	}
+	return y, nil
+}
+
+func (d *Dirfile) readArithmetic(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 2, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	divide := e.Type == format.DIVIDEENTRY
+	if typeOf(vals[0]).IsComplex() || typeOf(vals[1]).IsComplex() {
+		a, b := as[complex128](vals[0]), as[complex128](vals[1])
+		y := make([]complex128, n)
+		for i := range y {
+			if divide {
+				y[i] = a[i] / b[i]
+			} else {
+				y[i] = a[i] * b[i]
+			}
+		}
+		return y, nil
+	}
+	a, b := as[float64](vals[0]), as[float64](vals[1])
+	y := make([]float64, n)
+	for i := range y {
+		if divide {
+			y[i] = a[i] / b[i]
+		} else {
+			y[i] = a[i] * b[i]
+		}
+	}
+	return y, nil
+}
+
+func (d *Dirfile) readRecip(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 1, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	dividend, isComplex, err := d.scalar(e.Dividend)
+	if err != nil {
+		return nil, err
+	}
+	if isComplex || typeOf(vals[0]).IsComplex() {
+		x := as[complex128](vals[0])
+		y := make([]complex128, n)
+		for i, v := range x {
+			y[i] = dividend / v
+		}
+		return y, nil
+	}
+	x := as[float64](vals[0])
+	y := make([]float64, n)
+	for i, v := range x {
+		y[i] = real(dividend) / v
+	}
+	return y, nil
+}
+
+func (d *Dirfile) readPhase(e *format.Entry, start, n, depth int) (interface{}, error) {
+	in, err := d.vectorEntry(e.InFields[0], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	shift, err := d.intParam(e.Shift)
+	if err != nil {
+		return nil, err
+	}
+	// Samples shifted from before the start of the input are filled in
+	first := start + int(shift)
+	pad := 0
+	if first < 0 {
+		pad = min(-first, n)
+		first = 0
+	}
+	v, err := d.read(in, first, n-pad, depth+1)
+	if err != nil || pad == 0 {
+		return v, err
+	}
+	idx := make([]int, pad+length(v))
+	for i := range idx {
+		idx[i] = i - pad
+	}
+	return gather(v, idx), nil
+}
+
+func (d *Dirfile) readPolynom(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 1, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	a, isComplex, err := d.scalars(e.Coeffs)
+	if err != nil {
+		return nil, err
+	}
+	order := len(a) - 1
+
+	// y = x*...*x*a[order] + ... + x*x*a[2] + x*a[1] + a[0], summed left to right
+	if isComplex || typeOf(vals[0]).IsComplex() {
+		x := as[complex128](vals[0])
+		y := make([]complex128, n)
+		for i, v := range x {
+			var sum complex128
+			for k := order; k >= 1; k-- {
+				term := v
+				for j := 1; j < k; j++ {
+					term = complex128(term * v)
+				}
+				term = complex128(term * a[k])
+				if k == order {
+					sum = term
+				} else {
+					sum = sum + term
+				}
+			}
+			y[i] = sum + a[0]
+		}
+		return y, nil
+	}
+	x := as[float64](vals[0])
+	y := make([]float64, n)
+	for i, v := range x {
+		var sum float64
+		for k := order; k >= 1; k-- {
+			term := v
+			for j := 1; j < k; j++ {
+				term = float64(term * v)
+			}
+			term = float64(term * real(a[k]))
+			if k == order {
+				sum = term
+			} else {
+				sum = sum + term
+			}
+		}
+		y[i] = sum + real(a[0])
+	}
+	return y, nil
+}
+
+func (d *Dirfile) readWindow(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 2, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	check := vals[1]
+	ct := typeOf(check)
+	pass := make([]bool, n)
+
+	switch {
+	case e.WindowOp == format.WINDOPSET || e.WindowOp == format.WINDOPCLR:
+		th, err := d.intParam(e.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range as[uint64](check) {
+			if e.WindowOp == format.WINDOPSET {
+				pass[i] = c&uint64(th) != 0
+			} else {
+				pass[i] = ^c&uint64(th) != 0
+			}
+		}
+	case ct.IsFloat() || ct.IsComplex():
+		th, _, err := d.scalar(e.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range as[float64](check) {
+			pass[i] = compare(e.WindowOp, c, real(th))
+		}
+	case ct.IsSigned():
+		th, err := d.intParam(e.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range as[int64](check) {
+			pass[i] = compare(e.WindowOp, c, th)
+		}
+	default:
+		th, err := d.intParam(e.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range as[uint64](check) {
+			pass[i] = compare(e.WindowOp, c, uint64(th))
+		}
+	}
+
+	// Samples failing the check are filled in
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = -1
+		if pass[i] {
+			idx[i] = i
+		}
+	}
+	return gather(vals[0], idx), nil
+}
+
+func compare[T int64 | uint64 | float64](op format.WindowOps, c, th T) bool {
+	switch op {
+	case format.WINDOPEQ:
+		return c == th
+	case format.WINDOPNE:
+		return c != th
+	case format.WINDOPGE:
+		return c >= th
+	case format.WINDOPGT:
+		return c > th
+	case format.WINDOPLE:
+		return c <= th
+	case format.WINDOPLT:
+		return c < th
+	}
+	return false
+}
+
+func (d *Dirfile) readMplex(e *format.Entry, start, n, depth int) (interface{}, error) {
+	countVal, err := d.intParam(e.CountVal)
+	if err != nil {
+		return nil, err
+	}
+	period, err := d.intParam(e.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search back for the value in force at the first sample: lookback cycles
+	// of period samples, or of one frame if the period is not given
+	cycle := int(period)
+	if cycle <= 0 {
+		if cycle, err = d.spf(e, depth); err != nil {
+			return nil, err
+		}
+	}
+	back := start
+	if d.lookback != LOOKBACKALL {
+		back = min(start, d.lookback*cycle)
+	}
+	vals, total, err := d.inputs(e, 2, start-back, n+back, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	count := as[int64](vals[1])
+	idx := make([]int, max(total-back, 0))
+	last := -1
+	for i := 0; i < total; i++ {
+		if count[i] == countVal {
+			last = i
+		}
+		if i >= back {
+			idx[i-back] = last
+		}
+	}
+	return gather(vals[0], idx), nil
+}
+
+func (d *Dirfile) readIndir(e *format.Entry, start, n, depth int) (interface{}, error) {
+	vals, n, err := d.inputs(e, 1, start, n, depth)
+	if err != nil {
+		return nil, err
+	}
+	want := format.CARRAYENTRY
+	if e.Type == format.SINDIRENTRY {
+		want = format.SARRAYENTRY
+	}
+	ae := d.format.Entry(e.InFields[1])
+	if ae == nil || ae.Type != want {
+		return nil, fmt.Errorf("%w: %s is not a %v", ErrBadFieldType, e.InFields[1], want)
+	}
+
+	// Indices outside the array give zero (or NaN, or an empty string)
+	idx := make([]int, n)
+	for i, k := range as[int64](vals[0]) {
+		idx[i] = -1
+		if k >= 0 && k < int64(len(ae.Values)) {
+			idx[i] = int(k)
+		}
+	}
+	if e.Type == format.SINDIRENTRY {
+		return gather(ae.Values, idx), nil
+	}
+	src, err := parseValues(ae.Values, ae.DataType)
+	if err != nil {
+		return nil, err
+	}
+	array := makeSlice(ae.DataType, len(ae.Values))
+	if _, err := convert(array, src); err != nil {
+		return nil, err
+	}
+	return gather(array, idx), nil
+}
+
+// makeSlice returns a zeroed slice of n values of type t
+func makeSlice(t format.RetType, n int) interface{} {
+	if t == format.STRING {
+		return make([]string, n)
+	}
+	s, _ := decode(make([]byte, n*t.Size()), t, binary.LittleEndian, 0)
+	return s
+}
@@ -0,0 +1,185 @@
+package pure
+
+import (
+	"math"
+	"math/cmplx"
+	"slices"
+	"testing"
+
+	"github.com/joefowler/gogetdata/format"
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+// createTestDirfile writes the dirfile of package getdata's tests, and returns
+// its path
+func createTestDirfile(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := testdirfile.Write(dir, testdirfile.Format); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDerivedMetadata(t *testing.T) {
+	d, err := Open(createTestDirfile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	types := map[string]format.RetType{
+		"alias": format.INT8, "lincom": format.COMPLEX128, "linterp": format.FLOAT64,
+		"polynom": format.COMPLEX128, "bit": format.UINT64, "sbit": format.INT64,
+		"mplex": format.INT8, "mult": format.FLOAT64, "div": format.FLOAT64,
+		"recip": format.COMPLEX128, "phase": format.INT8, "window": format.FLOAT64,
+		"indir": format.FLOAT64, "sindir": format.STRING, "INDEX": format.UINT64,
+	}
+	for field, want := range types {
+		if got, err := d.NativeType(field); err != nil || got != want {
+			t.Errorf("NativeType(%s) = %v (%v), want %v", field, got, err, want)
+		}
+	}
+	for field, want := range map[string]int{"lincom": 80, "mplex": 80, "phase": 69, "recip": 80} {
+		if got, err := d.EoF(field); err != nil || got != want {
+			t.Errorf("EoF(%s) = %d (%v), want %d", field, got, err, want)
+		}
+	}
+	if spf, err := d.SPF("lincom"); err != nil || spf != 8 {
+		t.Errorf("SPF(lincom) = %d (%v), want 8", spf, err)
+	}
+	if spf, err := d.SPF("INDEX"); err != nil || spf != 1 {
+		t.Errorf("SPF(INDEX) = %d (%v), want 1", spf, err)
+	}
+	if _, err := d.EoF("INDEX"); err == nil {
+		t.Errorf("EoF(INDEX) did not fail")
+	}
+	if _, err := d.SPF("const"); err == nil {
+		t.Errorf("SPF(const) did not fail")
+	}
+}
+
+// TestDerivedData checks derived fields against values computed here, to within
+// rounding; TestPureMatchesC in package getdata checks that they match the C
+// library exactly.
+func TestDerivedData(t *testing.T) {
+	d, err := Open(createTestDirfile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	const n = 80
+	get := func(field string, out interface{}) {
+		t.Helper()
+		if got, err := d.GetData(field, 0, 0, 10, 0, out); err != nil || got != n {
+			t.Fatalf("GetData(%s) = %d (%v), want %d", field, got, err, n)
+		}
+	}
+	x := func(i int) float64 { return float64(i + 1) }
+	bit := func(i int) float64 { return float64((i + 1) >> 3 & 15) }
+	sbit := func(i int) float64 { return float64((i + 1) >> 5) }
+
+	c := make([]complex128, n)
+	get("lincom", &c)
+	for i, v := range c {
+		want := complex(x(i)*1.1+2.2+float64(i/8)*2.2, 0) + complex(3.3, 4.4) +
+			complex(2*x(i)*5.5+5.5, 0)
+		if cmplx.Abs(v-want) > 1e-9 {
+			t.Errorf("lincom[%d] = %v, want %v", i, v, want)
+		}
+	}
+
+	get("polynom", &c)
+	for i, v := range c {
+		xi := complex(x(i), 0)
+		want := 1.1 + 2.2*xi + 2.2*xi*xi + complex(3.3, 4.4)*xi*xi*xi + 5.5*xi*xi*xi*xi +
+			5.5*xi*xi*xi*xi*xi
+		if cmplx.Abs(v-want) > 1e-9*cmplx.Abs(want) {
+			t.Errorf("polynom[%d] = %v, want %v", i, v, want)
+		}
+	}
+
+	get("recip", &c)
+	for i, v := range c {
+		div := x(i) * sbit(i) / bit(i)
+		if want := complex(6.5, 4.3) / complex(div, 0); cmplx.Abs(v-want) > 1e-9 &&
+			!(cmplx.IsNaN(v) && cmplx.IsNaN(want)) && !(cmplx.IsInf(v) && cmplx.IsInf(want)) {
+			t.Errorf("recip[%d] = %v, want %v", i, v, want)
+		}
+	}
+
+	f := make([]float64, n)
+	checks := map[string]func(i int) float64{
+		"linterp": func(i int) float64 { return 2 * x(i) },
+		"bit":     bit,
+		"sbit":    sbit,
+		"mult":    func(i int) float64 { return x(i) * sbit(i) },
+		"phase": func(i int) float64 {
+			if i+11 >= n {
+				return math.NaN() // not read
+			}
+			return x(i + 11)
+		},
+		"mplex": func(i int) float64 {
+			switch {
+			case x(i) < 32:
+				return 0
+			case x(i) < 64:
+				return x(i)
+			}
+			return 63
+		},
+		"window": func(i int) float64 {
+			if x(i)*sbit(i) < 4.1 {
+				return 2 * x(i)
+			}
+			return math.NaN()
+		},
+		"indir": func(i int) float64 {
+			if i+1 < 6 {
+				return 1.1 * float64(i+2)
+			}
+			return math.NaN()
+		},
+	}
+	for field, want := range checks {
+		nread, err := d.GetData(field, 0, 0, 10, 0, &f)
+		if err != nil {
+			t.Fatalf("GetData(%s) failed: %v", field, err)
+		}
+		for i := 0; i < nread; i++ {
+			w := want(i)
+			if math.Abs(f[i]-w) > 1e-9 && !(math.IsNaN(f[i]) && math.IsNaN(w)) {
+				t.Errorf("%s[%d] = %v, want %v", field, i, f[i], w)
+			}
+		}
+	}
+	if nread, _ := d.GetData("phase", 0, 0, 10, 0, &f); nread != n-11 {
+		t.Errorf("GetData(phase) read %d samples, want %d", nread, n-11)
+	}
+
+	s := make([]string, 8)
+	if nread, err := d.GetData("sindir", 0, 0, 1, 0, &s); err != nil || nread != 8 ||
+		!slices.Equal(s, []string{"two", "three", "four", "five", "six", "seven", "", ""}) {
+		t.Errorf("GetData(sindir) = %q, %d (%v)", s, nread, err)
+	}
+
+	u := make([]uint64, 4)
+	if nread, err := d.GetData("INDEX", 3, 0, 0, 4, &u); err != nil || nread != 4 ||
+		!slices.Equal(u, []uint64{3, 4, 5, 6}) {
+		t.Errorf("GetData(INDEX) = %v, %d (%v)", u, nread, err)
+	}
+}
+
+func TestMplexLookback(t *testing.T) {
+	d, err := Open(createTestDirfile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	// mplex holds data=63 from sample 62 on; reading from sample 72 must look
+	// back at least 10 samples (one cycle of 10) to find it
+	out := make([]int8, 4)
+	for lookback, want := range map[int]int8{0: 0, 1: 63, LOOKBACKALL: 63} {
+		d.MplexLookback(lookback)
+		if n, err := d.GetData("mplex", 9, 0, 0, 4, &out); err != nil || n != 4 || out[0] != want {
+			t.Errorf("Lookback %d: mplex = %v, %d (%v), want first %d", lookback, out, n, err, want)
+		}
+	}
+}
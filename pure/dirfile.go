@@ -0,0 +1,289 @@
+// Package pure reads dirfiles in pure Go, without the GetData C library, so
+// that programs using it can be built without cgo. It serves RAW fields stored
+// unencoded or gzip-encoded, honouring each fragment's endianness and frame
+// offset, and evaluates derived fields from them. The format files are parsed
+// by package format.
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/joefowler/gogetdata/format"
+)
+
+// Errors returned (wrapped, with the field code) by Dirfile methods
+var (
+	ErrBadCode      = errors.New("Field not found")
+	ErrBadFieldType = errors.New("Operation not supported for this field type")
+	ErrUnsupported  = errors.New("Encoding not supported")
+	ErrBadType      = errors.New("Bad output data type")
+	ErrRange        = errors.New("Request out of range")
+	ErrBadScalar    = errors.New("Bad scalar parameter")
+	ErrLUT          = errors.New("Bad LINTERP table")
+	ErrRecurse      = errors.New("Too many levels of recursion")
+)
+
+// LOOKBACKALL makes MPLEX fields search backwards all the way to the start of
+// the data for their initial value
+const LOOKBACKALL int = -1
+
+// DefaultLookback is the number of MPLEX cycles searched backwards by default
+const DefaultLookback = 10
+
+// maxDepth limits the nesting of derived fields
+const maxDepth = 64
+
+// indexEntry is the implicit INDEX field
+var indexEntry = &format.Entry{Name: "INDEX", Type: format.INDEXENTRY}
+
+// Dirfile is a read-only dirfile accessed without the GetData C library
+type Dirfile struct {
+	fsys     fs.FS
+	root     string
+	format   *format.Format
+	lookback int
+	tables   map[string]*lut
+	gzSizes  map[string]gzSize
+}
+
+// Open opens the dirfile in directory name for reading
+func Open(name string) (*Dirfile, error) {
+	return OpenFS(os.DirFS(name), ".")
+}
+
+// OpenFS opens the dirfile at path dir within fsys for reading
+func OpenFS(fsys fs.FS, dir string) (*Dirfile, error) {
+	f, err := format.Parse(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Dirfile{fsys: fsys, root: dir, format: f, lookback: DefaultLookback,
+		tables: make(map[string]*lut), gzSizes: make(map[string]gzSize)}, nil
+}
+
+// Format returns the parsed format metadata of the dirfile
+func (d *Dirfile) Format() *format.Format {
+	return d.format
+}
+
+// Close releases the dirfile. It exists for symmetry with getdata.Dirfile;
+// no files are held open between calls.
+func (d *Dirfile) Close() error {
+	return nil
+}
+
+// MplexLookback changes how many MPLEX cycles are searched backwards for the
+// initial value of a MPLEX field (LOOKBACKALL to search to the start of the data)
+func (d *Dirfile) MplexLookback(lookback int) {
+	d.lookback = lookback
+}
+
+// Entry returns the entry for the given field code, following aliases. The
+// implicit INDEX field has an entry of type INDEXENTRY.
+func (d *Dirfile) Entry(fieldcode string) (*format.Entry, error) {
+	if fieldcode == "INDEX" {
+		return indexEntry, nil
+	}
+	e := d.format.Entry(fieldcode)
+	if e == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadCode, fieldcode)
+	}
+	return e, nil
+}
+
+// vectorEntry returns the entry for the given field code, which must be a vector field
+func (d *Dirfile) vectorEntry(fieldcode string, depth int) (*format.Entry, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: %s", ErrRecurse, fieldcode)
+	}
+	e, err := d.Entry(fieldcode)
+	if err != nil {
+		return nil, err
+	}
+	if e.Type.IsScalar() {
+		return nil, fmt.Errorf("%w: %s is %v", ErrBadFieldType, fieldcode, e.Type)
+	}
+	return e, nil
+}
+
+// ReferenceField returns the field code of the reference field: the one named by
+// /REFERENCE, or else the first RAW field. It returns "" if there are no RAW fields.
+func (d *Dirfile) ReferenceField() string {
+	if d.format.Reference != "" {
+		return d.format.Reference
+	}
+	for _, e := range d.format.Entries {
+		if e.Type == format.RAWENTRY {
+			return e.Name
+		}
+	}
+	return ""
+}
+
+// NFrames returns the number of frames in the dirfile, which is the length of
+// the reference field
+func (d *Dirfile) NFrames() (int, error) {
+	ref := d.ReferenceField()
+	if ref == "" {
+		return 0, nil
+	}
+	spf, err := d.SPF(ref)
+	if err != nil {
+		return 0, err
+	}
+	eof, err := d.EoF(ref)
+	if err != nil {
+		return 0, err
+	}
+	return eof / spf, nil
+}
+
+// SPF returns the number of samples per frame of a field
+func (d *Dirfile) SPF(fieldcode string) (int, error) {
+	e, err := d.vectorEntry(fieldcode, 0)
+	if err != nil {
+		return 0, err
+	}
+	return d.spf(e, 0)
+}
+
+// spf returns the samples per frame of a vector field. Derived fields take
+// the rate of their first input.
+func (d *Dirfile) spf(e *format.Entry, depth int) (int, error) {
+	switch e.Type {
+	case format.INDEXENTRY:
+		return 1, nil
+	case format.RAWENTRY:
+		spf, err := d.intParam(e.SPF)
+		if err != nil {
+			return 0, fmt.Errorf("SPF of %s: %w", e.Name, err)
+		}
+		if spf <= 0 {
+			return 0, fmt.Errorf("%w: SPF of %s is %d", ErrRange, e.Name, spf)
+		}
+		return int(spf), nil
+	}
+	in, err := d.vectorEntry(e.InFields[0], depth+1)
+	if err != nil {
+		return 0, err
+	}
+	return d.spf(in, depth+1)
+}
+
+// NativeType returns the native data type of a field
+func (d *Dirfile) NativeType(fieldcode string) (format.RetType, error) {
+	e, err := d.vectorEntry(fieldcode, 0)
+	if err != nil {
+		return format.UNKNOWN, err
+	}
+	return d.nativeType(e, 0)
+}
+
+// EoF returns the end-of-field position of a field, in samples, including the
+// fragment's frame offset
+func (d *Dirfile) EoF(fieldcode string) (int, error) {
+	e, err := d.vectorEntry(fieldcode, 0)
+	if err != nil {
+		return 0, err
+	}
+	return d.eof(e, 0)
+}
+
+// BoF returns the beginning-of-field position of a field, in samples: the
+// fragment's frame offset, for a RAW field
+func (d *Dirfile) BoF(fieldcode string) (int, error) {
+	e, err := d.vectorEntry(fieldcode, 0)
+	if err != nil {
+		return 0, err
+	}
+	return d.bof(e, 0)
+}
+
+// Framenum returns the (fractional) frame at which a field, which must be
+// monotonic, reaches value. As gd_framenum does, it interpolates linearly
+// between the samples either side of value, or extrapolates from the first or
+// last two samples.
+func (d *Dirfile) Framenum(fieldcode string, value float64) (float64, error) {
+	spf, err := d.SPF(fieldcode)
+	if err != nil {
+		return 0, err
+	}
+	lo, err := d.BoF(fieldcode)
+	if err != nil {
+		return 0, err
+	}
+	hi, err := d.EoF(fieldcode)
+	if err != nil {
+		return 0, err
+	}
+	if hi--; hi <= lo {
+		return 0, fmt.Errorf("%w: %s has fewer than two samples", ErrRange, fieldcode)
+	}
+	buf := make([]float64, 1)
+	sample := func(i int) (float64, error) {
+		_, err := d.GetData(fieldcode, 0, i, 0, 1, &buf)
+		return buf[0], err
+	}
+	vlo, err := sample(lo)
+	if err != nil {
+		return 0, err
+	}
+	vhi, err := sample(hi)
+	if err != nil {
+		return 0, err
+	}
+	increasing := vhi > vlo
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		v, err := sample(mid)
+		if err != nil {
+			return 0, err
+		}
+		if (v <= value) == increasing {
+			lo, vlo = mid, v
+		} else {
+			hi, vhi = mid, v
+		}
+	}
+	return (float64(lo) + (value-vlo)/(vhi-vlo)) / float64(spf), nil
+}
+
+// Scalar returns the value of a parameter, which may be given by a CONST or
+// CARRAY field, as it is used in computing a derived field
+func (d *Dirfile) Scalar(p format.Param) (complex128, error) {
+	v, _, err := d.scalar(p)
+	return v, err
+}
+
+// GetData fetches data from a field, like getdata.Dirfile.GetData. out should
+// be a *pointer to* a slice of numeric data (or of strings, for a SINDIR field)
+// holding at least numFrames*SPF + numSamples values. Samples before the frame
+// offset are read as zero (or NaN, for floating-point fields). Returns (n, err)
+// where n is the number of samples read, which is short if the field ends first.
+func (d *Dirfile) GetData(fieldcode string, firstFrame, firstSample, numFrames, numSamples int, out interface{}) (int, error) {
+	e, err := d.vectorEntry(fieldcode, 0)
+	if err != nil {
+		return 0, err
+	}
+	spf, err := d.spf(e, 0)
+	if err != nil {
+		return 0, err
+	}
+	start := firstFrame*spf + firstSample
+	n := numFrames*spf + numSamples
+	if start < 0 || n <= 0 {
+		return 0, fmt.Errorf("%w: %s from sample %d for %d samples", ErrRange, fieldcode, start, n)
+	}
+	dst, err := outSlice(out, n)
+	if err != nil {
+		return 0, fmt.Errorf("GetData %s: %w", fieldcode, err)
+	}
+	samples, err := d.read(e, start, n, 0)
+	if err != nil {
+		return 0, err
+	}
+	return convert(dst, samples)
+}
@@ -0,0 +1,184 @@
+package pure
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+// createPureDirfile writes a dirfile exercising endianness, frame offsets and
+// gzip encoding, and returns its path
+func createPureDirfile(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name string, data []byte) {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("format", []byte(`/ENDIAN little
+data RAW INT8 8
+/INCLUDE sub/form2
+/INCLUDE form3
+/REFERENCE data
+`))
+	write("data", testdirfile.Data())
+
+	write("sub/form2", []byte(`/ENDIAN big
+/FRAMEOFFSET 2
+/ENCODING gzip
+be RAW INT16 2
+`))
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	for i := int16(100); i < 110; i++ {
+		binary.Write(zw, binary.BigEndian, i)
+	}
+	zw.Close()
+	write("sub/be.gz", gz.Bytes())
+
+	write("form3", []byte(`/ENDIAN little arm
+dbl RAW FLOAT64 1
+spfc CONST UINT16 3
+three RAW UINT32 spfc
+`))
+	var dbl []byte
+	for _, v := range []float64{1.5, -2.25} {
+		le := binary.LittleEndian.AppendUint64(nil, math.Float64bits(v))
+		dbl = append(dbl, le[4:]...)
+		dbl = append(dbl, le[:4]...)
+	}
+	write("dbl", dbl)
+	var three []byte
+	for i := uint32(0); i < 7; i++ {
+		three = binary.LittleEndian.AppendUint32(three, 1000*i)
+	}
+	write("three", three)
+	return dir
+}
+
+func TestRawRead(t *testing.T) {
+	d, err := Open(createPureDirfile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if nf, err := d.NFrames(); err != nil || nf != 10 {
+		t.Errorf("NFrames = %d (%v), want 10", nf, err)
+	}
+	if spf, err := d.SPF("three"); err != nil || spf != 3 {
+		t.Errorf("SPF(three) = %d (%v), want 3", spf, err)
+	}
+	if nt, err := d.NativeType("be"); err != nil || nt.String() != "INT16" {
+		t.Errorf("NativeType(be) = %v (%v), want INT16", nt, err)
+	}
+	for field, want := range map[string]int{"data": 80, "be": 14, "dbl": 2, "three": 7} {
+		if eof, err := d.EoF(field); err != nil || eof != want {
+			t.Errorf("EoF(%s) = %d (%v), want %d", field, eof, err, want)
+		}
+	}
+
+	f64 := make([]float64, 16)
+	n, err := d.GetData("data", 9, 0, 2, 0, &f64)
+	if err != nil || n != 8 {
+		t.Fatalf("GetData(data) = %d (%v), want 8", n, err)
+	}
+	for i := 0; i < n; i++ {
+		if f64[i] != float64(73+i) {
+			t.Errorf("data[%d] = %v, want %d", i, f64[i], 73+i)
+		}
+	}
+
+	i32 := make([]int32, 4)
+	n, err = d.GetData("be", 1, 0, 2, 0, &i32)
+	if err != nil || n != 4 {
+		t.Fatalf("GetData(be) = %d (%v), want 4", n, err)
+	}
+	if want := []int32{0, 0, 100, 101}; !slices.Equal(i32, want) {
+		t.Errorf("GetData(be) = %v, want %v", i32, want)
+	}
+
+	c128 := make([]complex128, 2)
+	if n, err = d.GetData("dbl", 0, 0, 0, 2, &c128); err != nil || n != 2 ||
+		c128[0] != 1.5 || c128[1] != -2.25 {
+		t.Errorf("GetData(dbl) = %v, %d (%v)", c128, n, err)
+	}
+
+	u16 := make([]uint16, 6)
+	if n, err = d.GetData("three", 1, 1, 1, 0, &u16); err != nil || n != 3 ||
+		!slices.Equal(u16[:3], []uint16{4000, 5000, 6000}) {
+		t.Errorf("GetData(three) = %v, %d (%v)", u16, n, err)
+	}
+}
+
+func TestGzipSize(t *testing.T) {
+	dir := createPureDirfile(t)
+	d, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+	for range 2 {
+		if eof, err := d.EoF("be"); err != nil || eof != 14 {
+			t.Errorf("EoF(be) = %d (%v), want 14", eof, err)
+		}
+	}
+	if len(d.gzSizes) != 1 {
+		t.Errorf("%d gzip sizes cached, want 1", len(d.gzSizes))
+	}
+
+	// a changed file is decompressed again
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	binary.Write(zw, binary.BigEndian, make([]int16, 12))
+	zw.Close()
+	name := filepath.Join(dir, "sub", "be.gz")
+	if err := os.WriteFile(name, gz.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if eof, err := d.EoF("be"); err != nil || eof != 16 {
+		t.Errorf("EoF(be) after it grew = %d (%v), want 16", eof, err)
+	}
+}
+
+func TestRawErrors(t *testing.T) {
+	d, err := Open(createPureDirfile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	out := make([]float64, 8)
+	if _, err := d.GetData("xyz", 0, 0, 1, 0, &out); !errors.Is(err, ErrBadCode) {
+		t.Errorf("GetData(xyz) error %v, want ErrBadCode", err)
+	}
+	if _, err := d.GetData("spfc", 0, 0, 1, 0, &out); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("GetData(spfc) error %v, want ErrBadFieldType", err)
+	}
+	if _, err := d.GetData("data", 0, 0, 2, 0, &out); !errors.Is(err, ErrRange) {
+		t.Errorf("GetData into short slice error %v, want ErrRange", err)
+	}
+	if _, err := d.GetData("data", 0, 0, 1, 0, out); !errors.Is(err, ErrBadType) {
+		t.Errorf("GetData into non-pointer error %v, want ErrBadType", err)
+	}
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Errorf("Open succeeded without a format file")
+	}
+}
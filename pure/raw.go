@@ -0,0 +1,177 @@
+package pure
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/joefowler/gogetdata/format"
+)
+
+// rawFile is an open RAW data file, decompressed if necessary
+type rawFile struct {
+	io.Reader
+	file fs.File
+	name string
+}
+
+// gzSize is the decompressed size of a gzipped RAW file, cached until the
+// compressed file changes
+type gzSize struct {
+	size    int64 // of the compressed file
+	modTime time.Time
+	decoded int64
+}
+
+func (rf *rawFile) Close() error {
+	return rf.file.Close()
+}
+
+// skip discards the first n bytes of the file
+func (rf *rawFile) skip(n int64) error {
+	if s, ok := rf.Reader.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, rf.Reader, n)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// openRaw opens the data file of a RAW field. It returns nil and no error if the
+// file does not exist, which is treated as an empty field.
+func (d *Dirfile) openRaw(e *format.Entry) (*rawFile, error) {
+	frag := d.format.Fragments[e.Fragment]
+	name := path.Join(d.root, path.Dir(frag.Filename), e.Name)
+	var candidates []format.Flags
+	switch frag.Encoding {
+	case format.AUTOENCODED:
+		candidates = []format.Flags{format.UNENCODED, format.GZIPENCODED}
+	case format.UNENCODED, format.GZIPENCODED:
+		candidates = []format.Flags{frag.Encoding}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, e.Name)
+	}
+
+	for _, enc := range candidates {
+		filename := name
+		if enc == format.GZIPENCODED {
+			filename += ".gz"
+		}
+		f, err := d.fsys.Open(filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if enc == format.UNENCODED {
+			return &rawFile{Reader: f, file: f, name: filename}, nil
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		return &rawFile{Reader: gz, file: f, name: filename}, nil
+	}
+	return nil, nil
+}
+
+// rawSize returns the number of bytes of (decoded) data in a RAW field's file.
+// A gzipped file is decompressed to find its size only if it has changed since
+// the last call.
+func (d *Dirfile) rawSize(e *format.Entry) (int64, error) {
+	rf, err := d.openRaw(e)
+	if err != nil || rf == nil {
+		return 0, err
+	}
+	defer rf.Close()
+	info, err := rf.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := rf.Reader.(fs.File); ok {
+		return info.Size(), nil
+	}
+	if c, ok := d.gzSizes[rf.name]; ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+		return c.decoded, nil
+	}
+	n, err := io.Copy(io.Discard, rf.Reader)
+	if err != nil {
+		return 0, err
+	}
+	d.gzSizes[rf.name] = gzSize{size: info.Size(), modTime: info.ModTime(), decoded: n}
+	return n, nil
+}
+
+// readRaw reads n samples of a RAW field starting at sample start, and returns
+// them as a slice of the field's native type. It is shorter than n if the field
+// ends first.
+func (d *Dirfile) readRaw(e *format.Entry, spf, start, n int) (interface{}, error) {
+	frag := d.format.Fragments[e.Fragment]
+	size := e.DataType.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("%w: %s has type %v", ErrBadType, e.Name, e.DataType)
+	}
+
+	// Samples before the frame offset are not stored
+	offset := int(frag.FrameOffset) * spf
+	pad := 0
+	if start < offset {
+		pad = min(offset-start, n)
+	}
+	buf := make([]byte, (n-pad)*size)
+	nread := 0
+	if len(buf) > 0 {
+		rf, err := d.openRaw(e)
+		if err != nil {
+			return nil, err
+		}
+		if rf != nil {
+			defer rf.Close()
+			if err := rf.skip(int64(max(start-offset, 0) * size)); err != nil {
+				return nil, err
+			}
+			nread, err = io.ReadFull(rf, buf)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, err
+			}
+		}
+	}
+	buf = buf[:nread-nread%size]
+
+	order := byteOrder(frag.Endianness)
+	if frag.Endianness&format.ARMENDIAN != 0 && (e.DataType == format.FLOAT64 || e.DataType == format.COMPLEX128) {
+		swapWords(buf)
+	}
+	return decode(buf, e.DataType, order, pad)
+}
+
+// byteOrder returns the byte order of data with the given endianness flags
+func byteOrder(endianness format.Flags) binary.ByteOrder {
+	switch {
+	case endianness&format.BIGENDIAN != 0:
+		return binary.BigEndian
+	case endianness&format.LITTLEENDIAN != 0:
+		return binary.LittleEndian
+	}
+	return binary.NativeEndian
+}
+
+// swapWords exchanges the 4-byte halves of each 8-byte value, converting the
+// middle-endian doubles of old ARM processors to ordinary little-endian
+func swapWords(buf []byte) {
+	for i := 0; i+8 <= len(buf); i += 8 {
+		var w [4]byte
+		copy(w[:], buf[i:i+4])
+		copy(buf[i:i+4], buf[i+4:i+8])
+		copy(buf[i+4:i+8], w[:])
+	}
+}
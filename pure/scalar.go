@@ -0,0 +1,116 @@
+package pure
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/joefowler/gogetdata/format"
+)
+
+// scalarEntry returns the entry for the given field code, which must be of type t
+func (d *Dirfile) scalarEntry(fieldcode string, t format.EntryType) (*format.Entry, error) {
+	e, err := d.Entry(fieldcode)
+	if err != nil {
+		return nil, err
+	}
+	if e.Type != t {
+		return nil, fmt.Errorf("%w: %s is %v, not %v", ErrBadFieldType, fieldcode, e.Type, t)
+	}
+	return e, nil
+}
+
+// constValues returns the values of a CONST or CARRAY field, rounded to its storage type
+func (d *Dirfile) constValues(fieldcode string, t format.EntryType) (interface{}, error) {
+	e, err := d.scalarEntry(fieldcode, t)
+	if err != nil {
+		return nil, err
+	}
+	src, err := parseValues(e.Values, e.DataType)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fieldcode, err)
+	}
+	values := makeSlice(e.DataType, len(e.Values))
+	convert(values, src)
+	return values, nil
+}
+
+// GetConstant stores the value of a CONST field (including metafields) in the
+// numeric variable pointed to by out
+func (d *Dirfile) GetConstant(fieldcode string, out interface{}) error {
+	p := reflect.ValueOf(out)
+	if p.Kind() != reflect.Pointer || p.IsNil() {
+		return fmt.Errorf("%w: out was not a pointer to a numeric value", ErrBadType)
+	}
+	dst := reflect.MakeSlice(reflect.SliceOf(p.Type().Elem()), 1, 1)
+	if typeOf(dst.Interface()) == format.UNKNOWN || typeOf(dst.Interface()) == format.STRING {
+		return fmt.Errorf("%w: out was not a pointer to a numeric value", ErrBadType)
+	}
+	values, err := d.constValues(fieldcode, format.CONSTENTRY)
+	if err != nil {
+		return err
+	}
+	if _, err := convert(dst.Interface(), values); err != nil {
+		return err
+	}
+	p.Elem().Set(dst.Index(0))
+	return nil
+}
+
+// GetCarray fills the numeric slice pointed to by out, which must hold at least
+// as many values as the CARRAY field (including metafields) has, with its values
+func (d *Dirfile) GetCarray(fieldcode string, out interface{}) error {
+	values, err := d.constValues(fieldcode, format.CARRAYENTRY)
+	if err != nil {
+		return err
+	}
+	dst, err := outSlice(out, length(values))
+	if err != nil {
+		return fmt.Errorf("GetCarray %s: %w", fieldcode, err)
+	}
+	_, err = convert(dst, values)
+	return err
+}
+
+// GetString returns the value of a STRING field (including metafields)
+func (d *Dirfile) GetString(fieldcode string) (string, error) {
+	e, err := d.scalarEntry(fieldcode, format.STRINGENTRY)
+	if err != nil {
+		return "", err
+	}
+	return e.Values[0], nil
+}
+
+// GetSarray returns the values of an SARRAY field (including metafields)
+func (d *Dirfile) GetSarray(fieldcode string) ([]string, error) {
+	e, err := d.scalarEntry(fieldcode, format.SARRAYENTRY)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(e.Values), nil
+}
+
+// FieldList returns the field codes of all fields (no metafields) that are not
+// hidden, starting with INDEX, in order of definition
+func (d *Dirfile) FieldList() []string {
+	result := []string{"INDEX"}
+	for _, e := range d.format.Entries {
+		if !e.Hidden && e.Parent() == "" {
+			result = append(result, e.Name)
+		}
+	}
+	return result
+}
+
+// VectorList returns the field codes of all vector fields (no metafields) that
+// are not hidden, starting with INDEX, in order of definition. Aliases are
+// listed if their target is a vector field.
+func (d *Dirfile) VectorList() []string {
+	var result []string
+	for _, code := range d.FieldList() {
+		if e, err := d.Entry(code); err == nil && !e.Type.IsScalar() {
+			result = append(result, code)
+		}
+	}
+	return result
+}
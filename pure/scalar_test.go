@@ -0,0 +1,56 @@
+package pure
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestScalars(t *testing.T) {
+	d, err := Open(createTestDirfile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	var f float64
+	if err := d.GetConstant("const", &f); err != nil || f != 5.5 {
+		t.Errorf("GetConstant(const) = %v (%v), want 5.5", f, err)
+	}
+	var i int32
+	if err := d.GetConstant("const", &i); err != nil || i != 5 {
+		t.Errorf("GetConstant(const) as int32 = %v (%v), want 5", i, err)
+	}
+	var c complex64
+	if err := d.GetConstant("data/mconst", &c); err != nil || c != complex(3.3, 4.4) {
+		t.Errorf("GetConstant(data/mconst) = %v (%v), want (3.3+4.4i)", c, err)
+	}
+	if err := d.GetConstant("carray", &f); !errors.Is(err, ErrBadFieldType) {
+		t.Errorf("GetConstant(carray) = %v, want ErrBadFieldType", err)
+	}
+
+	carray := make([]float64, 6)
+	if err := d.GetCarray("carray", &carray); err != nil ||
+		!slices.Equal(carray, []float64{1.1, 2.2, 3.3, 4.4, 5.5, 6.6}) {
+		t.Errorf("GetCarray(carray) = %v (%v)", carray, err)
+	}
+	short := make([]float64, 5)
+	if err := d.GetCarray("carray", &short); !errors.Is(err, ErrRange) {
+		t.Errorf("GetCarray into short slice = %v, want ErrRange", err)
+	}
+
+	if s, err := d.GetString("data/mstr"); err != nil || s != "This is a string constant." {
+		t.Errorf("GetString(data/mstr) = %q (%v)", s, err)
+	}
+	if s, err := d.GetSarray("sarray"); err != nil ||
+		!slices.Equal(s, []string{"one", "two", "three", "four", "five", "six", "seven"}) {
+		t.Errorf("GetSarray(sarray) = %q (%v)", s, err)
+	}
+
+	fields := d.FieldList()
+	if len(fields) != 20 || fields[0] != "INDEX" || fields[1] != "data" || slices.Contains(fields, "data/mstr") {
+		t.Errorf("FieldList = %v", fields)
+	}
+	vectors := d.VectorList()
+	if len(vectors) != 16 || !slices.Contains(vectors, "alias") || slices.Contains(vectors, "const") {
+		t.Errorf("VectorList = %v", vectors)
+	}
+}
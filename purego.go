@@ -0,0 +1,84 @@
+//go:build purego
+
+package getdata
+
+// Building with the purego tag replaces the cgo binding with a read-only Dirfile
+// backed by package pure, so that programs need neither cgo nor libgetdata. It
+// supports only the methods of FSDirfile, and only RAW fields stored unencoded
+// or gzip-encoded, along with the derived fields computed from them.
+
+import "os"
+
+// RetType enumerates the data vector return types available
+type RetType uint
+
+// The data types, with the same values as in getdata.h
+const (
+	NULLTYPE   RetType = 0x000
+	UINT8      RetType = 0x001
+	INT8       RetType = 0x041
+	UINT16     RetType = 0x002
+	INT16      RetType = 0x042
+	UINT32     RetType = 0x004
+	INT32      RetType = 0x044
+	UINT64     RetType = 0x008
+	INT64      RetType = 0x048
+	FLOAT32    RetType = 0x084
+	FLOAT64    RetType = 0x088
+	COMPLEX64  RetType = 0x108
+	COMPLEX128 RetType = 0x110
+	STRING     RetType = 0x200
+	UNKNOWN    RetType = 0x400
+)
+
+// The error codes an FSDirfile reports, named as in the C API. Only their names
+// matter without libgetdata, so the values need not match getdata.h.
+const (
+	ErrFormat       ErrorCode = -1
+	ErrBadCode      ErrorCode = -3
+	ErrBadType      ErrorCode = -4
+	ErrIO           ErrorCode = -5
+	ErrRange        ErrorCode = -8
+	ErrLUT          ErrorCode = -9
+	ErrRecurseLevel ErrorCode = -10
+	ErrBadFieldType ErrorCode = -12
+	ErrUnsupported  ErrorCode = -14
+	ErrBadScalar    ErrorCode = -20
+	ErrArgument     ErrorCode = -24
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrFormat:       "GD_E_FORMAT",
+	ErrBadCode:      "GD_E_BAD_CODE",
+	ErrBadType:      "GD_E_BAD_TYPE",
+	ErrIO:           "GD_E_IO",
+	ErrRange:        "GD_E_RANGE",
+	ErrLUT:          "GD_E_LUT",
+	ErrRecurseLevel: "GD_E_RECURSE_LEVEL",
+	ErrBadFieldType: "GD_E_BAD_FIELD_TYPE",
+	ErrUnsupported:  "GD_E_UNSUPPORTED",
+	ErrBadScalar:    "GD_E_BAD_SCALAR",
+	ErrArgument:     "GD_E_ARGUMENT",
+}
+
+// Flags are dirfile-opening flags
+type Flags int64
+
+// RDONLY open read-only, the only mode supported without libgetdata
+const RDONLY Flags = 0
+
+// Dirfile is a read-only dirfile read in pure Go; see FSDirfile for its methods
+type Dirfile = FSDirfile
+
+// OpenDirfile returns an open Dirfile object. flags must be RDONLY.
+func OpenDirfile(name string, flags Flags) (Dirfile, error) {
+	if flags != RDONLY {
+		return Dirfile{}, newError(ErrArgument, "OpenDirfile", "", "OpenDirfile flags 0x%x not supported without libgetdata", int64(flags))
+	}
+	df, err := OpenDirfileFS(os.DirFS(name), ".")
+	if err != nil {
+		return Dirfile{}, err
+	}
+	df.name = name
+	return df, nil
+}
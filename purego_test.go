@@ -0,0 +1,52 @@
+//go:build purego
+
+package getdata
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joefowler/gogetdata/internal/testdirfile"
+)
+
+func TestPureGo(t *testing.T) {
+	dir := t.TempDir()
+	if err := testdirfile.Write(dir, testdirfile.Format); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenDirfile(dir, Flags(1)); !errors.Is(err, ErrArgument) {
+		t.Errorf("OpenDirfile of a read-write open returned %v, want ErrArgument", err)
+	}
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatalf("OpenDirfile failed: %v", err)
+	}
+	defer d.Close()
+
+	if nf := d.NFrames(); nf != 10 {
+		t.Errorf("NFrames = %d, want 10", nf)
+	}
+	if spf := d.SPF("data"); spf != 8 {
+		t.Errorf("SPF = %d, want 8", spf)
+	}
+	if eof := d.EoF("data"); eof != 80 {
+		t.Errorf("EoF = %d, want 80", eof)
+	}
+	if nt := d.NativeType("data"); nt != INT8 {
+		t.Errorf("NativeType = 0x%x, want INT8", nt)
+	}
+	if nt := d.NativeType("const"); nt != UNKNOWN || d.Error() == nil {
+		t.Errorf("NativeType(const) = 0x%x with error %v, want UNKNOWN and an error", nt, d.Error())
+	}
+
+	out := make([]uint16, 8)
+	n, err := d.GetData("data", 5, 0, 1, 0, &out)
+	if err != nil || n != 8 {
+		t.Fatalf("GetData = %d (%v), want 8", n, err)
+	}
+	if got := fmt.Sprint(out); got != "[41 42 43 44 45 46 47 48]" {
+		t.Errorf("GetData read %s", got)
+	}
+}
@@ -0,0 +1,140 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// FieldReader reads a vector field sequentially, in blocks of a fixed number of
+// samples, from its beginning (BoF) to its end (EoF, as found when the reader was
+// created), in any ReadOnly dirfile. Use it either as a scanner:
+//
+//	r, err := NewFieldReader[float64](&df, "field", 4096)
+//	for r.Next() {
+//		process(r.Block())
+//	}
+//	if err := r.Err(); err != nil {...}
+//
+// or with a range-over-func loop over r.Blocks(), checking r.Err() afterwards.
+type FieldReader[T Numeric] struct {
+	df        ReadOnly
+	fieldcode string
+	blockSize int
+	pos       int // sample number of the start of the next block
+	end       int
+	start     int // sample number of the start of the current block
+	block     []T
+	err       error
+}
+
+// NewFieldReader returns a FieldReader yielding blocks of up to blockSize samples
+// of fieldcode, converted to type T.
+func NewFieldReader[T Numeric](df ReadOnly, fieldcode string, blockSize int) (*FieldReader[T], error) {
+	if blockSize <= 0 {
+		return nil, errZeroLength("NewFieldReader", fieldcode)
+	}
+	end := df.EoF(fieldcode)
+	if end < 0 {
+		return nil, readError(df, "NewFieldReader", fieldcode)
+	}
+	pos := df.BoF(fieldcode)
+	if pos < 0 {
+		return nil, readError(df, "NewFieldReader", fieldcode)
+	}
+	return &FieldReader[T]{
+		df:        df,
+		fieldcode: fieldcode,
+		blockSize: blockSize,
+		pos:       pos,
+		end:       end,
+		block:     make([]T, blockSize),
+	}, nil
+}
+
+// Next reads the next block of samples, returning false at the end of the field
+// or on error (see Err).
+func (r *FieldReader[T]) Next() bool {
+	if r.err != nil || r.pos >= r.end {
+		return false
+	}
+	n := r.blockSize
+	if r.end-r.pos < n {
+		n = r.end - r.pos
+	}
+	r.block = r.block[:n]
+	nread, err := r.df.GetData(r.fieldcode, 0, r.pos, 0, n, &r.block)
+	if err != nil {
+		r.err = err
+		return false
+	}
+	r.block = r.block[:nread]
+	r.start = r.pos
+	r.pos += nread
+	if nread < n {
+		r.err = fmt.Errorf("FieldReader read %d samples of %s at sample %d, expected %d",
+			nread, r.fieldcode, r.start, n)
+	}
+	return nread > 0
+}
+
+// Block returns the samples read by the latest call to Next. The slice is
+// reused by the following call to Next.
+func (r *FieldReader[T]) Block() []T {
+	return r.block
+}
+
+// Sample returns the sample number of the first sample in Block
+func (r *FieldReader[T]) Sample() int {
+	return r.start
+}
+
+// Err returns the first error encountered while reading, if any
+func (r *FieldReader[T]) Err() error {
+	return r.err
+}
+
+// Blocks returns an iterator over the remaining blocks of the field. As with
+// Block, each slice is only valid until the next iteration.
+func (r *FieldReader[T]) Blocks() iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for r.Next() {
+			if !yield(r.block) {
+				return
+			}
+		}
+	}
+}
+
+// Reader returns an io.Reader of the remaining samples, encoded little-endian
+// in the binary form of type T.
+func (r *FieldReader[T]) Reader() io.Reader {
+	return &fieldByteReader[T]{r: r}
+}
+
+type fieldByteReader[T Numeric] struct {
+	r   *FieldReader[T]
+	buf []byte
+}
+
+func (br *fieldByteReader[T]) Read(p []byte) (int, error) {
+	for len(br.buf) == 0 {
+		if !br.r.Next() {
+			if br.r.err != nil {
+				return 0, br.r.err
+			}
+			return 0, io.EOF
+		}
+		var err error
+		br.buf, err = binary.Append(br.buf[:0], binary.LittleEndian, br.r.block)
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, br.buf)
+	br.buf = br.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,79 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"io"
+	"testing"
+)
+
+func TestFieldReader(t *testing.T) {
+	dir := "dirfile_reader"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-only:", err)
+	}
+	defer d.Close()
+
+	r, err := NewFieldReader[int32](&d, "data", 30)
+	if err != nil {
+		t.Fatal("NewFieldReader failed:", err)
+	}
+	expectedLen := []int{30, 30, 20}
+	nblocks := 0
+	for r.Next() {
+		block := r.Block()
+		if nblocks >= len(expectedLen) {
+			t.Fatalf("FieldReader returned too many blocks")
+		}
+		if len(block) != expectedLen[nblocks] {
+			t.Errorf("FieldReader block %d has length %d, want %d", nblocks, len(block), expectedLen[nblocks])
+		}
+		if r.Sample() != 30*nblocks {
+			t.Errorf("FieldReader block %d starts at sample %d, want %d", nblocks, r.Sample(), 30*nblocks)
+		}
+		if block[0] != int32(30*nblocks+1) {
+			t.Errorf("FieldReader block %d starts with %d, want %d", nblocks, block[0], 30*nblocks+1)
+		}
+		nblocks++
+	}
+	if r.Err() != nil {
+		t.Error("FieldReader failed:", r.Err())
+	}
+	if nblocks != len(expectedLen) {
+		t.Errorf("FieldReader returned %d blocks, want %d", nblocks, len(expectedLen))
+	}
+
+	r, _ = NewFieldReader[int32](&d, "data", 16)
+	total := 0
+	for block := range r.Blocks() {
+		total += len(block)
+		if total >= 32 {
+			break
+		}
+	}
+	if total != 32 {
+		t.Errorf("FieldReader.Blocks() loop read %d samples before break, want 32", total)
+	}
+
+	r16, _ := NewFieldReader[uint16](&d, "data", 7)
+	raw, err := io.ReadAll(r16.Reader())
+	if err != nil {
+		t.Error("FieldReader.Reader() failed:", err)
+	}
+	if len(raw) != 160 {
+		t.Errorf("FieldReader.Reader() returned %d bytes, want 160", len(raw))
+	} else if raw[2] != 2 || raw[3] != 0 || raw[158] != 80 {
+		t.Errorf("FieldReader.Reader() returned bytes %v..., want little-endian uint16", raw[:4])
+	}
+
+	if _, err := NewFieldReader[float64](&d, "data", 0); err == nil {
+		t.Error("NewFieldReader with zero block size did not return error")
+	}
+	if _, err := NewFieldReader[float64](&d, "xyz", 10); err == nil {
+		t.Error("NewFieldReader on non-existent field did not return error")
+	}
+}
@@ -0,0 +1,165 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimeIndex addresses the data in a dirfile by time rather than by frame, using
+// a time field: a vector field (e.g. a RAW ctime, or a LINCOM of one) holding
+// UTC times in seconds since the Unix epoch, increasing throughout. Reverse
+// look-up uses Framenum (gd_framenum, for a Dirfile), which interpolates
+// linearly between samples.
+type TimeIndex struct {
+	df         ReadOnly
+	timeField  string
+	start, end float64 // the first and last times in the time field
+}
+
+// timeBlockSize is the number of samples read at a time when checking a time field
+const timeBlockSize = 1 << 16
+
+// NewTimeIndex returns a TimeIndex using timeField, which it reads in full to
+// check that its values increase monotonically. It returns an error with code
+// ErrDomain if they do not.
+func NewTimeIndex(df ReadOnly, timeField string) (*TimeIndex, error) {
+	r, err := NewFieldReader[float64](df, timeField, timeBlockSize)
+	if err != nil {
+		return nil, err
+	}
+	ti := &TimeIndex{df: df, timeField: timeField, start: math.NaN()}
+	prev := math.Inf(-1)
+	for block := range r.Blocks() {
+		for i, t := range block {
+			if !(t > prev) {
+				return nil, &Error{Code: ErrDomain, Op: "NewTimeIndex", Field: timeField,
+					Msg: fmt.Sprintf("Time field %s is not increasing at sample %d (%v after %v)",
+						timeField, r.Sample()+i, t, prev)}
+			}
+			prev = t
+		}
+		if math.IsNaN(ti.start) {
+			ti.start = block[0]
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if math.IsNaN(ti.start) {
+		return nil, &Error{Code: ErrRange, Op: "NewTimeIndex", Field: timeField,
+			Msg: fmt.Sprintf("Time field %s holds no data", timeField)}
+	}
+	ti.end = prev
+	return ti, nil
+}
+
+// seconds returns t in seconds since the Unix epoch
+func seconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())*1e-9
+}
+
+// timeOf returns seconds since the Unix epoch as a UTC time
+func timeOf(s float64) time.Time {
+	sec, frac := math.Modf(s)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// TimeField returns the field code of the time field
+func (ti *TimeIndex) TimeField() string {
+	return ti.timeField
+}
+
+// Span returns the first and last times in the time field
+func (ti *TimeIndex) Span() (first, last time.Time) {
+	return timeOf(ti.start), timeOf(ti.end)
+}
+
+// errOutOfSpan is the error for a time outside the span of the time field
+func (ti *TimeIndex) errOutOfSpan(op string, t time.Time) error {
+	first, last := ti.Span()
+	return &Error{Code: ErrRange, Op: op, Field: ti.timeField,
+		Msg: fmt.Sprintf("Time %v is outside the span %v to %v of time field %s", t, first, last, ti.timeField)}
+}
+
+// framenum returns the (fractional) frame at which the time field reaches the
+// value s
+func (ti *TimeIndex) framenum(op string, s float64) (float64, error) {
+	frame := ti.df.Framenum(ti.timeField, s)
+	if math.IsNaN(frame) {
+		return 0, readError(ti.df, op, ti.timeField)
+	}
+	return frame, nil
+}
+
+// FrameAt returns the (fractional) frame number at which the time field reaches
+// time t. It returns an error with code ErrRange if t is outside the span of the
+// time field.
+func (ti *TimeIndex) FrameAt(t time.Time) (float64, error) {
+	s := seconds(t)
+	if s < ti.start || s > ti.end {
+		return 0, ti.errOutOfSpan("FrameAt", t)
+	}
+	return ti.framenum("FrameAt", s)
+}
+
+// SampleRange returns the first sample number and number of samples of
+// fieldcode that lie at or after t0 and before t1. Frames are converted to
+// samples using the SPF of fieldcode, so fields of any SPF may be addressed.
+// The interval is limited to the span of the time field; it is an error with
+// code ErrRange if they do not overlap.
+func (ti *TimeIndex) SampleRange(fieldcode string, t0, t1 time.Time) (first, n int, err error) {
+	if !t0.Before(t1) {
+		return 0, 0, &Error{Code: ErrArgument, Op: "SampleRange", Field: fieldcode,
+			Msg: fmt.Sprintf("Time interval %v to %v is empty", t0, t1)}
+	}
+	s0, s1 := seconds(t0), seconds(t1)
+	if s1 <= ti.start || s0 > ti.end {
+		return 0, 0, ti.errOutOfSpan("SampleRange", t0)
+	}
+	spf := ti.df.SPF(fieldcode)
+	if spf == 0 {
+		return 0, 0, readError(ti.df, "SampleRange", fieldcode)
+	}
+	f0, err := ti.framenum("SampleRange", max(s0, ti.start))
+	if err != nil {
+		return 0, 0, err
+	}
+	f1 := math.Inf(1)
+	if s1 <= ti.end {
+		if f1, err = ti.framenum("SampleRange", s1); err != nil {
+			return 0, 0, err
+		}
+	}
+	first = int(math.Ceil(f0 * float64(spf)))
+	if math.IsInf(f1, 1) {
+		end := ti.df.EoF(fieldcode)
+		if end < 0 {
+			return 0, 0, readError(ti.df, "SampleRange", fieldcode)
+		}
+		return first, max(end-first, 0), nil
+	}
+	return first, max(int(math.Ceil(f1*float64(spf)))-first, 0), nil
+}
+
+// GetDataBetween fetches the samples of fieldcode that lie at or after t0 and
+// before t1 (see SampleRange). out should be a *pointer to* a slice of numeric
+// data of any length, which is resized as by Dirfile.GetDataAlloc. Returns
+// (first, n, err) where first is the sample number of the first sample read and
+// n is the number read.
+func (ti *TimeIndex) GetDataBetween(fieldcode string, t0, t1 time.Time, out interface{}) (first, n int, err error) {
+	first, n, err = ti.SampleRange(fieldcode, t0, t1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !presize(out, 0) {
+		return 0, 0, newError(ErrArgument, "GetDataBetween", fieldcode, "GetDataBetween out variable was not a pointer to numeric slice")
+	}
+	if n == 0 {
+		return first, 0, nil
+	}
+	n, err = ti.df.GetDataAlloc(fieldcode, 0, first, 0, n, out)
+	return first, n, err
+}
@@ -0,0 +1,75 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTimeIndex(t *testing.T) {
+	dir := "dirfile_timeindex"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDONLY)
+	if err != nil {
+		t.Fatal("Could not open dirfile:", err)
+	}
+	defer d.Close()
+
+	if _, err = NewTimeIndex(&d, "sbit"); !errors.Is(err, ErrDomain) {
+		t.Errorf("NewTimeIndex on a non-monotonic field returned %v, want ErrDomain", err)
+	}
+	// "data" holds 1, 2, ..., 80, so serves as a time field at 8 samples per second
+	ti, err := NewTimeIndex(&d, "data")
+	if err != nil {
+		t.Fatal("NewTimeIndex failed:", err)
+	}
+	if first, last := ti.Span(); !first.Equal(time.Unix(1, 0)) || !last.Equal(time.Unix(80, 0)) {
+		t.Errorf("Span = %v, %v, want 1 s and 80 s", first, last)
+	}
+
+	for sec, want := range map[float64]float64{1: 0, 9: 1, 13: 1.5, 80: 9.875} {
+		tm := time.Unix(0, int64(sec*1e9))
+		if f, err := ti.FrameAt(tm); err != nil || f != want {
+			t.Errorf("FrameAt(%v) = %v (%v), want %v", sec, f, err, want)
+		}
+	}
+	if _, err = ti.FrameAt(time.Unix(81, 0)); !errors.Is(err, ErrRange) {
+		t.Errorf("FrameAt beyond the end returned %v, want ErrRange", err)
+	}
+
+	var data []float64
+	first, n, err := ti.GetDataBetween("data", time.Unix(9, 0), time.Unix(13, 500000000), &data)
+	if err != nil || first != 8 || n != 5 || !slices.Equal(data, []float64{9, 10, 11, 12, 13}) {
+		t.Errorf("GetDataBetween(data) = %d, %d, %v (%v), want 8, 5, [9 10 11 12 13]", first, n, data, err)
+	}
+	var index []int
+	if _, _, err = ti.GetDataBetween("INDEX", time.Unix(9, 0), time.Unix(30, 0), &index); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataBetween into []int returned %v, want ErrArgument", err)
+	}
+	// INDEX has 1 sample per frame
+	var index64 []int64
+	first, n, err = ti.GetDataBetween("INDEX", time.Unix(9, 0), time.Unix(30, 0), &index64)
+	if err != nil || first != 1 || n != 3 || !slices.Equal(index64, []int64{1, 2, 3}) {
+		t.Errorf("GetDataBetween(INDEX) = %d, %d, %v (%v), want 1, 3, [1 2 3]", first, n, index64, err)
+	}
+	// the interval is clipped to the span of the time field
+	first, n, err = ti.GetDataBetween("data", time.Unix(-5, 0), time.Unix(3, 0), &data)
+	if err != nil || first != 0 || n != 2 {
+		t.Errorf("GetDataBetween before the start = %d, %d (%v), want 0, 2", first, n, err)
+	}
+	first, n, err = ti.GetDataBetween("data", time.Unix(78, 0), time.Unix(100, 0), &data)
+	if err != nil || first != 77 || n != 3 {
+		t.Errorf("GetDataBetween past the end = %d, %d (%v), want 77, 3", first, n, err)
+	}
+	if _, _, err = ti.GetDataBetween("data", time.Unix(90, 0), time.Unix(100, 0), &data); !errors.Is(err, ErrRange) {
+		t.Errorf("GetDataBetween outside the span returned %v, want ErrRange", err)
+	}
+	if _, _, err = ti.GetDataBetween("data", time.Unix(10, 0), time.Unix(10, 0), &data); !errors.Is(err, ErrArgument) {
+		t.Errorf("GetDataBetween of an empty interval returned %v, want ErrArgument", err)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !purego
+
 package getdata
 
 /*
@@ -77,38 +79,91 @@ func sizeof(t RetType) uint {
 	return 0
 }
 
+// firstElement returns an unsafe.Pointer to the first element of a slice, or nil
+// if the slice is empty.
+func firstElement[T any](s []T) unsafe.Pointer {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&s[0])
+}
+
+// resizeSlice sets *s to a slice of length n, reusing its underlying array
+// if the capacity is sufficient.
+func resizeSlice[T any](s *[]T, n int) {
+	if cap(*s) < n {
+		*s = make([]T, n)
+	} else {
+		*s = (*s)[:n]
+	}
+}
+
+// presize accepts a pointer to a slice of numeric values and resizes the slice
+// to length n (see resizeSlice). It returns false if a is not such a pointer.
+func presize(a interface{}, n int) bool {
+	switch v := a.(type) {
+	case *[]uint8:
+		resizeSlice(v, n)
+	case *[]int8:
+		resizeSlice(v, n)
+	case *[]uint16:
+		resizeSlice(v, n)
+	case *[]int16:
+		resizeSlice(v, n)
+	case *[]uint32:
+		resizeSlice(v, n)
+	case *[]int32:
+		resizeSlice(v, n)
+	case *[]uint64:
+		resizeSlice(v, n)
+	case *[]int64:
+		resizeSlice(v, n)
+	case *[]float32:
+		resizeSlice(v, n)
+	case *[]float64:
+		resizeSlice(v, n)
+	case *[]complex64:
+		resizeSlice(v, n)
+	case *[]complex128:
+		resizeSlice(v, n)
+	default:
+		return false
+	}
+	return true
+}
+
 // parray2type accepts a pointer to a slice of numeric values and returns the
 // matching RetType from the GetData library and an unsafe.Pointer to the
-// first value in the underlying array.
+// first value in the underlying array (nil if the slice is empty).
 // Differs from array2type in that you use this if you want to be able to resize.
 func parray2type(a interface{}) (RetType, unsafe.Pointer) {
 	switch v := a.(type) {
 	case *[]uint8:
-		return UINT8, unsafe.Pointer(&(*v)[0])
+		return UINT8, firstElement(*v)
 	case *[]int8:
-		return INT8, unsafe.Pointer(&(*v)[0])
+		return INT8, firstElement(*v)
 	case *[]uint16:
-		return UINT16, unsafe.Pointer(&(*v)[0])
+		return UINT16, firstElement(*v)
 	case *[]int16:
-		return INT16, unsafe.Pointer(&(*v)[0])
+		return INT16, firstElement(*v)
 	case *[]uint32:
-		return UINT32, unsafe.Pointer(&(*v)[0])
+		return UINT32, firstElement(*v)
 	case *[]int32:
-		return INT32, unsafe.Pointer(&(*v)[0])
+		return INT32, firstElement(*v)
 	case *[]uint64:
-		return UINT64, unsafe.Pointer(&(*v)[0])
+		return UINT64, firstElement(*v)
 	case *[]int64:
-		return INT64, unsafe.Pointer(&(*v)[0])
+		return INT64, firstElement(*v)
 	case *[]float32:
-		return FLOAT32, unsafe.Pointer(&(*v)[0])
+		return FLOAT32, firstElement(*v)
 	case *[]float64:
-		return FLOAT64, unsafe.Pointer(&(*v)[0])
+		return FLOAT64, firstElement(*v)
 	case *[]complex64:
-		return COMPLEX64, unsafe.Pointer(&(*v)[0])
+		return COMPLEX64, firstElement(*v)
 	case *[]complex128:
-		return COMPLEX128, unsafe.Pointer(&(*v)[0])
+		return COMPLEX128, firstElement(*v)
 	case *[]string:
-		return STRING, unsafe.Pointer(&(*v)[0])
+		return STRING, firstElement(*v)
 	default:
 		return UNKNOWN, nil
 	}
@@ -116,35 +171,35 @@ func parray2type(a interface{}) (RetType, unsafe.Pointer) {
 
 // array2type accepts a slice of numeric values and returns the
 // matching RetType from the GetData library and an unsafe.Pointer to the
-// first value in the underlying array.
+// first value in the underlying array (nil if the slice is empty).
 func array2type(a interface{}) (RetType, unsafe.Pointer, int) {
 	switch v := a.(type) {
 	case []uint8:
-		return UINT8, unsafe.Pointer(&v[0]), len(v)
+		return UINT8, firstElement(v), len(v)
 	case []int8:
-		return INT8, unsafe.Pointer(&v[0]), len(v)
+		return INT8, firstElement(v), len(v)
 	case []uint16:
-		return UINT16, unsafe.Pointer(&v[0]), len(v)
+		return UINT16, firstElement(v), len(v)
 	case []int16:
-		return INT16, unsafe.Pointer(&v[0]), len(v)
+		return INT16, firstElement(v), len(v)
 	case []uint32:
-		return UINT32, unsafe.Pointer(&v[0]), len(v)
+		return UINT32, firstElement(v), len(v)
 	case []int32:
-		return INT32, unsafe.Pointer(&v[0]), len(v)
+		return INT32, firstElement(v), len(v)
 	case []uint64:
-		return UINT64, unsafe.Pointer(&v[0]), len(v)
+		return UINT64, firstElement(v), len(v)
 	case []int64:
-		return INT64, unsafe.Pointer(&v[0]), len(v)
+		return INT64, firstElement(v), len(v)
 	case []float32:
-		return FLOAT32, unsafe.Pointer(&v[0]), len(v)
+		return FLOAT32, firstElement(v), len(v)
 	case []float64:
-		return FLOAT64, unsafe.Pointer(&v[0]), len(v)
+		return FLOAT64, firstElement(v), len(v)
 	case []complex64:
-		return COMPLEX64, unsafe.Pointer(&v[0]), len(v)
+		return COMPLEX64, firstElement(v), len(v)
 	case []complex128:
-		return COMPLEX128, unsafe.Pointer(&v[0]), len(v)
+		return COMPLEX128, firstElement(v), len(v)
 	case []string:
-		return STRING, unsafe.Pointer(&v[0]), len(v)
+		return STRING, firstElement(v), len(v)
 	default:
 		return UNKNOWN, nil, 0
 	}
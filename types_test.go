@@ -1,3 +1,5 @@
+//go:build !purego
+
 package getdata
 
 import (
@@ -71,4 +73,12 @@ func TestTypeInference(t *testing.T) {
 	if tval, ptr, _ := array2type(wrong); tval != UNKNOWN || ptr != nil {
 		t.Errorf("array2type(val) returns 0x%x, %p; want UNKNOWN=0x%x, nil", tval, ptr, UNKNOWN)
 	}
+
+	var emptyFloats []float64
+	if tval, ptr := parray2type(&emptyFloats); tval != FLOAT64 || ptr != nil {
+		t.Errorf("parray2type(&[]float64{}) returns 0x%x, %p; want FLOAT64=0x%x, nil", tval, ptr, FLOAT64)
+	}
+	if tval, ptr, n := array2type(emptyFloats); tval != FLOAT64 || ptr != nil || n != 0 {
+		t.Errorf("array2type([]float64{}) returns 0x%x, %p, %d; want FLOAT64=0x%x, nil, 0", tval, ptr, n, FLOAT64)
+	}
 }
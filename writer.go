@@ -0,0 +1,248 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"fmt"
+	"time"
+)
+
+// PartialWriteError reports a flush that stored only some of the buffered samples
+// of a field. The unwritten samples remain buffered, so the flush may be retried.
+type PartialWriteError struct {
+	Field   string // the field being written
+	Written int    // the number of samples persisted by this flush
+	Pending int    // the number of samples still buffered
+	Err     error  // the underlying error, if any
+}
+
+func (e *PartialWriteError) Error() string {
+	msg := fmt.Sprintf("Wrote %d of %d samples to field %s", e.Written, e.Written+e.Pending, e.Field)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+// FieldWriter appends samples to the end of a field, buffering them in Go and
+// writing them with PutData (followed by Sync) when bufferSize samples are buffered
+// or, if flushInterval is non-zero, when a Write finds that much time has passed
+// since the last flush. Call Flush or Close when done.
+type FieldWriter[T Numeric] struct {
+	df            *Dirfile
+	fieldcode     string
+	bufferSize    int
+	flushInterval time.Duration
+	lastFlush     time.Time
+	pos           int // sample number where the buffer will be written
+	buf           []T
+	written       int
+}
+
+// NewFieldWriter returns a FieldWriter appending to fieldcode, starting at its
+// current end of field.
+func NewFieldWriter[T Numeric](df *Dirfile, fieldcode string, bufferSize int,
+	flushInterval time.Duration) (*FieldWriter[T], error) {
+	if bufferSize <= 0 {
+		return nil, errZeroLength("NewFieldWriter", fieldcode)
+	}
+	eof := df.EoF(fieldcode)
+	if eof < 0 {
+		return nil, df.opError("NewFieldWriter", fieldcode)
+	}
+	return &FieldWriter[T]{
+		df:            df,
+		fieldcode:     fieldcode,
+		bufferSize:    bufferSize,
+		flushInterval: flushInterval,
+		lastFlush:     time.Now(),
+		pos:           eof,
+		buf:           make([]T, 0, bufferSize),
+	}, nil
+}
+
+// Write buffers the samples, flushing if a threshold is reached
+func (w *FieldWriter[T]) Write(samples ...T) error {
+	w.buf = append(w.buf, samples...)
+	if len(w.buf) >= w.bufferSize ||
+		(w.flushInterval > 0 && time.Since(w.lastFlush) >= w.flushInterval) {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes all buffered samples to the field and syncs it to disk
+func (w *FieldWriter[T]) Flush() error {
+	w.lastFlush = time.Now()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	n, err := putBuffered(w.df, w.fieldcode, w.pos, &w.buf)
+	w.pos += n
+	w.written += n
+	if err != nil {
+		return err
+	}
+	return w.df.Sync(w.fieldcode)
+}
+
+// Close flushes any buffered samples. The Dirfile itself is left open.
+func (w *FieldWriter[T]) Close() error {
+	return w.Flush()
+}
+
+// Written returns the total number of samples persisted by this writer
+func (w *FieldWriter[T]) Written() int {
+	return w.written
+}
+
+// Buffered returns the number of samples waiting to be written
+func (w *FieldWriter[T]) Buffered() int {
+	return len(w.buf)
+}
+
+// putBuffered writes *buf to fieldcode starting at sample pos, and removes the
+// samples actually written from *buf. A short write is returned as a
+// *PartialWriteError.
+func putBuffered[T Numeric](df *Dirfile, fieldcode string, pos int, buf *[]T) (int, error) {
+	nbuf := len(*buf)
+	n, err := df.PutData(fieldcode, 0, pos, *buf)
+	if n > nbuf {
+		n = nbuf
+	}
+	*buf = (*buf)[:copy(*buf, (*buf)[n:])]
+	if n < nbuf {
+		return n, &PartialWriteError{Field: fieldcode, Written: n, Pending: nbuf - n, Err: err}
+	}
+	return n, nil
+}
+
+// FrameWriter appends whole frames to a set of RAW fields, which must all end
+// at the same frame. Each frame is checked for the right number of samples per
+// field before it is buffered, so frames are never split between fields from the
+// caller's perspective. Buffering and flushing work as for FieldWriter, with the
+// buffer size counted in frames.
+type FrameWriter[T Numeric] struct {
+	df            *Dirfile
+	fields        []string
+	spf           []int
+	bufferFrames  int
+	flushInterval time.Duration
+	lastFlush     time.Time
+	pos           []int // per field, the sample number where its buffer will be written
+	buf           [][]T
+	nframes       int // complete frames buffered
+}
+
+// NewFrameWriter returns a FrameWriter appending to the given RAW fields, starting
+// at their common end of field.
+func NewFrameWriter[T Numeric](df *Dirfile, fields []string, bufferFrames int,
+	flushInterval time.Duration) (*FrameWriter[T], error) {
+	if len(fields) == 0 || bufferFrames <= 0 {
+		return nil, fmt.Errorf("NewFrameWriter needs at least one field and a positive buffer size")
+	}
+	w := &FrameWriter[T]{
+		df:            df,
+		fields:        fields,
+		spf:           make([]int, len(fields)),
+		bufferFrames:  bufferFrames,
+		flushInterval: flushInterval,
+		lastFlush:     time.Now(),
+		pos:           make([]int, len(fields)),
+		buf:           make([][]T, len(fields)),
+	}
+	endFrame := -1
+	for i, field := range fields {
+		if et := df.EntryType(field); et != RAWENTRY {
+			return nil, fmt.Errorf("FrameWriter field %s has type 0x%x, not RAW", field, et)
+		}
+		w.spf[i] = df.SPF(field)
+		eof := df.EoF(field)
+		if w.spf[i] <= 0 || eof < 0 {
+			return nil, df.opError("NewFrameWriter", field)
+		}
+		if eof%w.spf[i] != 0 {
+			return nil, fmt.Errorf("Field %s ends at sample %d, partway through a frame of %d samples",
+				field, eof, w.spf[i])
+		}
+		if endFrame >= 0 && eof/w.spf[i] != endFrame {
+			return nil, fmt.Errorf("Field %s ends at frame %d, but field %s ends at frame %d",
+				field, eof/w.spf[i], fields[0], endFrame)
+		}
+		endFrame = eof / w.spf[i]
+		w.pos[i] = eof
+		w.buf[i] = make([]T, 0, bufferFrames*w.spf[i])
+	}
+	return w, nil
+}
+
+// WriteFrame buffers one frame, given as one slice of SPF samples per field (in
+// the order the fields were given to NewFrameWriter), flushing if a threshold is
+// reached. If the frame is malformed, nothing is buffered.
+func (w *FrameWriter[T]) WriteFrame(frame [][]T) error {
+	if len(frame) != len(w.fields) {
+		return fmt.Errorf("WriteFrame given %d fields, want %d", len(frame), len(w.fields))
+	}
+	for i, samples := range frame {
+		if len(samples) != w.spf[i] {
+			return fmt.Errorf("WriteFrame given %d samples for field %s, want %d",
+				len(samples), w.fields[i], w.spf[i])
+		}
+	}
+	for i, samples := range frame {
+		w.buf[i] = append(w.buf[i], samples...)
+	}
+	w.nframes++
+	if w.nframes >= w.bufferFrames ||
+		(w.flushInterval > 0 && time.Since(w.lastFlush) >= w.flushInterval) {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes all buffered frames and syncs the fields to disk. If a field is
+// only partly written, the first such *PartialWriteError is returned and the
+// remaining samples stay buffered for the next Flush.
+func (w *FrameWriter[T]) Flush() error {
+	w.lastFlush = time.Now()
+	var firstErr error
+	for i, field := range w.fields {
+		if len(w.buf[i]) == 0 {
+			continue
+		}
+		n, err := putBuffered(w.df, field, w.pos[i], &w.buf[i])
+		w.pos[i] += n
+		if err == nil {
+			err = w.df.Sync(field)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		w.nframes = 0
+	}
+	return firstErr
+}
+
+// Close flushes any buffered frames. The Dirfile itself is left open.
+func (w *FrameWriter[T]) Close() error {
+	return w.Flush()
+}
+
+// Frames returns the number of complete frames stored in every field, counting
+// only samples already persisted.
+func (w *FrameWriter[T]) Frames() int {
+	frames := -1
+	for i := range w.fields {
+		if f := w.pos[i] / w.spf[i]; frames < 0 || f < frames {
+			frames = f
+		}
+	}
+	return frames
+}
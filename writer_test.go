@@ -0,0 +1,111 @@
+//go:build !purego
+
+package getdata
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFieldWriter(t *testing.T) {
+	dir := "dirfile_writer"
+	createTestDirfile(dir)
+	defer removeTestDirfile(dir)
+
+	d, err := OpenDirfile(dir, RDWR)
+	if err != nil {
+		t.Fatal("Could not open dirfile read-write:", err)
+	}
+	defer d.Close()
+
+	w, err := NewFieldWriter[int16](&d, "data", 4, 0)
+	if err != nil {
+		t.Fatal("NewFieldWriter failed:", err)
+	}
+	if err = w.Write(81, 82, 83); err != nil {
+		t.Error("FieldWriter.Write failed:", err)
+	}
+	if w.Buffered() != 3 || w.Written() != 0 {
+		t.Errorf("FieldWriter buffered %d, wrote %d samples, want 3, 0", w.Buffered(), w.Written())
+	}
+	if err = w.Write(84, 85); err != nil {
+		t.Error("FieldWriter.Write failed:", err)
+	}
+	if w.Buffered() != 0 || w.Written() != 5 {
+		t.Errorf("FieldWriter buffered %d, wrote %d samples, want 0, 5", w.Buffered(), w.Written())
+	}
+	if err = w.Write(86); err != nil {
+		t.Error("FieldWriter.Write failed:", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Error("FieldWriter.Close failed:", err)
+	}
+	if eof := d.EoF("data"); eof != 86 {
+		t.Errorf("After FieldWriter, EoF=%d, want 86", eof)
+	}
+	data, err := GetData[int16](&d, "data", 0, 78, 0, 8)
+	if err != nil || len(data) != 8 {
+		t.Fatalf("GetData after FieldWriter returned %v, %v", data, err)
+	}
+	for i, v := range data {
+		if v != int16(79+i) {
+			t.Errorf("After FieldWriter, d[%d]=%d, want %d", 78+i, v, 79+i)
+		}
+	}
+
+	// "data" now ends partway through a frame
+	if _, err = NewFrameWriter[int16](&d, []string{"data"}, 2, 0); err == nil {
+		t.Error("NewFrameWriter on a field ending mid-frame did not return error")
+	}
+	if _, err = NewFrameWriter[int16](&d, []string{"lincom"}, 2, 0); err == nil {
+		t.Error("NewFrameWriter on a LINCOM field did not return error")
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dirfile_framewriter")
+	d, err := OpenDirfile(dir, RDWR|CREAT|TRUNC)
+	if err != nil {
+		t.Fatal("Could not create dirfile:", err)
+	}
+	defer d.Close()
+	if err = d.AddRaw("a", INT32, 1, 0); err != nil {
+		t.Fatal("Could not add RAW field a:", err)
+	}
+	if err = d.AddRaw("b", FLOAT64, 3, 0); err != nil {
+		t.Fatal("Could not add RAW field b:", err)
+	}
+
+	w, err := NewFrameWriter[float64](&d, []string{"a", "b"}, 2, 0)
+	if err != nil {
+		t.Fatal("NewFrameWriter failed:", err)
+	}
+	if err = w.WriteFrame([][]float64{{1}, {1, 2}}); err == nil {
+		t.Error("FrameWriter.WriteFrame with a short frame did not return error")
+	}
+	if err = w.WriteFrame([][]float64{{1}}); err == nil {
+		t.Error("FrameWriter.WriteFrame with too few fields did not return error")
+	}
+	for f := 0; f < 3; f++ {
+		x := float64(f)
+		if err = w.WriteFrame([][]float64{{x}, {x, x + 0.25, x + 0.5}}); err != nil {
+			t.Errorf("FrameWriter.WriteFrame(%d) failed: %v", f, err)
+		}
+	}
+	if w.Frames() != 2 {
+		t.Errorf("FrameWriter.Frames()=%d before Close, want 2", w.Frames())
+	}
+	if err = w.Close(); err != nil {
+		t.Error("FrameWriter.Close failed:", err)
+	}
+	if w.Frames() != 3 {
+		t.Errorf("FrameWriter.Frames()=%d after Close, want 3", w.Frames())
+	}
+	if eof := d.EoF("b"); eof != 9 {
+		t.Errorf("After FrameWriter, EoF(b)=%d, want 9", eof)
+	}
+	b, err := GetData[float64](&d, "b", 2, 0, 1, 0)
+	if err != nil || len(b) != 3 || b[1] != 2.25 {
+		t.Errorf("After FrameWriter, frame 2 of b=%v, %v, want [2 2.25 2.5]", b, err)
+	}
+}